@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+export writes every group to w, each one followed by its members and its images. The groups are
+read one page at a time and written as we go, so a stage of any size fits in memory.
+
+It is not a snapshot: a group that is changed while we export may be exported before or after the change
+*/
+func (t *transfer) export(w io.Writer) (*progress, error) {
+	p := &progress{action: "exported"}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw) //Encode ends every record with a newline
+
+	nextKey := ""
+	for {
+		groups, nk, err := t.groups.ScanGroups(t.pageSize, nextKey)
+		if err != nil {
+			return p, err
+		}
+
+		for _, g := range groups {
+			if err := t.exportGroup(enc, g, p); err != nil {
+				return p, err
+			}
+		}
+		p.report()
+
+		if nk == "" {
+			return p, bw.Flush()
+		}
+		nextKey = nk
+	}
+}
+
+func (t *transfer) exportGroup(enc *json.Encoder, g models.Group, p *progress) error {
+	if err := enc.Encode(record{Type: groupRecord, Group: &g}); err != nil {
+		return err
+	}
+	p.groups++
+
+	members, err := t.members.GetMembers(g.Id)
+	if err != nil {
+		return err
+	}
+	for i := range members {
+		if err := enc.Encode(record{Type: memberRecord, Member: &members[i]}); err != nil {
+			return err
+		}
+		p.members++
+	}
+
+	nextKey := ""
+	for {
+		images, nk, err := t.images.GetGroupImages(g.Id, t.pageSize, nextKey)
+		if err != nil {
+			return err
+		}
+
+		for _, image := range images {
+			if t.objectsDir != "" {
+				if err := t.download(image); err != nil {
+					return err
+				}
+			}
+			if err := enc.Encode(record{Type: imageRecord, Image: image}); err != nil {
+				return err
+			}
+			p.images++
+		}
+
+		if nk == "" {
+			return nil
+		}
+		nextKey = nk
+	}
+}
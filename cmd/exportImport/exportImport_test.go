@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// fakeS3 keeps the objects of a bucket and their tagging in maps
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+	tagging map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string][]byte), tagging: make(map[string]string)}
+}
+
+func (f *fakeS3) GetObject(in *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	b, ok := f.objects[aws.StringValue(in.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(b))}, nil
+}
+
+func (f *fakeS3) HeadObject(in *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	if _, ok := f.objects[aws.StringValue(in.Key)]; !ok {
+		return nil, awserr.New("NotFound", "not found", nil)
+	}
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (f *fakeS3) PutObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	b, _ := ioutil.ReadAll(in.Body)
+	f.objects[aws.StringValue(in.Key)] = b
+	f.tagging[aws.StringValue(in.Key)] = aws.StringValue(in.Tagging)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newTransfer() *transfer {
+	return &transfer{
+		groups:   groupsAccess.NewMemoryRepo(),
+		members:  membershipsAccess.NewMemoryRepo(),
+		images:   imagesAccess.NewMemoryRepo(),
+		tags:     tagsAccess.NewMemoryRepo(),
+		pageSize: 1, //so that we go through every page
+	}
+}
+
+// newSource creates a public group with tags and a private group, each with an owner and an image
+func newSource(t *testing.T) *transfer {
+	t.Helper()
+
+	src := newTransfer()
+	for _, g := range []models.Group{
+		{Id: "public", UserId: "alice", Name: "Public", Timestamp: "2021-05-01T12:00:00.000000000Z", Tags: []string{"cats"}},
+		{Id: "private", UserId: "bob", Name: "Private", Timestamp: "2021-05-01T12:00:01.000000000Z", Visibility: models.VisibilityPrivate},
+	} {
+		g, err := src.groups.CreateGroup(g)
+		if err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+
+		if _, err := src.members.AddMember(models.Membership{GroupId: g.Id, UserId: g.UserId, Role: models.RoleOwner}); err != nil {
+			t.Fatalf("AddMember failed: %v", err)
+		}
+		image := imagesAccess.ImageRecord{
+			"groupId":   g.Id,
+			"imageId":   g.Id + "-image",
+			"timestamp": g.Timestamp,
+			"title":     "An image of " + g.Name,
+			"imageUrl":  "https://source.s3.amazonaws.com/" + g.Id + "-image",
+		}
+		if err := src.images.ImportImage(image); err != nil {
+			t.Fatalf("ImportImage failed: %v", err)
+		}
+		if err := src.groups.CountImages(g.Id, g.Id+"-event", 1); err != nil {
+			t.Fatalf("CountImages failed: %v", err)
+		}
+	}
+
+	return src
+}
+
+func exportSource(t *testing.T, src *transfer) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	p, err := src.export(&buf)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if p.groups != 2 || p.members != 2 || p.images != 2 {
+		t.Errorf("exported %+v, want 2 groups, 2 members and 2 images", *p)
+	}
+
+	return buf.String()
+}
+
+func TestExportThenImport(t *testing.T) {
+	src := newSource(t)
+	export := exportSource(t, src)
+
+	if lines := strings.Count(export, "\n"); lines != 6 {
+		t.Errorf("the export has %d lines, want 6", lines)
+	}
+
+	dst := newTransfer()
+	p, err := dst.importAll(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if p.groups != 2 || p.members != 2 || p.images != 2 || p.skipped != 0 || p.failed != 0 {
+		t.Errorf("imported %+v, want 2 groups, 2 members and 2 images", *p)
+	}
+
+	for _, id := range []string{"public", "private"} {
+		want, _ := src.groups.GetGroup(id)
+		got, err := dst.groups.GetGroup(id)
+		if err != nil {
+			t.Fatalf("GetGroup(%s) after the import failed: %v", id, err)
+		}
+		//the groupStats function of the target stage counts the images again
+		if got.ImageCount != 0 {
+			t.Errorf("group %s was imported with imageCount %d, want 0", id, got.ImageCount)
+		}
+		want.ImageCount = 0
+		if got.Name != want.Name || got.Version != want.Version || got.GetVisibility() != want.GetVisibility() {
+			t.Errorf("group %s was imported as %+v, want %+v", id, got, want)
+		}
+
+		if m, err := dst.members.GetMember(id, want.UserId); err != nil || m.Role != models.RoleOwner {
+			t.Errorf("the owner of %s was imported as %+v, %v", id, m, err)
+		}
+
+		images, _, _ := dst.images.GetGroupImages(id, 10, "")
+		if len(images) != 1 || images[0]["imageUrl"] != "https://source.s3.amazonaws.com/"+id+"-image" {
+			t.Errorf("the images of %s were imported as %+v, want the image referencing the source bucket", id, images)
+		}
+	}
+
+	if ids, _, _ := dst.tags.GetGroupIdsByTag("cats", 10, "", groupsAccess.NewestFirst); len(ids) != 1 || ids[0] != "public" {
+		t.Errorf("the imported public group is not tagged: %v", ids)
+	}
+}
+
+func TestImportCanBeRunAgain(t *testing.T) {
+	export := exportSource(t, newSource(t))
+	dst := newTransfer()
+
+	//the first run stops half way, after the first group and its owner
+	half := strings.Join(strings.SplitAfter(export, "\n")[:2], "")
+	if _, err := dst.importAll(strings.NewReader(half)); err != nil {
+		t.Fatalf("import of half the file failed: %v", err)
+	}
+
+	p, err := dst.importAll(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if p.groups != 1 || p.images != 2 || p.skipped != 1 || p.failed != 0 {
+		t.Errorf("the second run imported %+v, want 1 group and 2 images, and 1 skipped", *p)
+	}
+
+	groups, _, _ := dst.groups.ScanGroups(10, "")
+	if len(groups) != 2 {
+		t.Errorf("after two runs there are %d groups, want 2", len(groups))
+	}
+}
+
+func TestDryRunImportsNothing(t *testing.T) {
+	export := exportSource(t, newSource(t))
+
+	dst := newTransfer()
+	dst.dryRun = true
+	p, err := dst.importAll(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	if groups, _, _ := dst.groups.ScanGroups(10, ""); len(groups) != 0 {
+		t.Errorf("a dry run imported %d groups", len(groups))
+	}
+	//it should still count what it would import
+	if p.groups != 2 || p.members != 2 || p.images != 2 {
+		t.Errorf("a dry run counted %+v, want 2 groups, 2 members and 2 images", *p)
+	}
+}
+
+func TestBrokenLines(t *testing.T) {
+	dst := newTransfer()
+
+	//a record we can't import is counted as failed, and the import goes on
+	p, err := dst.importAll(strings.NewReader(`{"type":"album"}` + "\n" + `{"type":"image","image":{"imageId":"no-key"}}` + "\n"))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if p.failed != 2 {
+		t.Errorf("failed = %d, want 2", p.failed)
+	}
+
+	//a line that is not a record means the file is broken
+	if _, err := dst.importAll(strings.NewReader("not json\n")); err == nil {
+		t.Error("the import of a broken file did not fail")
+	}
+}
+
+func TestObjectsAreCopied(t *testing.T) {
+	src := newSource(t)
+	src.s3Client, src.bucket, src.objectsDir = newFakeS3(), "source", t.TempDir()
+	src.s3Client.(*fakeS3).objects["public-image"] = []byte("public original")
+	src.s3Client.(*fakeS3).objects["private-image"] = []byte("private original")
+
+	export := exportSource(t, src)
+
+	dst := newTransfer()
+	target := newFakeS3()
+	dst.s3Client, dst.bucket, dst.objectsDir = target, "target", src.objectsDir
+	if _, err := dst.importAll(strings.NewReader(export)); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	if string(target.objects["public-image"]) != "public original" || string(target.objects["private-image"]) != "private original" {
+		t.Errorf("the originals were not copied: %v", target.objects)
+	}
+	if target.tagging["private-image"] != "visibility=private" || target.tagging["public-image"] != "" {
+		t.Errorf("the originals were tagged %v, want only the private one tagged", target.tagging)
+	}
+
+	images, _, _ := dst.images.GetGroupImages("public", 10, "")
+	if len(images) != 1 || images[0]["imageUrl"] != "https://target.s3.amazonaws.com/public-image" {
+		t.Errorf("the imported image is %+v, want it to reference the target bucket", images)
+	}
+
+	if _, err := dst.objectPath(imagesAccess.ImageRecord{"imageId": "../secrets"}); err == nil {
+		t.Error("an imageId could point outside of the objects directory")
+	}
+}
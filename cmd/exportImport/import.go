@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The longest line we can read. A group or an image is a few KB at most
+const maxLineSize = 1024 * 1024
+
+/*
+importAll replays an export into the repositories of t. A line that can't be written is logged and
+counted as failed, and we keep going. A line that is not a record means the file is broken, so we stop
+*/
+func (t *transfer) importAll(r io.Reader) (*progress, error) {
+	p := &progress{action: "imported"}
+	if t.dryRun {
+		p.action = "would import"
+	}
+
+	i := &importer{transfer: t, p: p, private: make(map[string]bool)}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return p, fmt.Errorf("line %d is not a record: %w", line, err)
+		}
+
+		if err := i.importRecord(rec); err != nil {
+			log.Printf("line %d: failed to import the %s: %s", line, rec.Type, err.Error())
+			p.failed++
+		}
+
+		if line%1000 == 0 {
+			p.report()
+		}
+	}
+
+	return p, sc.Err()
+}
+
+// importer is the state of one import
+type importer struct {
+	*transfer
+	p       *progress
+	private map[string]bool // the groups we read and whether they are private, for the originals of their images
+}
+
+func (i *importer) importRecord(rec record) error {
+	switch rec.Type {
+	case groupRecord:
+		if rec.Group != nil {
+			return i.importGroup(*rec.Group)
+		}
+	case memberRecord:
+		if rec.Member != nil {
+			return i.importMember(*rec.Member)
+		}
+	case imageRecord:
+		if rec.Image != nil {
+			return i.importImage(rec.Image)
+		}
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+	return errors.New("the record has no " + rec.Type)
+}
+
+func (i *importer) importGroup(g models.Group) error {
+	i.private[g.Id] = g.ImagesPrivate()
+
+	//the groupStats function of the target stage counts the images again as we import them
+	g.ImageCount, g.LastImageAt, g.CoverImageId = 0, "", ""
+
+	if i.dryRun {
+		log.Printf("would import group %s", g.Id)
+		i.p.groups++
+		return nil
+	}
+
+	err := i.groups.ImportGroup(g)
+	if groupsAccess.IsConflict(err) {
+		/*
+			The group is already there, maybe from an import that stopped before it could tag it. We
+			tag the group that is there, since it may have changed since it was imported
+		*/
+		i.p.skipped++
+		if g, err = i.groups.GetGroup(g.Id); err != nil {
+			return err
+		}
+		i.private[g.Id] = g.ImagesPrivate()
+	} else if err != nil {
+		return err
+	} else {
+		i.p.groups++
+	}
+
+	//tagging a group twice does nothing
+	if g.Listed() && len(g.Tags) > 0 {
+		return i.tags.TagGroup(g, g.Tags)
+	}
+	return nil
+}
+
+// importMember adds a member to a group. Adding a member that is already there just writes it again
+func (i *importer) importMember(m models.Membership) error {
+	if i.dryRun {
+		log.Printf("would add %s to group %s as %s", m.UserId, m.GroupId, m.Role)
+		i.p.members++
+		return nil
+	}
+
+	if _, err := i.members.AddMember(m); err != nil {
+		return err
+	}
+	i.p.members++
+
+	return nil
+}
+
+func (i *importer) importImage(image imagesAccess.ImageRecord) error {
+	groupId, _ := image["groupId"].(string)
+	imageId, _ := image["imageId"].(string)
+
+	if i.objectsDir != "" {
+		//the original is now in the bucket of the target stage
+		image["imageUrl"] = "https://" + i.bucket + ".s3.amazonaws.com/" + imageId
+	}
+
+	if i.dryRun {
+		log.Printf("would import image %s of group %s", imageId, groupId)
+		i.p.images++
+		return nil
+	}
+
+	//we write the row before the original, like createImage does
+	err := i.images.ImportImage(image)
+	if err == imagesAccess.ErrImageExists {
+		i.p.skipped++
+	} else if err != nil {
+		return err
+	} else {
+		i.p.images++
+	}
+
+	if i.objectsDir == "" {
+		return nil
+	}
+
+	private, err := i.isPrivate(groupId)
+	if err != nil {
+		return err
+	}
+
+	return i.upload(image, private)
+}
+
+// isPrivate tells if a group is private. The images of a group come after it, so we usually know already
+func (i *importer) isPrivate(groupId string) (bool, error) {
+	if private, ok := i.private[groupId]; ok {
+		return private, nil
+	}
+
+	g, err := i.groups.GetGroup(groupId)
+	if err != nil {
+		return false, err
+	}
+	i.private[groupId] = g.ImagesPrivate()
+
+	return g.ImagesPrivate(), nil
+}
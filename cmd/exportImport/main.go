@@ -0,0 +1,124 @@
+/*
+exportImport copies the groups of a stage, with their members and images, into a newline-delimited
+JSON file, and replays such a file into another stage. We use it to move data from dev to staging and
+to keep offline backups.
+
+	GROUPS_TABLE=Groups-dev MEMBERSHIPS_TABLE=Memberships-dev IMAGES_TABLE=Images-dev AWS_REGION=ca-central-1 \
+		go run ./cmd/exportImport export -file dev.ndjson
+
+	GROUPS_TABLE=Groups-staging MEMBERSHIPS_TABLE=Memberships-staging IMAGES_TABLE=Images-staging \
+	GROUP_TAGS_TABLE=GroupTags-staging TAG_COUNTS_TABLE=TagCounts-staging AWS_REGION=ca-central-1 \
+		go run ./cmd/exportImport import -file dev.ndjson -dry-run
+
+Every line of the file is one group, member or image, and the members and images of a group come
+right after it. The ids are kept as they are.
+
+The images only reference their S3 objects, through their imageUrl. With -objects-dir, export also
+downloads the originals into that directory and import uploads them into the IMAGES_S3_BUCKET of the
+target stage. The thumbnails are not copied, resizeImage makes them again when the originals are uploaded.
+
+An import never overwrites a group or an image that is already there, so it can be stopped and run
+again at any time. The imageCount and cover image of the groups are not imported: the groupStats
+function of the target stage counts the images again as they are written
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "export" && os.Args[1] != "import") {
+		log.Fatal("usage: exportImport export|import [flags]")
+	}
+	mode := os.Args[1]
+
+	flags := flag.NewFlagSet(mode, flag.ExitOnError)
+	file := flags.String("file", "", "the NDJSON file to write or read, stdout or stdin when empty")
+	objectsDir := flags.String("objects-dir", "", "a directory to download the originals into, or to upload them from")
+	dryRun := flags.Bool("dry-run", false, "only log what would be imported")
+	pageSize := flags.Int64("page-size", 100, "how many groups or images to read per call")
+	flags.Parse(os.Args[2:])
+
+	t := &transfer{
+		groups:     groupsAccess.NewRepo(),
+		members:    membershipsAccess.NewRepo(),
+		images:     imagesAccess.NewRepo(),
+		tags:       tagsAccess.NewRepo(),
+		objectsDir: *objectsDir,
+		bucket:     os.Getenv("IMAGES_S3_BUCKET"),
+		dryRun:     *dryRun,
+		pageSize:   *pageSize,
+	}
+	if t.objectsDir != "" {
+		if t.bucket == "" {
+			log.Fatal("-objects-dir needs the IMAGES_S3_BUCKET to copy the originals from or to")
+		}
+		t.s3Client = s3.New(session.Must(session.NewSession()))
+	}
+
+	var err error
+	if mode == "export" {
+		err = exportTo(t, *file)
+	} else {
+		err = importFrom(t, *file)
+	}
+	if err != nil {
+		log.Fatalf("Failed to %s: %s", mode, err.Error())
+	}
+}
+
+func exportTo(t *transfer, file string) error {
+	if file == "" {
+		p, err := t.export(os.Stdout)
+		p.report()
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	p, err := t.export(f)
+	p.report()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func importFrom(t *transfer, file string) error {
+	in := os.Stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	p, err := t.importAll(in)
+	p.report()
+	if err != nil {
+		return err
+	}
+
+	if p.failed > 0 {
+		return fmt.Errorf("%d lines could not be imported, run the import again to retry them", p.failed)
+	}
+	return nil
+}
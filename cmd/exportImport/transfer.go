@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The types of the lines of an export
+const (
+	groupRecord  = "group"
+	memberRecord = "member"
+	imageRecord  = "image"
+)
+
+// record is one line of an export. Only the field of its type is set
+type record struct {
+	Type   string                   `json:"type"`
+	Group  *models.Group            `json:"group,omitempty"`
+	Member *models.Membership       `json:"member,omitempty"`
+	Image  imagesAccess.ImageRecord `json:"image,omitempty"`
+}
+
+// transfer reads or writes a stage through our repositories, and copies the originals when objectsDir is set
+type transfer struct {
+	groups     groupsAccess.Repository
+	members    membershipsAccess.Repository
+	images     imagesAccess.Repository
+	tags       tagsAccess.Repository
+	s3Client   s3iface.S3API
+	bucket     string
+	objectsDir string
+	dryRun     bool
+	pageSize   int64
+}
+
+// progress counts what happened to the lines of an export or an import. It is logged as we go
+type progress struct {
+	action  string // exported, imported or would import
+	groups  int
+	members int
+	images  int
+	skipped int // already in the target stage
+	failed  int
+}
+
+func (p *progress) report() {
+	log.Printf("%s %d groups, %d members and %d images, skipped %d, failed %d", p.action, p.groups, p.members, p.images, p.skipped, p.failed)
+}
+
+/*
+objectPath is where the original of an image is in objectsDir. The imageId comes from a file that
+could have been edited, so it must not be able to point outside of the directory
+*/
+func (t *transfer) objectPath(image imagesAccess.ImageRecord) (string, error) {
+	imageId, _ := image["imageId"].(string)
+	if imageId == "" || imageId != filepath.Base(imageId) || imageId == "." || imageId == ".." {
+		return "", errors.New("the image has no valid imageId")
+	}
+	return filepath.Join(t.objectsDir, imageId), nil
+}
+
+// download saves the original of an image into objectsDir, unless an export that stopped half way already did
+func (t *transfer) download(image imagesAccess.ImageRecord) error {
+	path, err := t.objectPath(image)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	obj, err := t.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(filepath.Base(path)),
+	})
+	//the row of an image is created before the client uploads it, so the object may never have been uploaded
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		log.Printf("image %s has no original, only its row is exported", filepath.Base(path))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+
+	//we write to a temporary file first, so a download that is cut half way is not taken for a whole one
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, obj.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+/*
+upload puts the original of an image from objectsDir into the bucket of the target stage, unless it is
+already there. The originals of private groups are tagged so our bucket policy keeps them private
+*/
+func (t *transfer) upload(image imagesAccess.ImageRecord, private bool) error {
+	path, err := t.objectPath(image)
+	if err != nil {
+		return err
+	}
+	key := aws.String(filepath.Base(path))
+
+	_, err = t.s3Client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String(t.bucket), Key: key})
+	if err == nil {
+		return nil
+	}
+	//HeadObject has no body, so a missing object is a NotFound and not a NoSuchKey
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "NotFound" {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		log.Printf("image %s has no original in %s, only its row is imported", *key, t.objectsDir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    key,
+		Body:   f,
+	}
+	if private {
+		input.Tagging = aws.String(imagesAccess.VisibilityTagKey + "=" + string(models.VisibilityPrivate))
+	}
+
+	_, err = t.s3Client.PutObject(input)
+	return err
+}
@@ -0,0 +1,66 @@
+/*
+migrateTimestamps rewrites the timestamps of the Groups and Images tables in models.TimestampFormat.
+
+We used to store time.Time.String(), like "2021-05-01 12:00:00.123 +0000 UTC m=+0.01". These don't
+sort in the order they happened, and other languages can't parse them. Rows that already have the new
+format are left alone, so the migration can be stopped and run again at any time.
+
+	GROUPS_TABLE=Groups-dev IMAGES_TABLE=Images-dev AWS_REGION=ca-central-1 go run ./cmd/migrateTimestamps -dry-run
+	GROUPS_TABLE=Groups-dev IMAGES_TABLE=Images-dev AWS_REGION=ca-central-1 go run ./cmd/migrateTimestamps
+
+It also gives the public groups created before the CreatedAtIndex their listing attribute, so getGroups lists them
+*/
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func main() {
+	groupsTable := flag.String("groups-table", os.Getenv("GROUPS_TABLE"), "the Groups table, empty to skip it")
+	imagesTable := flag.String("images-table", os.Getenv("IMAGES_TABLE"), "the Images table, empty to skip it")
+	endpoint := flag.String("endpoint", os.Getenv("DYNAMODB_ENDPOINT"), "a DynamoDB endpoint, eg of DynamoDB Local")
+	dryRun := flag.Bool("dry-run", false, "only log what would be rewritten")
+	pageSize := flag.Int64("page-size", 100, "how many rows to read per Scan")
+	flag.Parse()
+
+	if *groupsTable == "" && *imagesTable == "" {
+		log.Fatal("nothing to migrate: set -groups-table and/or -images-table")
+	}
+
+	cfg := aws.NewConfig()
+	if *endpoint != "" {
+		cfg = cfg.WithEndpoint(*endpoint)
+	}
+	m := &migrator{
+		client:   dynamodb.New(session.Must(session.NewSession(cfg))),
+		dryRun:   *dryRun,
+		pageSize: *pageSize,
+	}
+
+	var failed int
+	if *groupsTable != "" {
+		p, err := m.migrateGroups(*groupsTable)
+		if err != nil {
+			log.Fatalf("Failed to migrate %s: %s", *groupsTable, err.Error())
+		}
+		failed += p.failed
+	}
+	if *imagesTable != "" {
+		p, err := m.migrateImages(*imagesTable)
+		if err != nil {
+			log.Fatalf("Failed to migrate %s: %s", *imagesTable, err.Error())
+		}
+		failed += p.failed
+	}
+
+	if failed > 0 {
+		log.Fatalf("%d rows could not be migrated, run the migration again to retry them", failed)
+	}
+}
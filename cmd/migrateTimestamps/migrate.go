@@ -0,0 +1,223 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The listing attribute that puts a public group in the CreatedAtIndex. See groupsAccess.GetAllGroups
+const (
+	listingAttr   = "listing"
+	listingPublic = "public"
+)
+
+type migrator struct {
+	client   dynamodbiface.DynamoDBAPI
+	dryRun   bool
+	pageSize int64
+}
+
+// progress counts what happened to the rows of a table. It is logged after every page
+type progress struct {
+	table    string
+	scanned  int
+	migrated int
+	skipped  int // already in the new format
+	failed   int
+}
+
+func (p *progress) report() {
+	log.Printf("%s: scanned %d, migrated %d, skipped %d, failed %d", p.table, p.scanned, p.migrated, p.skipped, p.failed)
+}
+
+/*
+newTimestamp returns old in models.TimestampFormat, and false when it already is. A row without
+a timestamp is left alone
+*/
+func newTimestamp(old string) (string, bool, error) {
+	if old == "" {
+		return old, false, nil
+	}
+
+	t, err := models.ParseTimestamp(old)
+	if err != nil {
+		return "", false, err
+	}
+
+	ts := models.FormatTimestamp(t)
+	return ts, ts != old, nil
+}
+
+// scan calls fn with every row of table, one page at a time
+func (m *migrator) scan(p *progress, fn func(item map[string]*dynamodb.AttributeValue)) error {
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		result, err := m.client.Scan(&dynamodb.ScanInput{
+			TableName:         aws.String(p.table),
+			Limit:             aws.Int64(m.pageSize),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range result.Items {
+			p.scanned++
+			fn(item)
+		}
+		p.report()
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+/*
+migrateGroups rewrites the timestamp of the groups in place, since it is not part of the key of the
+Groups table. Every update is conditional on the row still having the timestamp we read
+*/
+func (m *migrator) migrateGroups(table string) (*progress, error) {
+	p := &progress{table: table}
+
+	err := m.scan(p, func(item map[string]*dynamodb.AttributeValue) {
+		id := aws.StringValue(item["id"].S)
+		old := stringAttr(item, "timestamp")
+
+		ts, changed, err := newTimestamp(old)
+		if err != nil {
+			log.Printf("%s: group %s has a timestamp we can't read %q: %s", table, id, old, err.Error())
+			p.failed++
+			return
+		}
+
+		//the groups created before we had visibilities are public
+		visibility := stringAttr(item, "visibility")
+		needsListing := item[listingAttr] == nil && (visibility == "" || visibility == string(models.VisibilityPublic))
+
+		if !changed && !needsListing {
+			p.skipped++
+			return
+		}
+		if m.dryRun {
+			log.Printf("%s: would rewrite group %s: timestamp %q -> %q, add listing: %v", table, id, old, ts, needsListing)
+			p.migrated++
+			return
+		}
+
+		input := &dynamodb.UpdateItemInput{
+			TableName:                aws.String(table),
+			Key:                      map[string]*dynamodb.AttributeValue{"id": item["id"]},
+			UpdateExpression:         aws.String("SET #ts = :new"),
+			ConditionExpression:      aws.String("#ts = :old"),
+			ExpressionAttributeNames: map[string]*string{"#ts": aws.String("timestamp")}, //timestamp is a reserved word in DynamoDB
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":new": {S: aws.String(ts)},
+				":old": {S: aws.String(old)},
+			},
+		}
+		if old == "" {
+			input.UpdateExpression = aws.String("SET " + listingAttr + " = :listing")
+			input.ConditionExpression = aws.String("attribute_not_exists(#ts)")
+			input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{}
+		} else if needsListing {
+			input.UpdateExpression = aws.String("SET #ts = :new, " + listingAttr + " = :listing")
+		}
+		if needsListing {
+			//the group may have been made unlisted or private since we read it
+			*input.ConditionExpression += " AND (attribute_not_exists(visibility) OR visibility = :public)"
+			input.ExpressionAttributeValues[":listing"] = &dynamodb.AttributeValue{S: aws.String(listingPublic)}
+			input.ExpressionAttributeValues[":public"] = &dynamodb.AttributeValue{S: aws.String(string(models.VisibilityPublic))}
+		}
+
+		if _, err := m.client.UpdateItem(input); err != nil {
+			log.Printf("%s: failed to rewrite group %s: %s", table, id, err.Error())
+			p.failed++
+			return
+		}
+		p.migrated++
+	})
+
+	return p, err
+}
+
+/*
+migrateImages rewrites the timestamp of the images. timestamp is the range key of the Images table,
+so we can't update it: we put a copy of the row with the new timestamp and delete the old row in one
+transaction. Either both happen or none does
+*/
+func (m *migrator) migrateImages(table string) (*progress, error) {
+	p := &progress{table: table}
+
+	err := m.scan(p, func(item map[string]*dynamodb.AttributeValue) {
+		groupId := stringAttr(item, "groupId")
+		imageId := stringAttr(item, "imageId")
+		old := stringAttr(item, "timestamp")
+
+		ts, changed, err := newTimestamp(old)
+		if err != nil {
+			log.Printf("%s: image %s has a timestamp we can't read %q: %s", table, imageId, old, err.Error())
+			p.failed++
+			return
+		}
+
+		//the copies we already put can show up later in the same Scan. They are in the new format
+		if !changed {
+			p.skipped++
+			return
+		}
+		if m.dryRun {
+			log.Printf("%s: would rewrite image %s of group %s: timestamp %q -> %q", table, imageId, groupId, old, ts)
+			p.migrated++
+			return
+		}
+
+		copied := make(map[string]*dynamodb.AttributeValue, len(item))
+		for k, v := range item {
+			copied[k] = v
+		}
+		copied["timestamp"] = &dynamodb.AttributeValue{S: aws.String(ts)}
+
+		_, err = m.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{
+					Put: &dynamodb.Put{
+						TableName:           aws.String(table),
+						Item:                copied,
+						ConditionExpression: aws.String("attribute_not_exists(groupId)"), //never overwrite another image
+					},
+				},
+				{
+					Delete: &dynamodb.Delete{
+						TableName: aws.String(table),
+						Key: map[string]*dynamodb.AttributeValue{
+							"groupId":   item["groupId"],
+							"timestamp": item["timestamp"],
+						},
+						ConditionExpression: aws.String("attribute_exists(groupId)"), //the image was not deleted since we read it
+					},
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("%s: failed to rewrite image %s of group %s: %s", table, imageId, groupId, err.Error())
+			p.failed++
+			return
+		}
+		p.migrated++
+	})
+
+	return p, err
+}
+
+func stringAttr(item map[string]*dynamodb.AttributeValue, name string) string {
+	if v, ok := item[name]; ok {
+		return aws.StringValue(v.S)
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// fakeDynamoDb returns items one page at a time and records the writes we make
+type fakeDynamoDb struct {
+	dynamodbiface.DynamoDBAPI
+	items        []map[string]*dynamodb.AttributeValue
+	updates      []*dynamodb.UpdateItemInput
+	transactions []*dynamodb.TransactWriteItemsInput
+}
+
+func (f *fakeDynamoDb) Scan(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	start := 0
+	if in.ExclusiveStartKey != nil {
+		start, _ = strconv.Atoi(aws.StringValue(in.ExclusiveStartKey["next"].N))
+	}
+	end := start + int(aws.Int64Value(in.Limit))
+	if end >= len(f.items) {
+		return &dynamodb.ScanOutput{Items: f.items[start:]}, nil
+	}
+	return &dynamodb.ScanOutput{
+		Items:            f.items[start:end],
+		LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"next": {N: aws.String(strconv.Itoa(end))}},
+	}, nil
+}
+
+func (f *fakeDynamoDb) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	f.updates = append(f.updates, in)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (f *fakeDynamoDb) TransactWriteItems(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.transactions = append(f.transactions, in)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func item(attrs map[string]string) map[string]*dynamodb.AttributeValue {
+	i := make(map[string]*dynamodb.AttributeValue)
+	for k, v := range attrs {
+		i[k] = &dynamodb.AttributeValue{S: aws.String(v)}
+	}
+	return i
+}
+
+func TestNewTimestamp(t *testing.T) {
+	tests := []struct {
+		old     string
+		want    string
+		changed bool
+	}{
+		{"2021-05-01 12:00:00.123 +0000 UTC m=+0.01", "2021-05-01T12:00:00.123000000Z", true},
+		{"2021-05-01T12:00:00Z", "2021-05-01T12:00:00.000000000Z", true},
+		{"2021-05-01T12:00:00.123000000Z", "2021-05-01T12:00:00.123000000Z", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, changed, err := newTimestamp(tt.old)
+		if err != nil {
+			t.Errorf("newTimestamp(%q) failed: %v", tt.old, err)
+		}
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("newTimestamp(%q) = %q, %v, want %q, %v", tt.old, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestMigrateGroups(t *testing.T) {
+	db := &fakeDynamoDb{items: []map[string]*dynamodb.AttributeValue{
+		item(map[string]string{"id": "legacy", "timestamp": "2021-05-01 12:00:00.123 +0000 UTC m=+0.01"}),
+		item(map[string]string{"id": "private", "timestamp": "2021-05-01 12:00:00 +0000 UTC", "visibility": "private"}),
+		item(map[string]string{"id": "done", "timestamp": "2021-05-01T12:00:00.000000000Z", "visibility": "public", "listing": "public"}),
+		item(map[string]string{"id": "broken", "timestamp": "yesterday"}),
+	}}
+
+	p, err := (&migrator{client: db, pageSize: 3}).migrateGroups("Groups")
+	if err != nil {
+		t.Fatalf("migrateGroups failed: %v", err)
+	}
+
+	if p.scanned != 4 || p.migrated != 2 || p.skipped != 1 || p.failed != 1 {
+		t.Errorf("progress = %+v, want 4 scanned, 2 migrated, 1 skipped and 1 failed", *p)
+	}
+	if len(db.updates) != 2 {
+		t.Fatalf("migrateGroups made %d updates, want 2", len(db.updates))
+	}
+
+	legacy := db.updates[0]
+	if got := aws.StringValue(legacy.ExpressionAttributeValues[":new"].S); got != "2021-05-01T12:00:00.123000000Z" {
+		t.Errorf("the legacy group got timestamp %q", got)
+	}
+	if legacy.ExpressionAttributeValues[":listing"] == nil {
+		t.Error("the legacy public group did not get a listing")
+	}
+
+	if db.updates[1].ExpressionAttributeValues[":listing"] != nil {
+		t.Error("the private group got a listing")
+	}
+}
+
+func TestMigrateImages(t *testing.T) {
+	db := &fakeDynamoDb{items: []map[string]*dynamodb.AttributeValue{
+		item(map[string]string{"groupId": "g1", "imageId": "i1", "timestamp": "2021-05-01 12:00:00 +0000 UTC", "title": "a"}),
+		item(map[string]string{"groupId": "g1", "imageId": "i2", "timestamp": "2021-05-01T12:00:01.000000000Z", "title": "b"}),
+	}}
+
+	p, err := (&migrator{client: db, pageSize: 1}).migrateImages("Images")
+	if err != nil {
+		t.Fatalf("migrateImages failed: %v", err)
+	}
+
+	if p.scanned != 2 || p.migrated != 1 || p.skipped != 1 {
+		t.Errorf("progress = %+v, want 2 scanned, 1 migrated and 1 skipped", *p)
+	}
+	if len(db.transactions) != 1 {
+		t.Fatalf("migrateImages made %d transactions, want 1", len(db.transactions))
+	}
+
+	tx := db.transactions[0].TransactItems
+	put, del := tx[0].Put, tx[1].Delete
+	if got := aws.StringValue(put.Item["timestamp"].S); got != "2021-05-01T12:00:00.000000000Z" {
+		t.Errorf("the copy has timestamp %q", got)
+	}
+	if aws.StringValue(put.Item["title"].S) != "a" {
+		t.Error("the copy lost the other attributes of the image")
+	}
+	if got := aws.StringValue(del.Key["timestamp"].S); got != "2021-05-01 12:00:00 +0000 UTC" {
+		t.Errorf("the delete is for timestamp %q, want the old row", got)
+	}
+}
+
+func TestDryRunWritesNothing(t *testing.T) {
+	db := &fakeDynamoDb{items: []map[string]*dynamodb.AttributeValue{
+		item(map[string]string{"id": "g1", "groupId": "g1", "imageId": "i1", "timestamp": "2021-05-01 12:00:00 +0000 UTC"}),
+	}}
+	m := &migrator{client: db, dryRun: true, pageSize: 10}
+
+	gp, _ := m.migrateGroups("Groups")
+	ip, _ := m.migrateImages("Images")
+
+	if len(db.updates) != 0 || len(db.transactions) != 0 {
+		t.Errorf("a dry run made %d updates and %d transactions", len(db.updates), len(db.transactions))
+	}
+	if gp.migrated != 1 || ip.migrated != 1 {
+		t.Errorf("a dry run should still count what it would migrate, got %d groups and %d images", gp.migrated, ip.migrated)
+	}
+}
@@ -4,9 +4,9 @@ go 1.16
 
 require (
 	github.com/aws/aws-lambda-go v1.23.0
-	github.com/aws/aws-sdk-go v1.38.26 // indirect
-	github.com/aws/aws-xray-sdk-go v1.3.0 // indirect
+	github.com/aws/aws-sdk-go v1.38.26
+	github.com/aws/aws-xray-sdk-go v1.3.0
 	github.com/mailru/easyjson v0.7.7 // indirect
-	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
-	github.com/satori/go.uuid v1.2.0 // indirect
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	github.com/satori/go.uuid v1.2.0
 )
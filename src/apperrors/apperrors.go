@@ -0,0 +1,151 @@
+/*
+Package apperrors has the errors our data and business layers return, so that every handler can tell
+the client what went wrong the same way.
+
+Every error belongs to a Kind. The Kind decides the HTTP status the client gets (see Response), and
+errors.Is(err, ErrNotFound) etc tells the Kind of an error no matter how deep it is wrapped
+*/
+package apperrors
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// A Kind is what went wrong. It is also the code we send the client in the error body
+type Kind string
+
+const (
+	KindNotFound    Kind = "not_found"
+	KindConflict    Kind = "conflict"
+	KindValidation  Kind = "validation"
+	KindForbidden   Kind = "forbidden"
+	KindGone        Kind = "gone" // it existed but can't be used anymore, like an expired invitation
+	KindThrottled   Kind = "throttled"
+	KindUnavailable Kind = "unavailable"
+	KindInternal    Kind = "internal" // everything we did not expect
+)
+
+// The sentinels of every Kind. Use them with errors.Is, our errors are never equal to them
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("invalid request")
+	ErrForbidden   = errors.New("forbidden")
+	ErrGone        = errors.New("gone")
+	ErrThrottled   = errors.New("too many requests")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// kinds is in the order KindOf checks them
+var kinds = []struct {
+	kind     Kind
+	sentinel error
+}{
+	{KindNotFound, ErrNotFound},
+	{KindConflict, ErrConflict},
+	{KindValidation, ErrValidation},
+	{KindForbidden, ErrForbidden},
+	{KindGone, ErrGone},
+	{KindThrottled, ErrThrottled},
+	{KindUnavailable, ErrUnavailable},
+}
+
+/*
+Error is an error of a given Kind. Message is what we tell the client, so it must not leak anything
+we don't want them to see. Err is the cause, if there is one, and is only logged
+*/
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrNotFound) true for every *Error of KindNotFound, and so on
+func (e *Error) Is(target error) bool {
+	for _, k := range kinds {
+		if k.kind == e.Kind {
+			return target == k.sentinel
+		}
+	}
+	return false
+}
+
+// NotFound is returned when the thing the caller wants to read or write does not exist
+func NotFound(msg string) *Error {
+	return &Error{Kind: KindNotFound, Message: msg}
+}
+
+// Conflict is returned when a write can't be done because of the current state of the thing, like a version that changed
+func Conflict(msg string) *Error {
+	return &Error{Kind: KindConflict, Message: msg}
+}
+
+// Invalid is returned when the caller sent us something we can't use. See requests.ValidationError for request bodies
+func Invalid(msg string) *Error {
+	return &Error{Kind: KindValidation, Message: msg}
+}
+
+// Forbidden is returned when the caller is not allowed to do this
+func Forbidden(msg string) *Error {
+	return &Error{Kind: KindForbidden, Message: msg}
+}
+
+// Gone is returned for something that existed but can't be used anymore
+func Gone(msg string) *Error {
+	return &Error{Kind: KindGone, Message: msg}
+}
+
+// Throttled is returned when AWS (or us) refused the request because we are sending too many. The client can retry later
+func Throttled(err error) *Error {
+	return &Error{Kind: KindThrottled, Message: "too many requests, try again later", Err: err}
+}
+
+// Unavailable is returned when a service we depend on failed or could not be reached. The client can retry later
+func Unavailable(err error) *Error {
+	return &Error{Kind: KindUnavailable, Message: "service unavailable, try again later", Err: err}
+}
+
+/*
+FromAWS wraps the error of an AWS call so that throttling becomes a Throttled error, and errors
+the SDK would retry (5xx, timeouts, connection errors) become an Unavailable error. Any other error
+is returned as is. Our adapters call it on every error they get from the SDK
+*/
+func FromAWS(err error) error {
+	if err == nil {
+		return nil
+	}
+	if request.IsErrorThrottle(err) {
+		return Throttled(err)
+	}
+	if request.IsErrorRetryable(err) {
+		return Unavailable(err)
+	}
+	if rerr, ok := err.(awserr.RequestFailure); ok && rerr.StatusCode() >= 500 {
+		return Unavailable(err)
+	}
+	return err
+}
+
+// KindOf tells the Kind of err. Errors that are none of our Kinds are KindInternal
+func KindOf(err error) Kind {
+	for _, k := range kinds {
+		if errors.Is(err, k.sentinel) {
+			return k.kind
+		}
+	}
+	return KindInternal
+}
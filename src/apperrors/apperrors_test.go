@@ -0,0 +1,121 @@
+package apperrors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want apperrors.Kind
+	}{
+		{"not found", groupsAccess.ErrGroupNotFound, apperrors.KindNotFound},
+		{"wrapped", fmt.Errorf("getting the group: %w", groupsAccess.ErrGroupNotFound), apperrors.KindNotFound},
+		{"conflict", &groupsAccess.ConflictError{Id: "g1", Version: 2}, apperrors.KindConflict},
+		{"validation", requests.Decode(`{`, &requests.CreateGroupRequest{}), apperrors.KindValidation},
+		{"forbidden", apperrors.Forbidden("no"), apperrors.KindForbidden},
+		{"gone", apperrors.Gone("expired"), apperrors.KindGone},
+		{"anything else", errors.New("boom"), apperrors.KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apperrors.KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromAWS(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want apperrors.Kind
+	}{
+		{"throughput exceeded", awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil), apperrors.KindThrottled},
+		{"request limit", awserr.New(dynamodb.ErrCodeRequestLimitExceeded, "slow down", nil), apperrors.KindThrottled},
+		{"5xx", awserr.NewRequestFailure(awserr.New("InternalServerError", "oops", nil), 500, "req"), apperrors.KindUnavailable},
+		{"validation exception", awserr.New("ValidationException", "bad key", nil), apperrors.KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apperrors.FromAWS(tt.err)
+			if got := apperrors.KindOf(err); got != tt.want {
+				t.Errorf("KindOf(FromAWS(%v)) = %s, want %s", tt.err, got, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("FromAWS(%v) lost the AWS error", tt.err)
+			}
+		})
+	}
+
+	if apperrors.FromAWS(nil) != nil {
+		t.Error("FromAWS(nil) should be nil")
+	}
+}
+
+func TestResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"not found", groupsAccess.ErrGroupNotFound, 404, "not_found", "group not found"},
+		{"conflict", &groupsAccess.ConflictError{Id: "g1", Version: 2}, 409, "conflict", "group g1 is not at version 2 anymore"},
+		{"throttled", apperrors.FromAWS(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)), 429, "throttled", "too many requests, try again later"},
+		{"unavailable", apperrors.Unavailable(errors.New("connection reset")), 503, "unavailable", "service unavailable, try again later"},
+		{"internal errors are not sent to the client", errors.New("table Groups-dev does not exist"), 500, "internal", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := apperrors.Response(tt.err)
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if resp.Headers["Access-Control-Allow-Origin"] != "*" {
+				t.Error("the response has no CORS header")
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+				t.Fatalf("body is not JSON: %s", resp.Body)
+			}
+			if body["code"] != tt.code || body["error"] != tt.message {
+				t.Errorf("body = %s, want code %q and error %q", resp.Body, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestResponseHasTheWrongFields(t *testing.T) {
+	resp := apperrors.Response(requests.Decode(`{"name":""}`, &requests.CreateGroupRequest{}))
+	if resp.StatusCode != 400 {
+		t.Fatalf("status = %d, want 400", resp.StatusCode)
+	}
+
+	var body struct {
+		Code   string                 `json:"code"`
+		Fields []apperrors.FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		t.Fatalf("body is not JSON: %s", resp.Body)
+	}
+	if body.Code != "validation" || len(body.Fields) == 0 {
+		t.Errorf("body = %s, want the list of wrong fields", resp.Body)
+	}
+}
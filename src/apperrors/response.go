@@ -0,0 +1,105 @@
+package apperrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// A FieldError tells the client what is wrong with one field of the request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// fieldsError is implemented by the validation errors that know which fields are wrong, like requests.ValidationError
+type fieldsError interface {
+	FieldErrors() []FieldError
+}
+
+// The HTTP status of every Kind
+var statuses = map[Kind]int{
+	KindNotFound:    404,
+	KindConflict:    409,
+	KindValidation:  400,
+	KindForbidden:   403,
+	KindGone:        410,
+	KindThrottled:   429,
+	KindUnavailable: 503,
+	KindInternal:    500,
+}
+
+// Status is the HTTP status the client gets for err
+func Status(err error) int {
+	return statuses[KindOf(err)]
+}
+
+/*
+Message is what we tell the client about err. We never send the message of an error we did not
+expect, it could have details of our tables or buckets
+*/
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+
+	if KindOf(err) == KindInternal {
+		return "internal server error"
+	}
+
+	return err.Error()
+}
+
+/*
+Response is the response every handler sends when something went wrong. The body always looks like
+
+	{"error": "group not found", "code": "not_found"}
+
+and has the list of "fields" that are wrong when the request body is invalid
+*/
+func Response(err error) events.APIGatewayProxyResponse {
+	return ResponseWithStatus(Status(err), err)
+}
+
+// ResponseWithStatus is a Response with another status than the one of the Kind of err, like a 412 for a failed If-Match
+func ResponseWithStatus(status int, err error) events.APIGatewayProxyResponse {
+	var buf bytes.Buffer
+
+	kind := KindOf(err)
+	if kind == KindInternal {
+		log.Printf("Internal error: %s", err.Error())
+	}
+
+	errBody := map[string]interface{}{
+		"error": Message(err),
+		"code":  kind,
+	}
+
+	var ferr fieldsError
+	if errors.As(err, &ferr) {
+		errBody["error"] = "invalid request body"
+		errBody["fields"] = ferr.FieldErrors()
+	}
+
+	body, _ := json.Marshal(errBody)
+	json.HTMLEscape(&buf, body)
+
+	headers := map[string]string{
+		"Content-Type":                "application/json",
+		"Access-Control-Allow-Origin": "*",
+	}
+	if kind == KindThrottled {
+		headers["Retry-After"] = "1"
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Body:       buf.String(),
+		Headers:    headers,
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// The claims we care about in an Auth0 access token
+type JwtPayload struct {
+	Sub string   `json:"sub"` // the id of the user the token was issued to
+	Iss string   `json:"iss"` // the Auth0 tenant that issued it, eg https://AUTH0_DOMAIN.us.auth0.com/
+	Aud audience `json:"aud"` // the APIs the token is for
+	Exp int64    `json:"exp"`
+	Iat int64    `json:"iat"`
+}
+
+// audience is the aud claim. It is a string when the token is for one API, and a list when it is for several
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidAlg       = errors.New("unexpected signing method")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpiredToken     = errors.New("token is expired")
+	ErrInvalidIssuer    = errors.New("token was not issued by our tenant")
+	ErrInvalidAudience  = errors.New("token is not for our API")
+)
+
+/*
+VerifyToken verifies a token signed the Symmetric way(HS256) with the Auth0 'Client Secret' and returns its claims.
+The token must not be expired, and must come from our issuer for our audience: the same secret may sign
+the tokens of other APIs of the tenant. A token without an expiry is refused, it would be valid forever.
+
+A JWT is three base64url encoded parts separated by dots: header.payload.signature. The signature is
+the HMAC-SHA256 of "header.payload" with the secret, so we can verify it with just the standard library
+*/
+func VerifyToken(token string, secret []byte, issuer string, aud string) (*JwtPayload, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	header := &jwtHeader{}
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, ErrInvalidAlg
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload := &JwtPayload{}
+	if err := decodeSegment(parts[1], payload); err != nil {
+		return nil, err
+	}
+	if payload.Exp == 0 {
+		return nil, ErrMalformedToken
+	}
+	if time.Now().Unix() >= payload.Exp {
+		return nil, ErrExpiredToken
+	}
+	if payload.Iss != issuer {
+		return nil, ErrInvalidIssuer
+	}
+	if !payload.Aud.contains(aud) {
+		return nil, ErrInvalidAudience
+	}
+	if payload.Sub == "" {
+		return nil, ErrMalformedToken
+	}
+
+	return payload, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrMalformedToken
+	}
+	return nil
+}
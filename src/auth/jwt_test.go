@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret   = []byte("secret")
+	testIssuer   = "https://udagram.us.auth0.com/"
+	testAudience = "https://udagram/api"
+)
+
+// sign builds an HS256 token with those claims
+func sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "auth0|1",
+		"iss": testIssuer,
+		"aud": []string{testAudience, "https://udagram.us.auth0.com/userinfo"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	payload, err := VerifyToken(sign(t, validClaims()), testSecret, testIssuer, testAudience)
+	if err != nil || payload.Sub != "auth0|1" {
+		t.Fatalf("VerifyToken of a valid token returned %+v, %v", payload, err)
+	}
+
+	//a token for a single API has a string audience
+	claims := validClaims()
+	claims["aud"] = testAudience
+	if _, err := VerifyToken(sign(t, claims), testSecret, testIssuer, testAudience); err != nil {
+		t.Errorf("VerifyToken of a token with a string audience returned %v", err)
+	}
+}
+
+func TestVerifyTokenRefusesClaims(t *testing.T) {
+	tests := []struct {
+		name  string
+		claim string
+		value interface{}
+		want  error
+	}{
+		{"no expiry", "exp", nil, ErrMalformedToken},
+		{"expired", "exp", time.Now().Add(-time.Minute).Unix(), ErrExpiredToken},
+		{"other issuer", "iss", "https://evil.us.auth0.com/", ErrInvalidIssuer},
+		{"no issuer", "iss", nil, ErrInvalidIssuer},
+		{"other audience", "aud", "https://other/api", ErrInvalidAudience},
+		{"no audience", "aud", nil, ErrInvalidAudience},
+	}
+
+	for _, tt := range tests {
+		claims := validClaims()
+		if tt.value == nil {
+			delete(claims, tt.claim)
+		} else {
+			claims[tt.claim] = tt.value
+		}
+
+		if _, err := VerifyToken(sign(t, claims), testSecret, testIssuer, testAudience); err != tt.want {
+			t.Errorf("%s: VerifyToken returned %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := VerifyToken(sign(t, validClaims()), []byte("other"), testIssuer, testAudience); err != ErrInvalidSignature {
+		t.Errorf("VerifyToken with another secret returned %v, want %v", err, ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// The key our auth0Authorizer stores the id of the user under in the policy context
+const UserIdContextKey = "userId"
+
+/*
+GetUserId returns the id of the user that sent a request. API Gateway copies the context our
+auth0Authorizer returned into the request context of every function behind the authorizer.
+
+It returns an empty string for functions that are not behind the authorizer
+*/
+func GetUserId(rc events.APIGatewayProxyRequestContext) string {
+	if id, ok := rc.Authorizer[UserIdContextKey].(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+var (
+	/*
+		The Auth0 'Client Secret' used to verify tokens signed the Symmetric way(HS256).
+		When it is not set we fall back to our mock token "123" so the app still works for local testing.
+		Never in Lambda: a deploy without the secret would let anybody in as mockUserId
+	*/
+	clientSecret = os.Getenv("AUTH0_CLIENT_SECRET")
+	mockToken    = "123"
+	mockUserId   = "user"
+
+	// The tenant that issues our tokens and the identifier of our API in it. Both are required with the secret
+	issuer      = os.Getenv("AUTH0_ISSUER")
+	apiAudience = os.Getenv("AUTH0_AUDIENCE")
+)
+
+// UserIdFromToken returns the subject(user id) of a valid bearer token
+func UserIdFromToken(token string) (string, error) {
+	if clientSecret == "" {
+		if !localRun() {
+			return "", errors.New("AUTH0_CLIENT_SECRET is not set")
+		}
+		if token != mockToken {
+			return "", errors.New("Invalid token")
+		}
+		return mockUserId, nil
+	}
+
+	//without them any token signed with the secret would do, so we refuse them all
+	if issuer == "" || apiAudience == "" {
+		return "", errors.New("AUTH0_ISSUER and AUTH0_AUDIENCE must be set with AUTH0_CLIENT_SECRET")
+	}
+
+	payload, err := VerifyToken(token, []byte(clientSecret), issuer, apiAudience)
+	if err != nil {
+		return "", err
+	}
+
+	return payload.Sub, nil
+}
+
+// localRun tells if we run outside of Lambda, eg our tests, or on the memory Adapters, like in the cursor package
+func localRun() bool {
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" || os.Getenv("GROUPS_REPOSITORY") == "memory" || os.Getenv("IMAGES_REPOSITORY") == "memory"
+}
+
+/*
+GetOptionalUserId returns the id of the user that sent a request to a function that is NOT behind our
+authorizer, eg getImages. Anybody can call these functions, but a signed in user may see more(like the
+images of the private groups they are a member of), so we verify the Authorization header ourself.
+
+It returns an empty string when there is no valid token. We don't fail the request, the caller is just anonymous
+*/
+func GetOptionalUserId(req events.APIGatewayProxyRequest) string {
+	if id := GetUserId(req.RequestContext); id != "" {
+		return id
+	}
+
+	header := req.Headers["Authorization"]
+	if header == "" {
+		header = req.Headers["authorization"] //http/2 clients send lower case headers
+	}
+
+	split := strings.Split(header, " ")
+	if len(split) != 2 || strings.ToLower(split[0]) != "bearer" {
+		return ""
+	}
+
+	id, err := UserIdFromToken(split[1])
+	if err != nil {
+		log.Printf("Ignoring the Authorization header: %s", err.Error())
+		return ""
+	}
+
+	return id
+}
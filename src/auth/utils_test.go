@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMockTokenOnlyRunsLocally(t *testing.T) {
+	old, had := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME")
+	defer func() {
+		if had {
+			os.Setenv("AWS_LAMBDA_FUNCTION_NAME", old)
+		} else {
+			os.Unsetenv("AWS_LAMBDA_FUNCTION_NAME")
+		}
+	}()
+	defer func(s string) { clientSecret = s }(clientSecret)
+	clientSecret = ""
+
+	os.Unsetenv("AWS_LAMBDA_FUNCTION_NAME")
+	if id, err := UserIdFromToken(mockToken); err != nil || id != mockUserId {
+		t.Errorf("UserIdFromToken of the mock token outside of Lambda returned %q, %v", id, err)
+	}
+
+	//a deploy that forgot the secret must not let anybody in
+	os.Setenv("AWS_LAMBDA_FUNCTION_NAME", "udagram-dev-getImages")
+	if id, err := UserIdFromToken(mockToken); err == nil {
+		t.Errorf("UserIdFromToken of the mock token in Lambda returned %q, want an error", id)
+	}
+}
@@ -0,0 +1,201 @@
+package groups
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+GroupDeleter deletes groups. Like images.ImageDeleter it is not part of GroupAccess, because it needs
+the Images table and the cleanups on top of everything GroupAccess uses
+*/
+type GroupDeleter interface {
+	DeleteGroup(userId string, id string, version int64) error
+	RetryCleanups(limit int64) (int, error)
+}
+
+type groupDeleter struct {
+	*groupAccess
+	imageRepo   imagesAccess.Repository
+	cleanupRepo cleanupsAccess.Repository
+	now         func() time.Time
+}
+
+func NewGroupDeleter(r groupsAccess.Repository, m membershipsAccess.Repository, i invitationsAccess.Repository, t tagsAccess.Repository, images imagesAccess.Repository, c cleanupsAccess.Repository) GroupDeleter {
+	return &groupDeleter{&groupAccess{r, m, i, t}, images, c, time.Now}
+}
+
+const (
+	// Like for the images, a new cleanup is only due once DeleteGroup is surely done with it
+	cleanupGracePeriod = 5 * time.Minute
+	maxRetryDelay      = time.Hour
+	// How many images of the group we read at a time to store their cleanups
+	imagesPageSize = 100
+)
+
+/*
+DeleteGroup deletes a group, its members, its invitations, its tags and its images. Only the owners
+of a group can delete it.
+
+The cleanup is stored before we start, so whatever fails is retried by RetryCleanups. The images can be
+a lot more than we can delete before the function times out, so we only store an ImageCleanup for each
+of them, and images.ImageDeleter deletes them like any other image: their row, their files and their
+search document. We only return an error when the group is still there, then the client can try again
+*/
+func (d *groupDeleter) DeleteGroup(userId string, id string, version int64) error {
+	group, err := d.RequireRole(userId, id, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(group, version); err != nil {
+		return err
+	}
+
+	now := d.now()
+	c := models.GroupCleanup{
+		GroupId:   group.Id,
+		Group:     group,
+		Steps:     append([]models.GroupCleanupStep{}, models.GroupCleanupSteps...),
+		RetryAt:   models.FormatTimestamp(now.Add(cleanupGracePeriod)),
+		CreatedAt: models.FormatTimestamp(now),
+	}
+	if err := d.cleanupRepo.SaveGroupCleanup(c); err != nil {
+		return err
+	}
+
+	c, err = d.cleanUp(c)
+	if err != nil && len(c.Steps) > 0 && c.Steps[0] == models.GroupCleanupRow {
+		return err
+	}
+
+	return nil
+}
+
+// RetryCleanups goes on with up to limit group cleanups that failed before, and returns how many of them are done
+func (d *groupDeleter) RetryCleanups(limit int64) (int, error) {
+	cleanups, err := d.cleanupRepo.GetDueGroupCleanups(models.FormatTimestamp(d.now()), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, c := range cleanups {
+		if _, err := d.cleanUp(c); err == nil {
+			done++
+		}
+	}
+
+	return done, nil
+}
+
+/*
+cleanUp does the steps that are left, in order. When they are all done we forget the cleanup,
+otherwise we store what is left and when to retry
+*/
+func (d *groupDeleter) cleanUp(c models.GroupCleanup) (models.GroupCleanup, error) {
+	for len(c.Steps) > 0 {
+		err := d.do(c, c.Steps[0])
+		if c.Steps[0] == models.GroupCleanupRow && groupsAccess.IsConflict(err) {
+			//somebody changed the group since we read it, so it was not deleted and none of the rest must be
+			if derr := d.cleanupRepo.DeleteGroupCleanup(c.GroupId); derr != nil {
+				log.Printf("Failed to forget the cleanup of group %s: Error message was %s", c.GroupId, derr.Error())
+			}
+			return c, err
+		}
+		if err != nil {
+			log.Printf("Failed to clean up group %s at step %s, attempt %d: Error message was %s", c.GroupId, c.Steps[0], c.Attempts+1, err.Error())
+
+			c.Attempts++
+			c.LastError = err.Error()
+			c.RetryAt = models.FormatTimestamp(d.now().Add(retryDelay(c.Attempts)))
+			if serr := d.cleanupRepo.SaveGroupCleanup(c); serr != nil {
+				//the cleanup we stored before is still there, with more steps left. It will be retried from there
+				log.Printf("Failed to save the cleanup of group %s: Error message was %s", c.GroupId, serr.Error())
+			}
+			return c, err
+		}
+
+		c.Steps = c.Steps[1:]
+	}
+
+	return c, d.cleanupRepo.DeleteGroupCleanup(c.GroupId)
+}
+
+func (d *groupDeleter) do(c models.GroupCleanup, step models.GroupCleanupStep) error {
+	switch step {
+	case models.GroupCleanupRow:
+		//a retry after the group was deleted finds nothing to delete, which is what we want
+		if err := d.groupRepo.DeleteGroup(c.GroupId, c.Group.Version); err != nil && err != groupsAccess.ErrGroupNotFound {
+			return err
+		}
+		return nil
+	case models.GroupCleanupMembers:
+		return d.memberRepo.DeleteGroupMembers(c.GroupId)
+	case models.GroupCleanupInvitations:
+		return d.inviteRepo.DeleteGroupInvitations(c.GroupId)
+	case models.GroupCleanupTags:
+		return d.tagRepo.UntagGroup(c.Group, indexedTags(c.Group))
+	case models.GroupCleanupImages:
+		return d.cleanUpImages(c.GroupId)
+	}
+
+	//a step this version does not know about, eg from a newer version. Skipping it could orphan something, so we leave it for later
+	return fmt.Errorf("unknown cleanup step %q", step)
+}
+
+/*
+cleanUpImages stores a cleanup for every image of a deleted group, due right away, so that
+RetryImageCleanups deletes them. Storing the cleanup of an image again is harmless, and the images
+whose row is already deleted are not there anymore, so after a failure we simply start over
+*/
+func (d *groupDeleter) cleanUpImages(groupId string) error {
+	now := models.FormatTimestamp(d.now())
+	nextKey := ""
+	for {
+		//the pending images too, the client may still upload their file
+		images, nk, err := d.imageRepo.GetImagesByGroup(groupId, imagesAccess.TimeRange{}, imagesPageSize, nextKey, groupsAccess.OldestFirst, imagesAccess.ByCreatedAt, true)
+		if err != nil {
+			return err
+		}
+
+		for _, image := range images {
+			err := d.cleanupRepo.SaveCleanup(models.ImageCleanup{
+				ImageId:   image.ImageId,
+				GroupId:   image.GroupId,
+				Timestamp: image.Timestamp,
+				Steps:     append([]models.CleanupStep{}, models.CleanupSteps...),
+				RetryAt:   now,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if nk == "" {
+			return nil
+		}
+		nextKey = nk
+	}
+}
+
+// retryDelay doubles with every attempt, from a minute up to maxRetryDelay, like the one of the image cleanups
+func retryDelay(attempts int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempts && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
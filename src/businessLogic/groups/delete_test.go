@@ -0,0 +1,157 @@
+package groups
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+// deleterOf returns a GroupDeleter on the same repositories as ga, with no images and no cleanups
+func deleterOf(ga GroupAccess) GroupDeleter {
+	g := ga.(*groupAccess)
+	return NewGroupDeleter(g.groupRepo, g.memberRepo, g.inviteRepo, g.tagRepo, imagesAccess.NewMemoryRepo(), cleanupsAccess.NewMemoryRepo())
+}
+
+// failingMembers is a memberships Repository that can't delete the members of a group until it is fixed
+type failingMembers struct {
+	membershipsAccess.Repository
+	down bool
+}
+
+func (m *failingMembers) DeleteGroupMembers(groupId string) error {
+	if m.down {
+		return errors.New("the memberships table is down")
+	}
+	return m.Repository.DeleteGroupMembers(groupId)
+}
+
+// newTestDeleter creates a group with two images and returns everything a test needs to check what is left of it
+func newTestDeleter(t *testing.T) (*groupDeleter, models.Group, *failingMembers, cleanupsAccess.Repository) {
+	t.Helper()
+
+	members := &failingMembers{Repository: membershipsAccess.NewMemoryRepo()}
+	images := imagesAccess.NewMemoryRepo()
+	cleanups := cleanupsAccess.NewMemoryRepo()
+	d := NewGroupDeleter(groupsAccess.NewMemoryRepo(), members, invitationsAccess.NewMemoryRepo(), tagsAccess.NewMemoryRepo(), images, cleanups).(*groupDeleter)
+
+	group, err := d.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "d", Tags: []string{"cats"}})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	for _, id := range []string{"cat", "kitten"} {
+		status := models.ImageStatusProcessed
+		if id == "kitten" {
+			status = models.ImageStatusPending //its file may still be uploaded
+		}
+		if _, err := images.CreateImage(models.Image{ImageId: id, GroupId: group.Id, Timestamp: models.NewTimestamp(), Status: status}); err != nil {
+			t.Fatalf("CreateImage(%s) failed: %v", id, err)
+		}
+	}
+
+	return d, group, members, cleanups
+}
+
+func dueImageCleanups(t *testing.T, cleanups cleanupsAccess.Repository) []string {
+	t.Helper()
+
+	due, err := cleanups.GetDueCleanups(models.FormatTimestamp(time.Now().Add(2*time.Hour)), 10) //after the retries of the tests
+	if err != nil {
+		t.Fatalf("GetDueCleanups failed: %v", err)
+	}
+	ids := []string{}
+	for _, c := range due {
+		ids = append(ids, c.ImageId)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestDeleteGroupCleansUpEverything(t *testing.T) {
+	d, group, _, cleanups := newTestDeleter(t)
+
+	if err := d.DeleteGroup("owner", group.Id, group.Version); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+
+	if _, err := d.groupRepo.GetGroup(group.Id); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("GetGroup after DeleteGroup = %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+	if m, _ := d.memberRepo.GetMembers(group.Id); len(m) != 0 {
+		t.Errorf("the group still has members %+v", m)
+	}
+
+	//the images are deleted like any other image, by images.ImageDeleter
+	if ids := dueImageCleanups(t, cleanups); !reflect.DeepEqual(ids, []string{"cat", "kitten"}) {
+		t.Errorf("the due image cleanups are %v, want [cat kitten]", ids)
+	}
+	if c, _ := cleanups.GetDueGroupCleanups(models.FormatTimestamp(time.Now().Add(time.Hour)), 10); len(c) != 0 {
+		t.Errorf("the cleanup of the group is still there: %+v", c)
+	}
+}
+
+func TestDeleteGroupIsRetried(t *testing.T) {
+	d, group, members, cleanups := newTestDeleter(t)
+	members.down = true
+
+	//the group is gone, so the client can't retry. We do
+	if err := d.DeleteGroup("owner", group.Id, group.Version); err != nil {
+		t.Fatalf("DeleteGroup with the memberships down = %v, want nil", err)
+	}
+	if _, err := d.groupRepo.GetGroup(group.Id); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("GetGroup after DeleteGroup = %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+	if ids := dueImageCleanups(t, cleanups); len(ids) != 0 {
+		t.Errorf("the images were cleaned up before the members: %v", ids)
+	}
+
+	members.down = false
+	d.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if done, err := d.RetryCleanups(10); err != nil || done != 1 {
+		t.Fatalf("RetryCleanups returned %d, %v, want 1 cleanup done", done, err)
+	}
+	if m, _ := d.memberRepo.GetMembers(group.Id); len(m) != 0 {
+		t.Errorf("the group still has members %+v after the retry", m)
+	}
+	if ids := dueImageCleanups(t, cleanups); !reflect.DeepEqual(ids, []string{"cat", "kitten"}) {
+		t.Errorf("the due image cleanups are %v after the retry, want [cat kitten]", ids)
+	}
+}
+
+func TestDeleteGroupThatChangedDoesNothing(t *testing.T) {
+	d, group, _, cleanups := newTestDeleter(t)
+
+	//somebody changes the group after DeleteGroup read it, but before it is deleted
+	name := "Dogs"
+	updated, err := d.UpdateGroup("owner", group.Id, group.Version, &requests.UpdateGroupRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	c := models.GroupCleanup{GroupId: group.Id, Group: group, Steps: models.GroupCleanupSteps}
+	if err := cleanups.SaveGroupCleanup(c); err != nil {
+		t.Fatalf("SaveGroupCleanup failed: %v", err)
+	}
+
+	if _, err := d.cleanUp(c); !groupsAccess.IsConflict(err) {
+		t.Errorf("cleanUp of a group that changed = %v, want a *ConflictError", err)
+	}
+	if _, err := d.groupRepo.GetGroup(updated.Id); err != nil {
+		t.Errorf("GetGroup of the group that changed = %v, want nil", err)
+	}
+	if m, _ := d.memberRepo.GetMembers(group.Id); len(m) == 0 {
+		t.Error("the members of the group that changed were deleted")
+	}
+	if left, _ := cleanups.GetDueGroupCleanups(models.FormatTimestamp(time.Now().Add(time.Hour)), 10); len(left) != 0 {
+		t.Errorf("the cleanup of the group that changed is still there: %+v", left)
+	}
+}
@@ -0,0 +1,40 @@
+package groups
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// ETag returns the value of the ETag header for a group. It is the version of the group, quoted like the HTTP spec wants
+func ETag(g models.Group) string {
+	return `"` + strconv.FormatInt(g.Version, 10) + `"`
+}
+
+/*
+VersionFromIfMatch returns the version a client expects from the value of its If-Match header.
+No header or "*" means any version is fine.
+
+ok is false when the header can never match one of our ETags(eg it is a list or not a number).
+The handlers answer 412 Precondition Failed in that case, without trying the write
+*/
+func VersionFromIfMatch(header string) (version int64, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return AnyVersion, true
+	}
+
+	//we don't have weak ETags, but some proxies turn strong ones into weak ones
+	header = strings.TrimPrefix(header, "W/")
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+
+	v, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+
+	return v, true
+}
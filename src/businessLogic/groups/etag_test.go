@@ -0,0 +1,32 @@
+package groups
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+func TestVersionFromIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		ok      bool
+	}{
+		{"", AnyVersion, true},
+		{"*", AnyVersion, true},
+		{`"3"`, 3, true},
+		{`W/"3"`, 3, true},
+		{ETag(models.Group{Version: 12}), 12, true},
+		{`3`, 0, false},
+		{`"abc"`, 0, false},
+		{`"-1"`, 0, false},
+		{`"1", "2"`, 0, false},
+	}
+
+	for _, tt := range tests {
+		version, ok := VersionFromIfMatch(tt.header)
+		if ok != tt.ok || (ok && version != tt.version) {
+			t.Errorf("VersionFromIfMatch(%q) = %d, %v, want %d, %v", tt.header, version, ok, tt.version, tt.ok)
+		}
+	}
+}
@@ -1,43 +1,205 @@
 package groups
 
 import (
-	"time"
+	"log"
 
 	uuid "github.com/satori/go.uuid"
+	"github.com/udacity/serverless-golang/src/apperrors"
 	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
 	"github.com/udacity/serverless-golang/src/models"
 	"github.com/udacity/serverless-golang/src/requests"
 )
 
 /*Other developers might call this Service*/
 type GroupAccess interface {
-	GetAllGroups(l int64, n string) ([]models.Group, string)
-	CreateGroup(c *requests.CreateGroupRequest) (models.Group, error)
+	GetAllGroups(l int64, n string, order groupsAccess.SortOrder) ([]models.Group, string, error)
+	GetUserGroups(userId string, l int64, n string) ([]models.Group, string, error)
+	CreateGroup(userId string, c *requests.CreateGroupRequest) (models.Group, error)
+	UpdateGroup(userId string, id string, version int64, u *requests.UpdateGroupRequest) (models.Group, error)
+	GetVisibleGroup(userId string, id string) (models.Group, error)
+
+	// Tags of the public groups. See tags.go
+	GetGroupsByTag(tag string, l int64, n string, order groupsAccess.SortOrder) ([]models.Group, string, error)
+	GetTagCloud(limit int) ([]models.TagCount, error)
+
+	// Members of a group. See members.go
+	GetMembers(userId string, groupId string) ([]models.Membership, error)
+	AddMember(userId string, groupId string, a *requests.AddMemberRequest) (models.Membership, error)
+	RemoveMember(userId string, groupId string, memberId string) error
+	RequireRole(userId string, groupId string, role models.Role) (models.Group, error)
+
+	// Invitations to join a group. See invitations.go
+	CreateInvitation(userId string, groupId string, c *requests.CreateInvitationRequest) (models.Invitation, string, error)
+	GetInvitations(userId string, groupId string) ([]models.Invitation, error)
+	RevokeInvitation(userId string, groupId string, invitationId string) error
+	RedeemInvitation(userId string, token string) (models.Membership, error)
+}
+
+// ErrForbidden is returned when a user does not have the role needed to do something in a group
+var ErrForbidden = apperrors.Forbidden("you do not have the role needed to do this in this group")
+
+/*
+AnyVersion can be passed as the version to UpdateGroup and DeleteGroup when the caller did not say
+which version they expect(no If-Match header). The write still fails with a *groupsAccess.ConflictError
+if somebody else changes the group while we are writing it
+*/
+const AnyVersion int64 = -1
+
+// checkVersion returns a *groupsAccess.ConflictError when the caller expects the group to be at another version
+func checkVersion(group models.Group, version int64) error {
+	if version != AnyVersion && version != group.Version {
+		return &groupsAccess.ConflictError{Id: group.Id, Version: version}
+	}
+	return nil
 }
 
-//This 'groupAccess' businessLogic can only coomunitcate with the external service through the Port(groupsAccess.Repository)
+//This 'groupAccess' businessLogic can only coomunitcate with the external service through the Ports(groupsAccess.Repository, membershipsAccess.Repository, invitationsAccess.Repository and tagsAccess.Repository)
 type groupAccess struct {
-	groupRepo groupsAccess.Repository
+	groupRepo  groupsAccess.Repository
+	memberRepo membershipsAccess.Repository
+	inviteRepo invitationsAccess.Repository
+	tagRepo    tagsAccess.Repository
+}
+
+func NewGroupAccess(r groupsAccess.Repository, m membershipsAccess.Repository, i invitationsAccess.Repository, t tagsAccess.Repository) GroupAccess {
+	return &groupAccess{r, m, i, t}
 }
 
-func NewGroupAccess(r groupsAccess.Repository) GroupAccess {
-	return &groupAccess{r}
+func (g *groupAccess) GetAllGroups(l int64, n string, order groupsAccess.SortOrder) ([]models.Group, string, error) {
+	return g.groupRepo.GetAllGroups(l, n, order)
 }
 
-func (g *groupAccess) GetAllGroups(l int64, n string) ([]models.Group, string) {
-	return g.groupRepo.GetAllGroups(l, n)
+func (g *groupAccess) GetUserGroups(userId string, l int64, n string) ([]models.Group, string, error) {
+	return g.groupRepo.GetGroupsByUser(userId, l, n)
 }
 
-func (g *groupAccess) CreateGroup(createReq *requests.CreateGroupRequest) (models.Group, error) {
+func (g *groupAccess) CreateGroup(userId string, createReq *requests.CreateGroupRequest) (models.Group, error) {
 	id := uuid.Must(uuid.NewV4(), nil).String() //create a new id
 
+	visibility := models.Visibility(createReq.Visibility)
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
 	// Initialize group
 	group := models.Group{
 		Id:          id,
+		UserId:      userId,
 		Name:        createReq.Name,
 		Description: createReq.Description,
-		Timestamp:   time.Now().String(),
+		Visibility:  visibility,
+		Tags:        models.NormalizeTags(createReq.Tags),
+		Timestamp:   models.NewTimestamp(),
+
+		OriginalsPolicy: models.OriginalsPolicy(createReq.OriginalsPolicy), //empty follows the stage
+	}
+
+	group, err := g.groupRepo.CreateGroup(group)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	/*
+		The group is there now, so we don't fail anymore: the client would create it again, and
+		createGroup would forget its idempotency key. The creator of a group is always its owner, even
+		if this fails(see roleOf). We still store the membership so that the creator shows up in the
+		list of members like everybody else
+	*/
+	_, err = g.memberRepo.AddMember(models.Membership{
+		GroupId:   group.Id,
+		UserId:    userId,
+		Role:      models.RoleOwner,
+		Timestamp: group.Timestamp,
+	})
+	if err != nil {
+		log.Printf("Failed to add the creator of group %s as its owner: Error message was %s", group.Id, err.Error())
+	}
+
+	//the group is still there without its tags, it just can't be found by them
+	if err := g.tagRepo.TagGroup(group, indexedTags(group)); err != nil {
+		log.Printf("Failed to tag group %s: Error message was %s", group.Id, err.Error())
+	}
+
+	return group, nil
+}
+
+func (g *groupAccess) UpdateGroup(userId string, id string, version int64, updateReq *requests.UpdateGroupRequest) (models.Group, error) {
+	//editors can change the name and description, but only owners decide who can see the group and its originals
+	role := models.RoleEditor
+	if updateReq.Visibility != nil || updateReq.OriginalsPolicy != nil {
+		role = models.RoleOwner
+	}
+
+	group, err := g.RequireRole(userId, id, role)
+	if err != nil {
+		return models.Group{}, err
+	}
+	if err := checkVersion(group, version); err != nil {
+		return models.Group{}, err
+	}
+	wasIndexed := indexedTags(group)
+
+	// Only change the fields the caller sent us
+	if updateReq.Name != nil {
+		group.Name = *updateReq.Name
+	}
+	if updateReq.Description != nil {
+		group.Description = *updateReq.Description
+	}
+	/*
+		The group only gets its new visibility once the files of its images follow it, see
+		VisibilityChanges. Asking for the one it has while another is pending makes its files go back
+	*/
+	if updateReq.Visibility != nil {
+		if v := models.Visibility(*updateReq.Visibility); v != group.GetVisibility() || group.PendingVisibility != "" {
+			group.PendingVisibility = v
+		}
+	}
+	if updateReq.Tags != nil {
+		group.Tags = models.NormalizeTags(*updateReq.Tags)
+	}
+	if updateReq.OriginalsPolicy != nil {
+		group.OriginalsPolicy = models.OriginalsPolicy(*updateReq.OriginalsPolicy)
+	}
+
+	group, err = g.groupRepo.UpdateGroup(group) //only saves if the group is still at the version we read
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	//like in CreateGroup the update is stored, so failing now would only make the client retry with a version that is gone
+	if err := g.reindexTags(group, wasIndexed); err != nil {
+		log.Printf("Failed to reindex the tags of group %s: Error message was %s", group.Id, err.Error())
+	}
+
+	return group, nil
+}
+
+/*
+GetVisibleGroup returns a group if userId can see it. Anybody can see public and unlisted groups,
+but only the members of a private group can. For everybody else a private group does not exist,
+so we return ErrGroupNotFound instead of ErrForbidden. userId is empty for anonymous callers
+*/
+func (g *groupAccess) GetVisibleGroup(userId string, id string) (models.Group, error) {
+	group, err := g.groupRepo.GetGroup(id)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if !group.Private() {
+		return group, nil
+	}
+
+	r, err := g.roleOf(userId, group)
+	if err != nil {
+		return models.Group{}, err
+	}
+	if !r.Includes(models.RoleViewer) {
+		return models.Group{}, groupsAccess.ErrGroupNotFound
 	}
 
-	return g.groupRepo.CreateGroup(group)
+	return group, nil
 }
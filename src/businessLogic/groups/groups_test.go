@@ -0,0 +1,158 @@
+package groups
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+func TestCreateGroupIsPublicByDefault(t *testing.T) {
+	_, group := newTestGroup(t)
+
+	if group.Visibility != models.VisibilityPublic {
+		t.Errorf("CreateGroup visibility = %q, want %q", group.Visibility, models.VisibilityPublic)
+	}
+}
+
+func TestOnlyOwnersSetTheOriginalsPolicy(t *testing.T) {
+	ga, group := newTestGroup(t)
+	keep := string(models.OriginalsKeepPrivateCopy)
+
+	if _, err := ga.UpdateGroup("editor", group.Id, AnyVersion, &requests.UpdateGroupRequest{OriginalsPolicy: &keep}); err != ErrForbidden {
+		t.Errorf("UpdateGroup of the originals policy by an editor = %v, want %v", err, ErrForbidden)
+	}
+	updated, err := ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{OriginalsPolicy: &keep})
+	if err != nil {
+		t.Fatalf("UpdateGroup of the originals policy by the owner = %v, want nil", err)
+	}
+	if got := updated.GetOriginalsPolicy(models.OriginalsStrip); got != models.OriginalsKeepPrivateCopy {
+		t.Errorf("GetOriginalsPolicy after the update = %q, want %q", got, models.OriginalsKeepPrivateCopy)
+	}
+}
+
+func TestPrivateGroupsAreOnlyVisibleToMembers(t *testing.T) {
+	ga, group := newTestGroup(t)
+	private := string(models.VisibilityPrivate)
+
+	if _, err := ga.UpdateGroup("editor", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &private}); err != ErrForbidden {
+		t.Errorf("UpdateGroup of the visibility by an editor = %v, want %v", err, ErrForbidden)
+	}
+	updated, err := ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &private})
+	if err != nil {
+		t.Fatalf("UpdateGroup of the visibility by the owner = %v, want nil", err)
+	}
+	if _, err := visibilityOf(ga).Finish(updated); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	for _, user := range []string{"owner", "editor", "viewer"} {
+		if _, err := ga.GetVisibleGroup(user, group.Id); err != nil {
+			t.Errorf("GetVisibleGroup(%q) = %v, want nil", user, err)
+		}
+	}
+	for _, user := range []string{"stranger", ""} {
+		if _, err := ga.GetVisibleGroup(user, group.Id); err != groupsAccess.ErrGroupNotFound {
+			t.Errorf("GetVisibleGroup(%q) = %v, want %v", user, err, groupsAccess.ErrGroupNotFound)
+		}
+	}
+
+	groups, _, err := ga.GetAllGroups(20, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetAllGroups failed: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("GetAllGroups returned %d groups, want the private group to be left out", len(groups))
+	}
+}
+
+func TestUnlistedGroupsAreVisibleWithTheLink(t *testing.T) {
+	ga := NewGroupAccess(groupsAccess.NewMemoryRepo(), membershipsAccess.NewMemoryRepo(), invitationsAccess.NewMemoryRepo(), tagsAccess.NewMemoryRepo())
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "d", Visibility: "unlisted"})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	if _, err := ga.GetVisibleGroup("", group.Id); err != nil {
+		t.Errorf("GetVisibleGroup of an unlisted group = %v, want nil", err)
+	}
+
+	groups, _, err := ga.GetAllGroups(20, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetAllGroups failed: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("GetAllGroups returned %d groups, want the unlisted group to be left out", len(groups))
+	}
+}
+
+func TestWritesNeedTheCurrentVersion(t *testing.T) {
+	ga, group := newTestGroup(t)
+	name := "Dogs"
+
+	if _, err := ga.UpdateGroup("owner", group.Id, group.Version+1, &requests.UpdateGroupRequest{Name: &name}); !groupsAccess.IsConflict(err) {
+		t.Errorf("UpdateGroup with the wrong version = %v, want a *ConflictError", err)
+	}
+
+	updated, err := ga.UpdateGroup("owner", group.Id, group.Version, &requests.UpdateGroupRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateGroup with the current version = %v, want nil", err)
+	}
+	if updated.Version != group.Version+1 {
+		t.Errorf("UpdateGroup returned version %d, want %d", updated.Version, group.Version+1)
+	}
+
+	//the version we read before the update is stale now
+	if err := deleterOf(ga).DeleteGroup("owner", group.Id, group.Version); !groupsAccess.IsConflict(err) {
+		t.Errorf("DeleteGroup with a stale version = %v, want a *ConflictError", err)
+	}
+	if err := deleterOf(ga).DeleteGroup("owner", group.Id, updated.Version); err != nil {
+		t.Errorf("DeleteGroup with the current version = %v, want nil", err)
+	}
+}
+
+// failingTags is a tags Repository that can't tag anything
+type failingTags struct {
+	tagsAccess.Repository
+}
+
+func (failingTags) TagGroup(group models.Group, tags []string) error {
+	return errors.New("the tags table is down")
+}
+
+func TestCreateGroupDoesNotFailOnceTheGroupIsStored(t *testing.T) {
+	groupRepo := groupsAccess.NewMemoryRepo()
+	ga := NewGroupAccess(groupRepo, membershipsAccess.NewMemoryRepo(), invitationsAccess.NewMemoryRepo(), failingTags{tagsAccess.NewMemoryRepo()})
+
+	//an error would make the client create the group again
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "d", Tags: []string{"cats"}})
+	if err != nil {
+		t.Fatalf("CreateGroup with the tags down = %v, want nil", err)
+	}
+	if _, err := groupRepo.GetGroup(group.Id); err != nil {
+		t.Errorf("GetGroup of the group CreateGroup returned = %v", err)
+	}
+}
+
+func TestUpdateGroupDoesNotFailOnceTheGroupIsStored(t *testing.T) {
+	ga, group := newTestGroup(t)
+	g := ga.(*groupAccess)
+	g.tagRepo = failingTags{g.tagRepo}
+	tags := []string{"cats"}
+
+	//an error would make the client retry with the version that is gone
+	updated, err := ga.UpdateGroup("editor", group.Id, group.Version, &requests.UpdateGroupRequest{Tags: &tags})
+	if err != nil || updated.Version != group.Version+1 || !reflect.DeepEqual(updated.Tags, tags) {
+		t.Fatalf("UpdateGroup with the tags down returned %+v, %v", updated, err)
+	}
+	name := "Kittens"
+	if _, err := ga.UpdateGroup("editor", group.Id, updated.Version, &requests.UpdateGroupRequest{Name: &name}); err != nil {
+		t.Errorf("UpdateGroup at the version UpdateGroup returned = %v", err)
+	}
+}
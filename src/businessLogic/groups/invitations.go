@@ -0,0 +1,228 @@
+package groups
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+var (
+	// ErrInvalidInvitation is returned for a token we did not sign, or for an invitation that was revoked
+	ErrInvalidInvitation = apperrors.NotFound("invitation does not exist")
+	// ErrInvitationExpired is returned for an invitation that expired or was redeemed as many times as it allows
+	ErrInvitationExpired = apperrors.Gone("invitation expired or was used up")
+	// errNoInvitationSecret is returned by the invitation functions in Lambda when INVITATION_SECRET is not set
+	errNoInvitationSecret = errors.New("INVITATION_SECRET is not set")
+)
+
+/*
+The secret we sign invitation tokens with. Every Lambda instance must have the same one, otherwise
+the redeemInvitation function can't verify the tokens createInvitation signed. Without an
+INVITATION_SECRET a local run signs with a random secret. In Lambda it is nil and we refuse to
+create or redeem invitations, rather than hand out links that stop working after a cold start
+*/
+var invitationSecret = func() []byte {
+	secret := []byte(os.Getenv("INVITATION_SECRET"))
+	if len(secret) == 0 && !localRun() {
+		log.Println("INVITATION_SECRET is not set. Invitations will fail")
+		return nil
+	}
+	if len(secret) == 0 {
+		log.Println("INVITATION_SECRET is not set. Invitation tokens will only be valid in this process")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	return secret
+}()
+
+// localRun tells if we run outside of Lambda, eg our tests, or on the memory Adapters. Nothing we sign there outlives the process
+func localRun() bool {
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" || os.Getenv("GROUPS_REPOSITORY") == "memory"
+}
+
+// now is a variable so our tests can move the clock
+var now = time.Now
+
+/*
+invitationToken is what the owner shares with the people they invite:
+
+	base64url(groupId + "/" + invitationId) + "." + base64url(HMAC-SHA256 of the first part)
+
+Like our cursors, clients cannot forge one. The invitation itself stays in our table so the owner can revoke it
+*/
+func invitationToken(i models.Invitation) string {
+	body := base64.RawURLEncoding.EncodeToString([]byte(i.GroupId + "/" + i.Id))
+	return body + "." + base64.RawURLEncoding.EncodeToString(signInvitation(body))
+}
+
+// parseInvitationToken verifies a token and returns the groupId and invitationId it was made from
+func parseInvitationToken(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", "", ErrInvalidInvitation
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, signInvitation(parts[0])) {
+		return "", "", ErrInvalidInvitation
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", ErrInvalidInvitation
+	}
+
+	ids := strings.Split(string(b), "/")
+	if len(ids) != 2 || ids[0] == "" || ids[1] == "" {
+		return "", "", ErrInvalidInvitation
+	}
+
+	return ids[0], ids[1], nil
+}
+
+func signInvitation(body string) []byte {
+	mac := hmac.New(sha256.New, invitationSecret)
+	mac.Write([]byte("invitation\x00")) // so a token can never be mistaken for anything else we sign
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// CreateInvitation creates an invitation to join a group and returns it with the token to share. Only owners can invite people
+func (g *groupAccess) CreateInvitation(userId string, groupId string, createReq *requests.CreateInvitationRequest) (models.Invitation, string, error) {
+	if _, err := g.RequireRole(userId, groupId, models.RoleOwner); err != nil {
+		return models.Invitation{}, "", err
+	}
+	if invitationSecret == nil {
+		return models.Invitation{}, "", errNoInvitationSecret
+	}
+
+	t := now()
+	invitation, err := g.inviteRepo.CreateInvitation(models.Invitation{
+		Id:        uuid.Must(uuid.NewV4(), nil).String(),
+		GroupId:   groupId,
+		Role:      models.Role(createReq.Role),
+		MaxUses:   createReq.MaxUses,
+		ExpiresAt: t.Add(time.Duration(createReq.ExpiresInHours) * time.Hour).Unix(),
+		CreatedBy: userId,
+		Timestamp: models.FormatTimestamp(t),
+	})
+	if err != nil {
+		return models.Invitation{}, "", err
+	}
+
+	return invitation, invitationToken(invitation), nil
+}
+
+// GetInvitations returns the invitations of a group that can still be redeemed. Only owners can see them
+func (g *groupAccess) GetInvitations(userId string, groupId string) ([]models.Invitation, error) {
+	if _, err := g.RequireRole(userId, groupId, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	invitations, err := g.inviteRepo.GetInvitations(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	//DynamoDB only deletes expired invitations some time after they expire, so we filter them out ourself
+	t := now()
+	pending := []models.Invitation{}
+	for _, i := range invitations {
+		if i.Pending(t) {
+			pending = append(pending, i)
+		}
+	}
+
+	return pending, nil
+}
+
+// RevokeInvitation deletes an invitation so that its token does not work anymore. Only owners can do this
+func (g *groupAccess) RevokeInvitation(userId string, groupId string, invitationId string) error {
+	if _, err := g.RequireRole(userId, groupId, models.RoleOwner); err != nil {
+		return err
+	}
+
+	err := g.inviteRepo.DeleteInvitation(groupId, invitationId)
+	if err == invitationsAccess.ErrInvitationNotFound {
+		return ErrInvalidInvitation
+	}
+
+	return err
+}
+
+/*
+RedeemInvitation adds userId to the group of the invitation with the role of the invitation.
+
+A member that already has that role(or a better one) keeps their role and does not use up the invitation
+*/
+func (g *groupAccess) RedeemInvitation(userId string, token string) (models.Membership, error) {
+	if invitationSecret == nil {
+		return models.Membership{}, errNoInvitationSecret
+	}
+	groupId, invitationId, err := parseInvitationToken(token)
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	group, err := g.groupRepo.GetGroup(groupId)
+	if err == groupsAccess.ErrGroupNotFound {
+		return models.Membership{}, ErrInvalidInvitation //the group was deleted after the invitation was created
+	}
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	invitation, err := g.inviteRepo.GetInvitation(groupId, invitationId)
+	if err == invitationsAccess.ErrInvitationNotFound {
+		return models.Membership{}, ErrInvalidInvitation
+	}
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	role, err := g.roleOf(userId, group)
+	if err != nil {
+		return models.Membership{}, err
+	}
+	if role.Includes(invitation.Role) {
+		return models.Membership{GroupId: groupId, UserId: userId, Role: role}, nil
+	}
+
+	/*
+		UseInvitation checks the expiry and the number of uses again when it counts this use, so two
+		people redeeming the last use at the same time can't both get in
+	*/
+	t := now()
+	_, err = g.inviteRepo.UseInvitation(groupId, invitationId, t)
+	switch err {
+	case nil:
+	case invitationsAccess.ErrInvitationNotFound:
+		return models.Membership{}, ErrInvalidInvitation
+	case invitationsAccess.ErrInvitationUsedUp:
+		return models.Membership{}, ErrInvitationExpired
+	default:
+		return models.Membership{}, err
+	}
+
+	return g.memberRepo.AddMember(models.Membership{
+		GroupId:   groupId,
+		UserId:    userId,
+		Role:      invitation.Role,
+		Timestamp: models.FormatTimestamp(t),
+	})
+}
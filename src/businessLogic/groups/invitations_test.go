@@ -0,0 +1,107 @@
+package groups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+func newTestInvitation(t *testing.T, ga GroupAccess, groupId string, role string, maxUses int) (models.Invitation, string) {
+	t.Helper()
+
+	invitation, token, err := ga.CreateInvitation("owner", groupId, &requests.CreateInvitationRequest{Role: role, MaxUses: maxUses, ExpiresInHours: 24})
+	if err != nil {
+		t.Fatalf("CreateInvitation failed: %v", err)
+	}
+
+	return invitation, token
+}
+
+func TestOnlyOwnersManageInvitations(t *testing.T) {
+	ga, group := newTestGroup(t)
+	createReq := &requests.CreateInvitationRequest{Role: "viewer", MaxUses: 1, ExpiresInHours: 1}
+
+	if _, _, err := ga.CreateInvitation("editor", group.Id, createReq); err != ErrForbidden {
+		t.Errorf("CreateInvitation by an editor = %v, want %v", err, ErrForbidden)
+	}
+
+	invitation, _ := newTestInvitation(t, ga, group.Id, "viewer", 1)
+
+	if _, err := ga.GetInvitations("editor", group.Id); err != ErrForbidden {
+		t.Errorf("GetInvitations by an editor = %v, want %v", err, ErrForbidden)
+	}
+	if err := ga.RevokeInvitation("editor", group.Id, invitation.Id); err != ErrForbidden {
+		t.Errorf("RevokeInvitation by an editor = %v, want %v", err, ErrForbidden)
+	}
+}
+
+func TestRedeemInvitation(t *testing.T) {
+	ga, group := newTestGroup(t)
+	_, token := newTestInvitation(t, ga, group.Id, "editor", 2)
+
+	m, err := ga.RedeemInvitation("new", token)
+	if err != nil {
+		t.Fatalf("RedeemInvitation failed: %v", err)
+	}
+	if m.GroupId != group.Id || m.UserId != "new" || m.Role != models.RoleEditor {
+		t.Errorf("RedeemInvitation returned %+v", m)
+	}
+	if _, err := ga.RequireRole("new", group.Id, models.RoleEditor); err != nil {
+		t.Errorf("the user that redeemed the invitation is not an editor: %v", err)
+	}
+
+	//the owner already has a better role, so they keep it and don't use up the invitation
+	if m, err := ga.RedeemInvitation("owner", token); err != nil || m.Role != models.RoleOwner {
+		t.Errorf("RedeemInvitation by the owner = %+v, %v", m, err)
+	}
+
+	if _, err := ga.RedeemInvitation("second", token); err != nil {
+		t.Errorf("RedeemInvitation of the last use = %v, want nil", err)
+	}
+	if _, err := ga.RedeemInvitation("third", token); err != ErrInvitationExpired {
+		t.Errorf("RedeemInvitation past MaxUses = %v, want %v", err, ErrInvitationExpired)
+	}
+
+	pending, err := ga.GetInvitations("owner", group.Id)
+	if err != nil {
+		t.Fatalf("GetInvitations failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("GetInvitations returned %+v, want the used up invitation to be left out", pending)
+	}
+}
+
+func TestRedeemExpiredInvitation(t *testing.T) {
+	ga, group := newTestGroup(t)
+	_, token := newTestInvitation(t, ga, group.Id, "viewer", 1)
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+
+	if _, err := ga.RedeemInvitation("new", token); err != ErrInvitationExpired {
+		t.Errorf("RedeemInvitation after it expired = %v, want %v", err, ErrInvitationExpired)
+	}
+}
+
+func TestRedeemInvalidInvitation(t *testing.T) {
+	ga, group := newTestGroup(t)
+	invitation, token := newTestInvitation(t, ga, group.Id, "viewer", 1)
+
+	for _, bad := range []string{"", "abc", token + "x", "x" + token} {
+		if _, err := ga.RedeemInvitation("new", bad); err != ErrInvalidInvitation {
+			t.Errorf("RedeemInvitation(%q) = %v, want %v", bad, err, ErrInvalidInvitation)
+		}
+	}
+
+	if err := ga.RevokeInvitation("owner", group.Id, invitation.Id); err != nil {
+		t.Fatalf("RevokeInvitation failed: %v", err)
+	}
+	if _, err := ga.RedeemInvitation("new", token); err != ErrInvalidInvitation {
+		t.Errorf("RedeemInvitation of a revoked invitation = %v, want %v", err, ErrInvalidInvitation)
+	}
+	if err := ga.RevokeInvitation("owner", group.Id, invitation.Id); err != ErrInvalidInvitation {
+		t.Errorf("RevokeInvitation twice = %v, want %v", err, ErrInvalidInvitation)
+	}
+}
@@ -0,0 +1,101 @@
+package groups
+
+import (
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+/*
+RequireRole returns the group only if userId has at least the given role in it. Our handlers
+call this before they do anything in a group, eg add an image to it
+*/
+func (g *groupAccess) RequireRole(userId string, groupId string, role models.Role) (models.Group, error) {
+	group, err := g.groupRepo.GetGroup(groupId)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	r, err := g.roleOf(userId, group)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	if !r.Includes(role) {
+		return models.Group{}, ErrForbidden
+	}
+
+	return group, nil
+}
+
+/*
+roleOf returns the role of a user in a group, or an empty role if they are not a member.
+
+The creator of a group is always its owner. This also makes the groups created before we had
+memberships work, since they have a userId but no member rows
+*/
+func (g *groupAccess) roleOf(userId string, group models.Group) (models.Role, error) {
+	if userId == "" {
+		return "", nil
+	}
+
+	if group.UserId == userId {
+		return models.RoleOwner, nil
+	}
+
+	m, err := g.memberRepo.GetMember(group.Id, userId)
+	if err == membershipsAccess.ErrMemberNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return m.Role, nil
+}
+
+func (g *groupAccess) GetMembers(userId string, groupId string) ([]models.Membership, error) {
+	if _, err := g.RequireRole(userId, groupId, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return g.memberRepo.GetMembers(groupId)
+}
+
+func (g *groupAccess) AddMember(userId string, groupId string, addReq *requests.AddMemberRequest) (models.Membership, error) {
+	group, err := g.RequireRole(userId, groupId, models.RoleOwner)
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	//the creator is always the owner, so giving them another role would not do anything
+	if addReq.UserId == group.UserId {
+		return models.Membership{}, ErrForbidden
+	}
+
+	return g.memberRepo.AddMember(models.Membership{
+		GroupId:   groupId,
+		UserId:    addReq.UserId,
+		Role:      models.Role(addReq.Role),
+		Timestamp: models.NewTimestamp(),
+	})
+}
+
+// RemoveMember removes memberId from a group. Owners can remove anybody but the creator, and members can remove themselves
+func (g *groupAccess) RemoveMember(userId string, groupId string, memberId string) error {
+	role := models.RoleOwner
+	if userId == memberId {
+		role = models.RoleViewer
+	}
+
+	group, err := g.RequireRole(userId, groupId, role)
+	if err != nil {
+		return err
+	}
+
+	if memberId == group.UserId {
+		return ErrForbidden
+	}
+
+	return g.memberRepo.RemoveMember(groupId, memberId)
+}
@@ -0,0 +1,108 @@
+package groups
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+func newTestGroup(t *testing.T) (GroupAccess, models.Group) {
+	t.Helper()
+
+	ga := NewGroupAccess(groupsAccess.NewMemoryRepo(), membershipsAccess.NewMemoryRepo(), invitationsAccess.NewMemoryRepo(), tagsAccess.NewMemoryRepo())
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "Pictures of cats"})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	for user, role := range map[string]string{"editor": "editor", "viewer": "viewer"} {
+		if _, err := ga.AddMember("owner", group.Id, &requests.AddMemberRequest{UserId: user, Role: role}); err != nil {
+			t.Fatalf("AddMember(%s) failed: %v", user, err)
+		}
+	}
+
+	return ga, group
+}
+
+func TestRequireRole(t *testing.T) {
+	ga, group := newTestGroup(t)
+
+	tests := []struct {
+		user string
+		role models.Role
+		want error
+	}{
+		{"owner", models.RoleOwner, nil},
+		{"editor", models.RoleEditor, nil},
+		{"editor", models.RoleOwner, ErrForbidden},
+		{"viewer", models.RoleViewer, nil},
+		{"viewer", models.RoleEditor, ErrForbidden},
+		{"stranger", models.RoleViewer, ErrForbidden},
+		{"", models.RoleViewer, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		if _, err := ga.RequireRole(tt.user, group.Id, tt.role); err != tt.want {
+			t.Errorf("RequireRole(%q, %q) = %v, want %v", tt.user, tt.role, err, tt.want)
+		}
+	}
+
+	if _, err := ga.RequireRole("owner", "missing", models.RoleViewer); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("RequireRole of a missing group = %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
+
+func TestGroupChangesNeedARole(t *testing.T) {
+	ga, group := newTestGroup(t)
+	name := "Dogs"
+
+	if _, err := ga.UpdateGroup("viewer", group.Id, AnyVersion, &requests.UpdateGroupRequest{Name: &name}); err != ErrForbidden {
+		t.Errorf("UpdateGroup by a viewer = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := ga.UpdateGroup("editor", group.Id, AnyVersion, &requests.UpdateGroupRequest{Name: &name}); err != nil {
+		t.Errorf("UpdateGroup by an editor = %v, want nil", err)
+	}
+	if err := deleterOf(ga).DeleteGroup("editor", group.Id, AnyVersion); err != ErrForbidden {
+		t.Errorf("DeleteGroup by an editor = %v, want %v", err, ErrForbidden)
+	}
+	if err := deleterOf(ga).DeleteGroup("owner", group.Id, AnyVersion); err != nil {
+		t.Errorf("DeleteGroup by the owner = %v, want nil", err)
+	}
+}
+
+func TestManageMembers(t *testing.T) {
+	ga, group := newTestGroup(t)
+
+	if _, err := ga.AddMember("editor", group.Id, &requests.AddMemberRequest{UserId: "new", Role: "viewer"}); err != ErrForbidden {
+		t.Errorf("AddMember by an editor = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := ga.AddMember("owner", group.Id, &requests.AddMemberRequest{UserId: "owner", Role: "viewer"}); err != ErrForbidden {
+		t.Errorf("AddMember demoting the creator = %v, want %v", err, ErrForbidden)
+	}
+
+	members, err := ga.GetMembers("viewer", group.Id)
+	if err != nil {
+		t.Fatalf("GetMembers failed: %v", err)
+	}
+	if len(members) != 3 {
+		t.Errorf("GetMembers returned %d members, want 3", len(members))
+	}
+	if _, err := ga.GetMembers("stranger", group.Id); err != ErrForbidden {
+		t.Errorf("GetMembers by a stranger = %v, want %v", err, ErrForbidden)
+	}
+
+	if err := ga.RemoveMember("editor", group.Id, "viewer"); err != ErrForbidden {
+		t.Errorf("RemoveMember by an editor = %v, want %v", err, ErrForbidden)
+	}
+	if err := ga.RemoveMember("viewer", group.Id, "viewer"); err != nil {
+		t.Errorf("a member leaving the group = %v, want nil", err)
+	}
+	if err := ga.RemoveMember("owner", group.Id, "owner"); err != ErrForbidden {
+		t.Errorf("removing the creator = %v, want %v", err, ErrForbidden)
+	}
+}
@@ -0,0 +1,62 @@
+package groups
+
+import (
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+)
+
+/*
+GroupStats keeps the image statistics of the groups up to date, from the Images stream. Like
+GroupDeleter it is not part of GroupAccess, because it reads the Images table
+*/
+type GroupStats interface {
+	RecordImageAdded(eventId string, groupId string, imageId string, timestamp string) error
+	RecordImageRemoved(eventId string, groupId string, timestamp string) error
+}
+
+type groupStats struct {
+	groupRepo groupsAccess.Repository
+	imageRepo imagesAccess.Repository
+}
+
+func NewGroupStats(r groupsAccess.Repository, images imagesAccess.Repository) GroupStats {
+	return &groupStats{r, images}
+}
+
+/*
+RecordImageAdded counts an image of a group whose file was uploaded, and makes it the cover if it is the
+newest one. eventId is the id of the stream record, so a record that is delivered twice is only counted once
+*/
+func (s *groupStats) RecordImageAdded(eventId string, groupId string, imageId string, timestamp string) error {
+	if err := s.groupRepo.CountImages(groupId, eventId, 1); err != nil {
+		return err
+	}
+
+	return s.groupRepo.SetLatestImage(groupId, imageId, timestamp)
+}
+
+// RecordImageRemoved stops counting an uploaded image of a group, and picks another cover if it was the cover
+func (s *groupStats) RecordImageRemoved(eventId string, groupId string, timestamp string) error {
+	if err := s.groupRepo.CountImages(groupId, eventId, -1); err != nil {
+		return err
+	}
+
+	/*
+		The newest image that is left. Not a pending one, its file may never come. The pending ones are
+		filtered after the limit, so a page can be empty while there are more. We read a few at a time
+	*/
+	nextKey := ""
+	for {
+		latest, nk, err := s.imageRepo.GetImagesByGroup(groupId, imagesAccess.TimeRange{}, 10, nextKey, groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false)
+		if err != nil {
+			return err
+		}
+		if len(latest) > 0 {
+			return s.groupRepo.ReplaceLatestImage(groupId, timestamp, latest[0].ImageId, latest[0].Timestamp)
+		}
+		if nk == "" {
+			return s.groupRepo.ReplaceLatestImage(groupId, timestamp, "", "")
+		}
+		nextKey = nk
+	}
+}
@@ -0,0 +1,44 @@
+package groups
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+func TestImageStatistics(t *testing.T) {
+	ga, group := newTestGroup(t)
+	images := imagesAccess.NewMemoryRepo()
+	gs := NewGroupStats(ga.(*groupAccess).groupRepo, images)
+
+	const first, second, third, fourth = "2021-05-01T12:00:00.000000000Z", "2021-05-01T12:00:01.000000000Z", "2021-05-01T12:00:02.000000000Z", "2021-05-01T12:00:03.000000000Z"
+	//i4 is still pending, it is not counted and can't be the cover
+	for _, image := range []models.Image{{ImageId: "i1", GroupId: group.Id, Timestamp: first}, {ImageId: "i2", GroupId: group.Id, Timestamp: second}, {ImageId: "i4", GroupId: group.Id, Timestamp: fourth, Status: models.ImageStatusPending}} {
+		if _, err := images.CreateImage(image); err != nil {
+			t.Fatalf("CreateImage(%s) failed: %v", image.ImageId, err)
+		}
+	}
+
+	steps := []func() error{
+		func() error { return gs.RecordImageAdded("e1", group.Id, "i1", first) },
+		func() error { return gs.RecordImageAdded("e2", group.Id, "i2", second) },
+		func() error { return gs.RecordImageAdded("e2", group.Id, "i2", second) }, //the stream delivered e2 again
+		func() error { return gs.RecordImageAdded("e3", group.Id, "i3", third) },
+		func() error { return gs.RecordImageRemoved("e4", group.Id, first) },
+		func() error { return gs.RecordImageRemoved("e5", group.Id, third) }, //i3 is not in the table anymore, i2 is the newest left
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	got, err := ga.GetVisibleGroup("", group.Id)
+	if err != nil {
+		t.Fatalf("GetVisibleGroup failed: %v", err)
+	}
+	if got.ImageCount != 1 || got.CoverImageId != "i2" || got.LastImageAt != second {
+		t.Errorf("got %d images and cover %q at %q, want 1 image and cover i2 at %q", got.ImageCount, got.CoverImageId, got.LastImageAt, second)
+	}
+}
@@ -0,0 +1,104 @@
+package groups
+
+import (
+	"sort"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+indexedTags are the tags of a group that should be in the tag index. Only the public groups are,
+since the index is how anybody finds groups by tag. Unlisted and private groups keep their tags,
+they just can't be found with them
+*/
+func indexedTags(group models.Group) []string {
+	if !group.Listed() {
+		return nil
+	}
+	return group.Tags
+}
+
+/*
+reindexTags brings the tag index in line with a group we just updated. wasIndexed are the tags the
+group had in the index before the update.
+
+We tag the group with all its tags, not just the new ones: tagging is idempotent, so a client that
+retries an update after a failure here also fixes the tags we did not get to
+*/
+func (g *groupAccess) reindexTags(group models.Group, wasIndexed []string) error {
+	indexed := indexedTags(group)
+
+	var removed []string
+	for _, t := range wasIndexed {
+		if !contains(indexed, t) {
+			removed = append(removed, t)
+		}
+	}
+
+	if err := g.tagRepo.UntagGroup(group, removed); err != nil {
+		return err
+	}
+	return g.tagRepo.TagGroup(group, indexed)
+}
+
+/*
+GetGroupsByTag lists the public groups that have a tag, in the order they were created.
+
+The index only has the ids of the groups, so we read the groups themselves in one batch. A group can
+change between the two reads, eg be made private or lose the tag, and we leave those out. So a page
+can be shorter than l even when there are more pages
+*/
+func (g *groupAccess) GetGroupsByTag(tag string, l int64, n string, order groupsAccess.SortOrder) ([]models.Group, string, error) {
+	tag = models.NormalizeTag(tag)
+
+	ids, nk, err := g.tagRepo.GetGroupIdsByTag(tag, l, n, order)
+	if err != nil {
+		return nil, "", err
+	}
+
+	found, err := g.groupRepo.GetGroups(ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	groups := []models.Group{}
+	for _, group := range found {
+		if contains(indexedTags(group), tag) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nk, nil
+}
+
+// GetTagCloud returns the limit tags the most public groups have, the most used first
+func (g *groupAccess) GetTagCloud(limit int) ([]models.TagCount, error) {
+	counts, err := g.tagRepo.GetTagCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	//tags with the same count are in alphabetical order, so the cloud does not change on every call
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+
+	return counts, nil
+}
+
+func contains(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
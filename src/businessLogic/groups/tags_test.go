@@ -0,0 +1,141 @@
+package groups
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+// tagged returns the ids of the groups GetGroupsByTag lists for tag
+func tagged(t *testing.T, ga GroupAccess, tag string) []string {
+	t.Helper()
+
+	groups, _, err := ga.GetGroupsByTag(tag, 20, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetGroupsByTag(%s) failed: %v", tag, err)
+	}
+
+	ids := []string{}
+	for _, g := range groups {
+		ids = append(ids, g.Id)
+	}
+	return ids
+}
+
+func TestCreateGroupNormalizesTags(t *testing.T) {
+	ga, _ := newTestGroup(t)
+
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "d", Tags: []string{"Cute Cats", "#kittens", "kittens"}})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	if want := []string{"cute-cats", "kittens"}; !reflect.DeepEqual(group.Tags, want) {
+		t.Errorf("CreateGroup tags = %v, want %v", group.Tags, want)
+	}
+	//the tag is normalized when we look it up as well
+	if got := tagged(t, ga, "Cute Cats"); !reflect.DeepEqual(got, []string{group.Id}) {
+		t.Errorf("GetGroupsByTag returned %v, want %v", got, []string{group.Id})
+	}
+}
+
+func TestUpdateGroupReindexesTags(t *testing.T) {
+	ga, group := newTestGroup(t)
+	tags := []string{"cats", "dogs"}
+
+	group, err := ga.UpdateGroup("editor", group.Id, AnyVersion, &requests.UpdateGroupRequest{Tags: &tags})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if got := tagged(t, ga, "dogs"); len(got) != 1 {
+		t.Errorf("GetGroupsByTag(dogs) after adding it returned %v", got)
+	}
+
+	tags = []string{"cats"}
+	if _, err := ga.UpdateGroup("editor", group.Id, AnyVersion, &requests.UpdateGroupRequest{Tags: &tags}); err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if got := tagged(t, ga, "dogs"); len(got) != 0 {
+		t.Errorf("GetGroupsByTag(dogs) after removing it returned %v", got)
+	}
+
+	//a group that is not public anymore can't be found by its tags, but keeps them
+	unlisted := string(models.VisibilityUnlisted)
+	group, err = ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &unlisted})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if got := tagged(t, ga, "cats"); len(got) != 1 {
+		t.Errorf("GetGroupsByTag(cats) of a group that is not unlisted yet returned %v", got)
+	}
+	if group, err = visibilityOf(ga).Finish(group); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if got := tagged(t, ga, "cats"); len(got) != 0 {
+		t.Errorf("GetGroupsByTag(cats) of an unlisted group returned %v", got)
+	}
+	if !reflect.DeepEqual(group.Tags, tags) {
+		t.Errorf("the unlisted group has tags %v, want %v", group.Tags, tags)
+	}
+
+	public := string(models.VisibilityPublic)
+	group, err = ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &public})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if _, err := visibilityOf(ga).Finish(group); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if got := tagged(t, ga, "cats"); len(got) != 1 {
+		t.Errorf("GetGroupsByTag(cats) of a group made public again returned %v", got)
+	}
+}
+
+func TestTagCloud(t *testing.T) {
+	ga, _ := newTestGroup(t)
+
+	for _, tags := range [][]string{{"cats"}, {"cats", "dogs"}, {"birds", "dogs"}, {"cats"}} {
+		if _, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "n", Description: "d", Tags: tags}); err != nil {
+			t.Fatalf("CreateGroup failed: %v", err)
+		}
+	}
+	if _, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "n", Description: "d", Visibility: "private", Tags: []string{"secret"}}); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	cloud, err := ga.GetTagCloud(2)
+	if err != nil {
+		t.Fatalf("GetTagCloud failed: %v", err)
+	}
+	want := []models.TagCount{{Tag: "cats", Count: 3}, {Tag: "dogs", Count: 2}}
+	if !reflect.DeepEqual(cloud, want) {
+		t.Errorf("GetTagCloud(2) = %v, want %v", cloud, want)
+	}
+
+	cloud, _ = ga.GetTagCloud(10)
+	if len(cloud) != 3 {
+		t.Errorf("GetTagCloud(10) = %v, want the 3 tags of the public groups", cloud)
+	}
+}
+
+func TestDeleteGroupUntagsIt(t *testing.T) {
+	ga, _ := newTestGroup(t)
+
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "d", Tags: []string{"cats"}})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if err := deleterOf(ga).DeleteGroup("owner", group.Id, AnyVersion); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+
+	if got := tagged(t, ga, "cats"); len(got) != 0 {
+		t.Errorf("GetGroupsByTag after DeleteGroup returned %v", got)
+	}
+	if cloud, _ := ga.GetTagCloud(10); len(cloud) != 0 {
+		t.Errorf("GetTagCloud after DeleteGroup = %v, want nothing", cloud)
+	}
+}
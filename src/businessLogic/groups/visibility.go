@@ -0,0 +1,85 @@
+package groups
+
+import (
+	"log"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+VisibilityChanges gives the groups the visibility their owner asked for. UpdateGroup only stores it as
+the PendingVisibility of the group, in the same write as the rest of the update. Then the files of its
+images are tagged for it, which can take a while for a big group, and only then does the group get it.
+That is RetryPending's job, in retryImageCleanups.
+
+Whatever is stopped half way is still pending, so RetryPending finds it again and starts over. Tagging the
+files again is harmless, so is updating the tag index again
+*/
+type VisibilityChanges interface {
+	Finish(group models.Group) (models.Group, error)
+	RetryPending(limit int64) (int, error)
+}
+
+type visibilityChanges struct {
+	*groupAccess
+	imageRepo imagesAccess.Repository
+}
+
+func NewVisibilityChanges(r groupsAccess.Repository, t tagsAccess.Repository, images imagesAccess.Repository) VisibilityChanges {
+	return &visibilityChanges{&groupAccess{groupRepo: r, tagRepo: t}, images}
+}
+
+/*
+Finish tags the files of the images of a group for its pending visibility, then gives it to the group.
+It returns the group as it is now, which is the one it got when there was nothing pending
+*/
+func (c *visibilityChanges) Finish(group models.Group) (models.Group, error) {
+	v := group.PendingVisibility
+	if v == "" {
+		return group, nil
+	}
+
+	if err := c.imageRepo.SetImagesVisibility(group.Id, v); err != nil {
+		return group, err
+	}
+
+	//the tag index only has the listed groups. We don't know what we did before a failure, so we do all of it
+	changed := group
+	changed.Visibility, changed.PendingVisibility = v, ""
+	if changed.Listed() {
+		if err := c.tagRepo.TagGroup(changed, changed.Tags); err != nil {
+			return group, err
+		}
+	} else if err := c.tagRepo.UntagGroup(changed, changed.Tags); err != nil {
+		return group, err
+	}
+
+	finished, err := c.groupRepo.FinishVisibility(group.Id, v)
+	if err != nil {
+		return group, err
+	}
+	return finished, nil
+}
+
+// RetryPending finishes up to limit visibility changes that were stopped half way, and returns how many of them are done
+func (c *visibilityChanges) RetryPending(limit int64) (int, error) {
+	groups, err := c.groupRepo.GetPendingVisibility(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, g := range groups {
+		if _, err := c.Finish(g); err != nil {
+			//ErrVisibilityChanged too: the owner asked for another one meanwhile, and we will finish that one next time
+			log.Printf("Failed to give group %s its visibility %s: Error message was %s", g.Id, g.PendingVisibility, err.Error())
+			continue
+		}
+		done++
+	}
+
+	return done, nil
+}
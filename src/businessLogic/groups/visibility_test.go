@@ -0,0 +1,90 @@
+package groups
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+// visibilityOf returns the VisibilityChanges on the same repositories as ga, with no images
+func visibilityOf(ga GroupAccess) VisibilityChanges {
+	g := ga.(*groupAccess)
+	return NewVisibilityChanges(g.groupRepo, g.tagRepo, imagesAccess.NewMemoryRepo())
+}
+
+// failingImages is an images Repository that can't tag the files of the images until it is fixed
+type failingImages struct {
+	imagesAccess.Repository
+	down bool
+}
+
+func (r *failingImages) SetImagesVisibility(groupId string, v models.Visibility) error {
+	if r.down {
+		return errors.New("the images bucket is down")
+	}
+	return r.Repository.SetImagesVisibility(groupId, v)
+}
+
+func TestVisibilityIsPendingUntilFinished(t *testing.T) {
+	ga, group := newTestGroup(t)
+	private := string(models.VisibilityPrivate)
+
+	group, err := ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &private})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if group.Private() || group.PendingVisibility != models.VisibilityPrivate {
+		t.Errorf("UpdateGroup returned visibility %q pending %q, want it public until its images are private", group.GetVisibility(), group.PendingVisibility)
+	}
+	if !group.ImagesPrivate() {
+		t.Errorf("ImagesPrivate of a group becoming private = false, want new images to be private already")
+	}
+
+	finished, err := visibilityOf(ga).Finish(group)
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if !finished.Private() || finished.PendingVisibility != "" || finished.Version != group.Version+1 {
+		t.Errorf("Finish returned %+v, want a private group at version %d", finished, group.Version+1)
+	}
+
+	//finishing it again does nothing
+	if again, err := visibilityOf(ga).Finish(finished); err != nil || again.Version != finished.Version {
+		t.Errorf("Finish of a finished group returned %+v, %v", again, err)
+	}
+}
+
+func TestRetryPendingFinishesTheVisibility(t *testing.T) {
+	ga, group := newTestGroup(t)
+	g := ga.(*groupAccess)
+	images := &failingImages{Repository: imagesAccess.NewMemoryRepo(), down: true}
+	changes := NewVisibilityChanges(g.groupRepo, g.tagRepo, images)
+	private := string(models.VisibilityPrivate)
+
+	group, err := ga.UpdateGroup("owner", group.Id, AnyVersion, &requests.UpdateGroupRequest{Visibility: &private})
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if _, err := changes.Finish(group); err == nil {
+		t.Fatalf("Finish while the bucket is down returned nil, want an error")
+	}
+	if _, err := ga.GetVisibleGroup("", group.Id); err != nil {
+		t.Errorf("GetVisibleGroup of a group whose images are not private yet = %v, want nil", err)
+	}
+
+	if done, err := changes.RetryPending(10); err != nil || done != 0 {
+		t.Errorf("RetryPending while the bucket is down returned %d, %v, want 0, nil", done, err)
+	}
+
+	images.down = false
+	if done, err := changes.RetryPending(10); err != nil || done != 1 {
+		t.Errorf("RetryPending returned %d, %v, want 1, nil", done, err)
+	}
+	if _, err := ga.GetVisibleGroup("", group.Id); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("GetVisibleGroup after the retry = %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
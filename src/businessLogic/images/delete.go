@@ -0,0 +1,174 @@
+package images
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/notificationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/searchAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+ImageDeleter deletes images. It is not part of ImageAccess because it needs a lot more than the
+Images table, and we don't want getImages to connect to the search index and the websocket API
+*/
+type ImageDeleter interface {
+	DeleteImage(userId string, imageId string) error
+	RetryCleanups(limit int64) (int, error)
+}
+
+type imageDeleter struct {
+	imageRepo     imagesAccess.Repository
+	cleanupRepo   cleanupsAccess.Repository
+	searchRepo    searchAccess.Repository
+	notifications notificationsAccess.Repository
+	groups        groups.GroupAccess
+	now           func() time.Time
+}
+
+func NewImageDeleter(r imagesAccess.Repository, c cleanupsAccess.Repository, s searchAccess.Repository, n notificationsAccess.Repository, g groups.GroupAccess) ImageDeleter {
+	return &imageDeleter{r, c, s, n, g, time.Now}
+}
+
+const (
+	/*
+		A new cleanup is only due after this, so the retries don't start while DeleteImage is still
+		working on it. It is longer than the timeout of any of our functions
+	*/
+	cleanupGracePeriod = 5 * time.Minute
+	maxRetryDelay      = time.Hour
+)
+
+// imageDeletedMessage is what the websocket clients get when an image is deleted
+type imageDeletedMessage struct {
+	Type    string `json:"type"`
+	ImageId string `json:"imageId"`
+	GroupId string `json:"groupId"`
+}
+
+/*
+DeleteImage deletes an image, its files, its search document, and tells the websocket clients. The
+owners and editors of its group can delete it.
+
+The cleanup is stored before we start, so whatever fails is retried by RetryCleanups. We only return
+an error when the image is still in the Images table. Then the client can try again, but the image
+will be deleted by the retries anyway
+*/
+func (d *imageDeleter) DeleteImage(userId string, imageId string) error {
+	image, err := d.imageRepo.GetImage(imageId)
+	if err != nil {
+		return err
+	}
+
+	//like GetImage, the images of a private group don't exist for the users that are not members
+	if _, err := d.groups.GetVisibleGroup(userId, image.GroupId); err == groupsAccess.ErrGroupNotFound {
+		return imagesAccess.ErrImageNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := d.groups.RequireRole(userId, image.GroupId, models.RoleEditor); err != nil {
+		return err
+	}
+
+	now := d.now()
+	c := models.ImageCleanup{
+		ImageId:   image.ImageId,
+		GroupId:   image.GroupId,
+		Timestamp: image.Timestamp,
+		Steps:     append([]models.CleanupStep{}, models.CleanupSteps...),
+		RetryAt:   models.FormatTimestamp(now.Add(cleanupGracePeriod)),
+		CreatedAt: models.FormatTimestamp(now),
+	}
+	if err := d.cleanupRepo.SaveCleanup(c); err != nil {
+		return err
+	}
+
+	c, err = d.cleanUp(c)
+	if err != nil && len(c.Steps) > 0 && c.Steps[0] == models.CleanupRow {
+		return err
+	}
+
+	return nil
+}
+
+/*
+RetryCleanups goes on with up to limit cleanups that failed before, and returns how many of them are
+done. A cleanup that fails again waits longer every time
+*/
+func (d *imageDeleter) RetryCleanups(limit int64) (int, error) {
+	cleanups, err := d.cleanupRepo.GetDueCleanups(models.FormatTimestamp(d.now()), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for _, c := range cleanups {
+		if _, err := d.cleanUp(c); err == nil {
+			done++
+		}
+	}
+
+	return done, nil
+}
+
+/*
+cleanUp does the steps that are left, in order. When they are all done we forget the cleanup,
+otherwise we store what is left and when to retry
+*/
+func (d *imageDeleter) cleanUp(c models.ImageCleanup) (models.ImageCleanup, error) {
+	for len(c.Steps) > 0 {
+		if err := d.do(c, c.Steps[0]); err != nil {
+			log.Printf("Failed to clean up image %s at step %s, attempt %d: Error message was %s", c.ImageId, c.Steps[0], c.Attempts+1, err.Error())
+
+			c.Attempts++
+			c.LastError = err.Error()
+			c.RetryAt = models.FormatTimestamp(d.now().Add(retryDelay(c.Attempts)))
+			if serr := d.cleanupRepo.SaveCleanup(c); serr != nil {
+				//the cleanup we stored before is still there, with more steps left. It will be retried from there
+				log.Printf("Failed to save the cleanup of image %s: Error message was %s", c.ImageId, serr.Error())
+			}
+			return c, err
+		}
+
+		c.Steps = c.Steps[1:]
+	}
+
+	return c, d.cleanupRepo.DeleteCleanup(c.ImageId)
+}
+
+func (d *imageDeleter) do(c models.ImageCleanup, step models.CleanupStep) error {
+	switch step {
+	case models.CleanupRow:
+		return d.imageRepo.DeleteImage(c.GroupId, c.Timestamp)
+	case models.CleanupOriginal:
+		return d.imageRepo.DeleteOriginal(c.ImageId)
+	case models.CleanupThumbnail:
+		return d.imageRepo.DeleteThumbnail(c.ImageId)
+	case models.CleanupSearch:
+		return d.searchRepo.DeleteImage(c.ImageId)
+	case models.CleanupNotify:
+		return d.notifications.Broadcast(imageDeletedMessage{Type: "imageDeleted", ImageId: c.ImageId, GroupId: c.GroupId})
+	}
+
+	//a step this version does not know about, eg from a newer version. Skipping it could orphan something, so we leave it for later
+	return fmt.Errorf("unknown cleanup step %q", step)
+}
+
+// retryDelay doubles with every attempt, from a minute up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempts && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
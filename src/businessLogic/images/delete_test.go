@@ -0,0 +1,191 @@
+package images
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/notificationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/searchAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// failingImages is an images Repository whose steps of a cleanup fail as many times as we ask
+type failingImages struct {
+	imagesAccess.Repository
+	failures map[models.CleanupStep]int
+}
+
+func (f *failingImages) fail(step models.CleanupStep) error {
+	if f.failures[step] > 0 {
+		f.failures[step]--
+		return errors.New(string(step) + " is down")
+	}
+	return nil
+}
+
+func (f *failingImages) DeleteImage(groupId string, timestamp string) error {
+	if err := f.fail(models.CleanupRow); err != nil {
+		return err
+	}
+	return f.Repository.DeleteImage(groupId, timestamp)
+}
+
+func (f *failingImages) DeleteThumbnail(imageId string) error {
+	return f.fail(models.CleanupThumbnail)
+}
+
+type deleterTest struct {
+	deleter  *imageDeleter
+	images   *failingImages
+	cleanups cleanupsAccess.Repository
+	search   *searchAccess.SearchMemoryRepository
+	notified *notificationsAccess.NotificationsMemoryRepository
+	group    models.Group
+	image    models.Image
+}
+
+// newDeleterTest creates a group with an image, and a deleter whose clock we can move
+func newDeleterTest(t *testing.T, visibility models.Visibility) *deleterTest {
+	t.Helper()
+
+	ga, group := newTestGroupAccess(t, visibility)
+	images := &failingImages{imagesAccess.NewMemoryRepo(), map[models.CleanupStep]int{}}
+
+	image, _, err := NewImageAccess(images, ga).CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	dt := &deleterTest{
+		images:   images,
+		cleanups: cleanupsAccess.NewMemoryRepo(),
+		search:   searchAccess.NewMemoryRepo().(*searchAccess.SearchMemoryRepository),
+		notified: notificationsAccess.NewMemoryRepo().(*notificationsAccess.NotificationsMemoryRepository),
+		group:    group,
+		image:    image,
+	}
+	dt.deleter = NewImageDeleter(images, dt.cleanups, dt.search, dt.notified, ga).(*imageDeleter)
+	dt.setNow(time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	return dt
+}
+
+func (dt *deleterTest) setNow(now time.Time) {
+	dt.deleter.now = func() time.Time { return now }
+}
+
+// pending returns the cleanups that are left, whenever they are due
+func (dt *deleterTest) pending(t *testing.T) []models.ImageCleanup {
+	t.Helper()
+
+	cleanups, err := dt.cleanups.GetDueCleanups("9999", 10)
+	if err != nil {
+		t.Fatalf("GetDueCleanups failed: %v", err)
+	}
+	return cleanups
+}
+
+func TestDeleteImage(t *testing.T) {
+	dt := newDeleterTest(t, models.VisibilityPrivate)
+
+	for user, want := range map[string]error{"viewer": groups.ErrForbidden, "stranger": imagesAccess.ErrImageNotFound, "": imagesAccess.ErrImageNotFound} {
+		if err := dt.deleter.DeleteImage(user, dt.image.ImageId); err != want {
+			t.Errorf("DeleteImage by %q returned %v, want %v", user, err, want)
+		}
+	}
+	if err := dt.deleter.DeleteImage("editor", "missing"); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("DeleteImage of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+
+	if err := dt.deleter.DeleteImage("editor", dt.image.ImageId); err != nil {
+		t.Fatalf("DeleteImage by an editor failed: %v", err)
+	}
+
+	if _, err := dt.images.GetImage(dt.image.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of the deleted image returned %v", err)
+	}
+	if !reflect.DeepEqual(dt.search.Deleted, []string{dt.image.ImageId}) {
+		t.Errorf("the deleted search documents are %v, want the one of the image", dt.search.Deleted)
+	}
+	want := imageDeletedMessage{Type: "imageDeleted", ImageId: dt.image.ImageId, GroupId: dt.group.Id}
+	if len(dt.notified.Messages) != 1 || dt.notified.Messages[0] != want {
+		t.Errorf("the websocket clients got %+v, want %+v", dt.notified.Messages, want)
+	}
+	if cleanups := dt.pending(t); len(cleanups) != 0 {
+		t.Errorf("a cleanup that is done was not forgotten: %+v", cleanups)
+	}
+}
+
+func TestDeleteImageIsRetried(t *testing.T) {
+	dt := newDeleterTest(t, models.VisibilityPublic)
+	dt.images.failures[models.CleanupThumbnail] = 2
+
+	//the image is gone from our API even if its thumbnail is still there
+	if err := dt.deleter.DeleteImage("owner", dt.image.ImageId); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, err := dt.images.GetImage(dt.image.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of the deleted image returned %v", err)
+	}
+
+	cleanups := dt.pending(t)
+	if len(cleanups) != 1 || cleanups[0].Attempts != 1 || cleanups[0].LastError == "" ||
+		!reflect.DeepEqual(cleanups[0].Steps, []models.CleanupStep{models.CleanupThumbnail, models.CleanupSearch, models.CleanupNotify}) {
+		t.Fatalf("after a failure the cleanups are %+v, want the thumbnail, search and notify steps left", cleanups)
+	}
+	if len(dt.search.Deleted) != 0 {
+		t.Errorf("the steps after the one that failed were done: %v", dt.search.Deleted)
+	}
+
+	//a cleanup is not retried before it is due
+	if done, err := dt.deleter.RetryCleanups(10); err != nil || done != 0 {
+		t.Errorf("RetryCleanups before the cleanup is due returned %d, %v", done, err)
+	}
+
+	//the thumbnail fails again, then the cleanup waits longer
+	dt.setNow(time.Date(2021, 5, 1, 12, 1, 0, 0, time.UTC))
+	if done, _ := dt.deleter.RetryCleanups(10); done != 0 {
+		t.Errorf("RetryCleanups finished %d cleanups while the thumbnail step fails", done)
+	}
+	if cleanups := dt.pending(t); len(cleanups) != 1 || cleanups[0].Attempts != 2 || cleanups[0].RetryAt != "2021-05-01T12:03:00.000000000Z" {
+		t.Fatalf("after the second failure the cleanups are %+v, want it retried in 2 minutes", cleanups)
+	}
+
+	dt.setNow(time.Date(2021, 5, 1, 12, 3, 0, 0, time.UTC))
+	if done, err := dt.deleter.RetryCleanups(10); err != nil || done != 1 {
+		t.Fatalf("RetryCleanups returned %d, %v, want 1 cleanup done", done, err)
+	}
+	if len(dt.search.Deleted) != 1 || len(dt.notified.Messages) != 1 {
+		t.Errorf("the retry did not finish the cleanup: %v, %v", dt.search.Deleted, dt.notified.Messages)
+	}
+	if cleanups := dt.pending(t); len(cleanups) != 0 {
+		t.Errorf("a cleanup that is done was not forgotten: %+v", cleanups)
+	}
+}
+
+func TestDeleteImageWhenTheRowFails(t *testing.T) {
+	dt := newDeleterTest(t, models.VisibilityPublic)
+	dt.images.failures[models.CleanupRow] = 1
+
+	//the image is still there, so the client hears about it
+	if err := dt.deleter.DeleteImage("editor", dt.image.ImageId); err == nil {
+		t.Fatal("DeleteImage succeeded while the row could not be deleted")
+	}
+	if _, err := dt.images.GetImage(dt.image.ImageId); err != nil {
+		t.Errorf("GetImage after a failed delete returned %v", err)
+	}
+
+	//but it is still deleted by the retries
+	dt.setNow(time.Date(2021, 5, 1, 13, 0, 0, 0, time.UTC))
+	if done, err := dt.deleter.RetryCleanups(10); err != nil || done != 1 {
+		t.Fatalf("RetryCleanups returned %d, %v, want 1 cleanup done", done, err)
+	}
+	if _, err := dt.images.GetImage(dt.image.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage after the retry returned %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package images
+
+import (
+	"strconv"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+/*Like groups.GroupAccess, other developers might call this Service*/
+type ImageAccess interface {
+	CreateImage(userId string, groupId string, c *requests.CreateImageRequest) (models.Image, models.ImageUpload, error)
+	NewUpload(userId string, imageId string) (models.ImageUpload, error)
+	GetImages(userId string, groupId string, r imagesAccess.TimeRange, l int64, n string, order groupsAccess.SortOrder, by imagesAccess.SortBy, includePending bool) ([]models.Image, string, error)
+	GetImage(userId string, imageId string) (models.Image, error)
+	UpdateImage(userId string, imageId string, version int64, u *requests.UpdateImageRequest) (models.Image, error)
+
+	// Uploading the file of an image in parts, see multipart.go
+	StartMultipartUpload(userId string, imageId string) (models.MultipartUpload, error)
+	GetMultipartUpload(userId string, imageId string, uploadId string) (models.MultipartUpload, []models.UploadedPart, error)
+	SignParts(userId string, imageId string, uploadId string, s *requests.SignPartsRequest) ([]models.PartUpload, error)
+	CompleteMultipartUpload(userId string, imageId string, uploadId string) (models.Image, error)
+	AbortMultipartUpload(userId string, imageId string, uploadId string) error
+}
+
+/*
+This 'imageAccess' businessLogic stores the images through the imagesAccess.Repository Port. Who can
+see or add the images of a group depends on their role in it, so it asks the groups businessLogic
+*/
+type imageAccess struct {
+	imageRepo imagesAccess.Repository
+	groups    groups.GroupAccess
+	limits    UploadLimits
+}
+
+// uploadLimits are read once per Lambda instance, like the secret of our cursors
+var uploadLimits = UploadLimitsFromEnv()
+
+func NewImageAccess(r imagesAccess.Repository, g groups.GroupAccess) ImageAccess {
+	return &imageAccess{r, g, uploadLimits}
+}
+
+/*
+CreateImage adds an image to a group and returns how the client uploads its file. Only the editors
+and owners of a group can add images to it, and only the content types and sizes of our UploadLimits
+*/
+func (i *imageAccess) CreateImage(userId string, groupId string, createReq *requests.CreateImageRequest) (models.Image, models.ImageUpload, error) {
+	group, err := i.groups.RequireRole(userId, groupId, models.RoleEditor)
+	if err != nil {
+		return models.Image{}, models.ImageUpload{}, err
+	}
+	if err := i.limits.check(createReq.ContentType, createReq.Size); err != nil {
+		return models.Image{}, models.ImageUpload{}, err
+	}
+
+	id := uuid.Must(uuid.NewV4(), nil).String() //create a new id
+
+	/*
+		We sign the upload before we store the image. Once it is stored we must not fail: createImage
+		would forget the Idempotency-Key and the retry of the client would create a second image
+	*/
+	upload, err := i.imageRepo.GetUploadUrl(id, createReq.ContentType, createReq.Size, group.ImagesPrivate())
+	if err != nil {
+		return models.Image{}, models.ImageUpload{}, err
+	}
+
+	image, err := i.imageRepo.CreateImage(models.Image{
+		ImageId:     id,
+		GroupId:     groupId,
+		Title:       createReq.Title,
+		Timestamp:   models.NewTimestamp(),
+		ImageUrl:    i.imageRepo.PublicUrl(id),
+		ContentType: createReq.ContentType,
+		Size:        createReq.Size,
+		Status:      models.ImageStatusPending, //until the file is uploaded, see ImageStatuses
+	})
+	if err != nil {
+		return models.Image{}, models.ImageUpload{}, err
+	}
+
+	return image, upload, nil
+}
+
+/*
+NewUpload signs a new upload for an image that was already created, eg because the client took too
+long to use the first one. The user must still be an editor of its group
+*/
+func (i *imageAccess) NewUpload(userId string, imageId string) (models.ImageUpload, error) {
+	image, err := i.imageRepo.GetImage(imageId)
+	if err != nil {
+		return models.ImageUpload{}, err
+	}
+
+	group, err := i.groups.RequireRole(userId, image.GroupId, models.RoleEditor)
+	if err != nil {
+		return models.ImageUpload{}, err
+	}
+
+	if image.ContentType == "" {
+		return models.ImageUpload{}, ErrNoDeclaredUpload
+	}
+
+	return i.imageRepo.GetUploadUrl(image.ImageId, image.ContentType, image.Size, group.ImagesPrivate())
+}
+
+/*
+GetImages gets one page of the images of a group that were created in the time range. Like the group
+itself, only the members can see the images of a private group. The images whose file was never
+uploaded would be broken, so the pending ones are left out unless includePending is set. Only the
+editors can ask for them: they are the ones uploading them, and nobody else should see what is not
+published yet
+*/
+func (i *imageAccess) GetImages(userId string, groupId string, r imagesAccess.TimeRange, limit int64, nextKey string, order groupsAccess.SortOrder, by imagesAccess.SortBy, includePending bool) ([]models.Image, string, error) {
+	group, err := i.groups.GetVisibleGroup(userId, groupId)
+	if err != nil {
+		return nil, "", err
+	}
+	if includePending {
+		if _, err := i.groups.RequireRole(userId, groupId, models.RoleEditor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	images, nk, err := i.imageRepo.GetImagesByGroup(groupId, r, limit, nextKey, order, by, includePending)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := i.hideLocations(userId, group, images); err != nil {
+		return nil, "", err
+	}
+
+	return images, nk, i.signPrivateUrls(group, images)
+}
+
+/*
+GetImage gets an image. The image of a private group does not exist for the users that are not members,
+and only the members see where it was taken
+*/
+func (i *imageAccess) GetImage(userId string, imageId string) (models.Image, error) {
+	image, err := i.imageRepo.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	group, err := i.groups.GetVisibleGroup(userId, image.GroupId)
+	if err == groupsAccess.ErrGroupNotFound {
+		return models.Image{}, imagesAccess.ErrImageNotFound
+	}
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	images := []models.Image{image}
+	if err := i.hideLocations(userId, group, images); err != nil {
+		return models.Image{}, err
+	}
+	if err := i.signPrivateUrls(group, images); err != nil {
+		return models.Image{}, err
+	}
+
+	return images[0], nil
+}
+
+/*
+UpdateImage changes the title, description, alt text and tags of an image. Like the name of a group,
+the editors and owners of its group can change them. version is the version the client expects the
+image to be at, or groups.AnyVersion
+*/
+func (i *imageAccess) UpdateImage(userId string, imageId string, version int64, updateReq *requests.UpdateImageRequest) (models.Image, error) {
+	image, err := i.imageRepo.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	//like GetImage, the images of a private group don't exist for the users that are not members
+	if _, err := i.groups.GetVisibleGroup(userId, image.GroupId); err == groupsAccess.ErrGroupNotFound {
+		return models.Image{}, imagesAccess.ErrImageNotFound
+	} else if err != nil {
+		return models.Image{}, err
+	}
+
+	group, err := i.groups.RequireRole(userId, image.GroupId, models.RoleEditor)
+	if err != nil {
+		return models.Image{}, err
+	}
+	if version != groups.AnyVersion && version != image.Version {
+		return models.Image{}, imagesAccess.ErrImageChanged
+	}
+
+	// Only change the fields the caller sent us
+	if updateReq.Title != nil {
+		image.Title = *updateReq.Title
+	}
+	if updateReq.Description != nil {
+		image.Description = *updateReq.Description
+	}
+	if updateReq.AltText != nil {
+		image.AltText = *updateReq.AltText
+	}
+	if updateReq.Tags != nil {
+		image.Tags = models.NormalizeTags(*updateReq.Tags)
+	}
+
+	image, err = i.imageRepo.UpdateImage(image) //only saves if the image is still at the version we read
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	images := []models.Image{image}
+	if err := i.signPrivateUrls(group, images); err != nil {
+		return models.Image{}, err
+	}
+
+	return images[0], nil
+}
+
+// ETag returns the value of the ETag header for an image, like groups.ETag does for a group
+func ETag(image models.Image) string {
+	return `"` + strconv.FormatInt(image.Version, 10) + `"`
+}
+
+/*
+hideLocations takes out where the photos were taken, unless userId is a member of the group. The position
+of a photo can be somebody's home. The caller already checked the members of a private group
+*/
+func (i *imageAccess) hideLocations(userId string, group models.Group, images []models.Image) error {
+	if group.Private() {
+		return nil
+	}
+
+	found := false
+	for _, image := range images {
+		found = found || image.Location != nil
+	}
+	if !found {
+		return nil //no need to look up the caller
+	}
+
+	_, err := i.groups.RequireRole(userId, group.Id, models.RoleViewer)
+	if err == groups.ErrForbidden {
+		for n := range images {
+			images[n].Location = nil
+		}
+		return nil
+	}
+	return err
+}
+
+// signPrivateUrls gives the images of a private group a link that expires, since the bucket does not let anybody read them
+func (i *imageAccess) signPrivateUrls(group models.Group, images []models.Image) error {
+	if !group.Private() {
+		return nil
+	}
+
+	for n := range images {
+		url, err := i.imageRepo.GetPrivateUrl(images[n].ImageId)
+		if err != nil {
+			return err
+		}
+		images[n].ImageUrl = url
+	}
+
+	return nil
+}
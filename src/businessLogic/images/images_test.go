@@ -0,0 +1,238 @@
+package images
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+// newTestGroup creates a group with an owner, an editor and a viewer
+func newTestGroup(t *testing.T, visibility models.Visibility) (ImageAccess, models.Group) {
+	t.Helper()
+
+	ga, group := newTestGroupAccess(t, visibility)
+	return NewImageAccess(imagesAccess.NewMemoryRepo(), ga), group
+}
+
+func newTestGroupAccess(t *testing.T, visibility models.Visibility) (groups.GroupAccess, models.Group) {
+	t.Helper()
+
+	ga := groups.NewGroupAccess(groupsAccess.NewMemoryRepo(), membershipsAccess.NewMemoryRepo(), invitationsAccess.NewMemoryRepo(), tagsAccess.NewMemoryRepo())
+	group, err := ga.CreateGroup("owner", &requests.CreateGroupRequest{Name: "Cats", Description: "Pictures of cats", Visibility: string(visibility)})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	for user, role := range map[string]string{"editor": "editor", "viewer": "viewer"} {
+		if _, err := ga.AddMember("owner", group.Id, &requests.AddMemberRequest{UserId: user, Role: role}); err != nil {
+			t.Fatalf("AddMember(%s) failed: %v", user, err)
+		}
+	}
+
+	return ga, group
+}
+
+// catUpload is the request of an editor that adds a picture of a cat
+func catUpload() *requests.CreateImageRequest {
+	return &requests.CreateImageRequest{Title: "A cat", ContentType: "image/jpeg", Size: 52000}
+}
+
+func TestOnlyEditorsCreateImages(t *testing.T) {
+	ia, group := newTestGroup(t, models.VisibilityPublic)
+
+	for _, user := range []string{"viewer", "stranger", ""} {
+		if _, _, err := ia.CreateImage(user, group.Id, catUpload()); err != groups.ErrForbidden {
+			t.Errorf("CreateImage by %q returned %v, want %v", user, err, groups.ErrForbidden)
+		}
+	}
+
+	image, upload, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage by an editor failed: %v", err)
+	}
+	if image.ImageId == "" || image.GroupId != group.Id || image.Title != "A cat" || image.ImageUrl == "" {
+		t.Errorf("CreateImage returned %+v", image)
+	}
+	if upload.Url == "" || upload.Fields["Content-Type"] != "image/jpeg" || upload.Fields["tagging"] != "" {
+		t.Errorf("the upload of a public image is %+v, want a url and no tagging", upload)
+	}
+
+	got, err := ia.GetImage("", image.ImageId)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if got.ImageId != image.ImageId || got.Title != image.Title || got.ImageUrl != image.ImageUrl {
+		t.Errorf("GetImage returned %+v, want %+v", got, image)
+	}
+
+	if _, _, err := ia.CreateImage("editor", "missing", catUpload()); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("CreateImage in a missing group returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
+
+func TestImagesOfPrivateGroups(t *testing.T) {
+	ia, group := newTestGroup(t, models.VisibilityPrivate)
+
+	image, upload, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	//the file of a private image is tagged when it is uploaded, so the bucket does not let anybody read it
+	if !strings.Contains(upload.Fields["tagging"], "<Key>visibility</Key><Value>private</Value>") {
+		t.Errorf("the upload of a private image has fields %v", upload.Fields)
+	}
+
+	//for everybody else, the images of a private group don't exist
+	for _, user := range []string{"stranger", ""} {
+		if _, _, err := ia.GetImages(user, group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); err != groupsAccess.ErrGroupNotFound {
+			t.Errorf("GetImages by %q returned %v, want %v", user, err, groupsAccess.ErrGroupNotFound)
+		}
+		if _, err := ia.GetImage(user, image.ImageId); err != imagesAccess.ErrImageNotFound {
+			t.Errorf("GetImage by %q returned %v, want %v", user, err, imagesAccess.ErrImageNotFound)
+		}
+	}
+
+	//the members get a link that expires instead of the public url
+	images, _, err := ia.GetImages("editor", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, true)
+	if err != nil {
+		t.Fatalf("GetImages by a member failed: %v", err)
+	}
+	if len(images) != 1 || images[0].ImageUrl == image.ImageUrl || !strings.HasPrefix(images[0].ImageUrl, image.ImageUrl) {
+		t.Errorf("GetImages returned %+v, want the image with a signed url", images)
+	}
+
+	got, err := ia.GetImage("viewer", image.ImageId)
+	if err != nil {
+		t.Fatalf("GetImage by a member failed: %v", err)
+	}
+	if got.ImageUrl != images[0].ImageUrl {
+		t.Errorf("GetImage returned url %q, want %q", got.ImageUrl, images[0].ImageUrl)
+	}
+}
+
+func TestNewUpload(t *testing.T) {
+	ia, group := newTestGroup(t, models.VisibilityPrivate)
+
+	image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	upload, err := ia.NewUpload("owner", image.ImageId)
+	if err != nil {
+		t.Fatalf("NewUpload failed: %v", err)
+	}
+	if upload.Url == "" || upload.Fields["tagging"] == "" || upload.Fields["Content-Type"] != "image/jpeg" {
+		t.Errorf("NewUpload returned %+v", upload)
+	}
+
+	if _, err := ia.NewUpload("viewer", image.ImageId); err != groups.ErrForbidden {
+		t.Errorf("NewUpload by a viewer returned %v, want %v", err, groups.ErrForbidden)
+	}
+	if _, err := ia.NewUpload("owner", "missing"); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("NewUpload of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	ia, group := newTestGroup(t, models.VisibilityPublic)
+	ia.(*imageAccess).limits = UploadLimits{MaxSize: 1000, ContentTypes: []string{"image/jpeg", "image/png"}}
+
+	for _, req := range []*requests.CreateImageRequest{
+		{Title: "A page", ContentType: "text/html", Size: 100},
+		{Title: "A video", ContentType: "image/png", Size: 1001},
+	} {
+		if _, _, err := ia.CreateImage("editor", group.Id, req); apperrors.KindOf(err) != apperrors.KindValidation {
+			t.Errorf("CreateImage of %s of %d bytes returned %v, want an invalid request", req.ContentType, req.Size, err)
+		}
+	}
+
+	image, upload, err := ia.CreateImage("editor", group.Id, &requests.CreateImageRequest{Title: "A cat", ContentType: "IMAGE/PNG", Size: 1000})
+	if err != nil {
+		t.Fatalf("CreateImage within the limits failed: %v", err)
+	}
+	if image.ContentType != "IMAGE/PNG" || image.Size != 1000 || upload.Fields["Content-Type"] != "IMAGE/PNG" {
+		t.Errorf("CreateImage returned %+v, %+v", image, upload)
+	}
+}
+
+func TestUploadLimitsFromEnv(t *testing.T) {
+	setenv(t, "IMAGE_MAX_SIZE", "2048")
+	setenv(t, "IMAGE_CONTENT_TYPES", " image/jpeg, Image/WebP,")
+
+	l := UploadLimitsFromEnv()
+	if l.MaxSize != 2048 || strings.Join(l.ContentTypes, ",") != "image/jpeg,image/webp" {
+		t.Errorf("UploadLimitsFromEnv returned %+v", l)
+	}
+
+	setenv(t, "IMAGE_MAX_SIZE", "big")
+	if l := UploadLimitsFromEnv(); l.MaxSize != DefaultUploadLimits.MaxSize {
+		t.Errorf("UploadLimitsFromEnv with an invalid IMAGE_MAX_SIZE returned %+v", l)
+	}
+}
+
+// setenv sets an environment variable until the end of the test. We build with Go 1.16, which has no t.Setenv
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestUpdateImage(t *testing.T) {
+	ia, group := newTestGroup(t, models.VisibilityPrivate)
+
+	image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	title, alt := "Our cat", "A grey cat asleep"
+	update := &requests.UpdateImageRequest{Title: &title, AltText: &alt, Tags: &[]string{"#Cats", "sofa", "cats"}}
+
+	for user, want := range map[string]error{"viewer": groups.ErrForbidden, "stranger": imagesAccess.ErrImageNotFound} {
+		if _, err := ia.UpdateImage(user, image.ImageId, groups.AnyVersion, update); err != want {
+			t.Errorf("UpdateImage by %q returned %v, want %v", user, err, want)
+		}
+	}
+	if _, err := ia.UpdateImage("editor", image.ImageId, 3, update); err != imagesAccess.ErrImageChanged {
+		t.Errorf("UpdateImage of another version returned %v, want %v", err, imagesAccess.ErrImageChanged)
+	}
+
+	got, err := ia.UpdateImage("editor", image.ImageId, 0, update)
+	if err != nil {
+		t.Fatalf("UpdateImage by an editor failed: %v", err)
+	}
+	if got.Title != title || got.AltText != alt || got.Description != "" || strings.Join(got.Tags, ",") != "cats,sofa" || got.Version != 1 || ETag(got) != `"1"` {
+		t.Errorf("UpdateImage returned %+v", got)
+	}
+	//the image of a private group gets a link that expires, like GetImage
+	if got.ImageUrl == image.ImageUrl {
+		t.Errorf("UpdateImage returned the public url %q of a private image", got.ImageUrl)
+	}
+
+	//a field that is not sent does not change
+	description := "On the sofa"
+	got, err = ia.UpdateImage("owner", image.ImageId, groups.AnyVersion, &requests.UpdateImageRequest{Description: &description})
+	if err != nil {
+		t.Fatalf("UpdateImage by the owner failed: %v", err)
+	}
+	if got.Title != title || got.Description != description || len(got.Tags) != 2 || got.Version != 2 {
+		t.Errorf("the second UpdateImage returned %+v", got)
+	}
+}
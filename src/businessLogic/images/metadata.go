@@ -0,0 +1,29 @@
+package images
+
+import (
+	"github.com/udacity/serverless-golang/src/exif"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+NewImageMetadata is what we store on an image from its file: the size of its pixels, which only the
+decoder knows, and what the exif package read from it. The position is left out unless keepLocation,
+see Originals.KeepsLocation
+*/
+func NewImageMetadata(width int, height int, m exif.Metadata, keepLocation bool) models.ImageMetadata {
+	metadata := models.ImageMetadata{
+		Width:       width,
+		Height:      height,
+		CameraMake:  m.Make,
+		CameraModel: m.Model,
+		Orientation: m.Orientation,
+	}
+	if !m.TakenAt.IsZero() {
+		metadata.TakenAt = models.FormatTimestamp(m.TakenAt)
+	}
+	if m.GPS != nil && keepLocation {
+		metadata.Location = &models.GeoPoint{Lat: m.GPS.Latitude, Lon: m.GPS.Longitude}
+	}
+
+	return metadata
+}
@@ -0,0 +1,211 @@
+package images
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+/*
+StartMultipartUpload starts an upload of the file of an image in parts, for the files that are too
+big for the one request of NewUpload. Like NewUpload, only the editors and owners of its group can
+upload it. The image has one upload at a time, so starting one aborts the one it had.
+
+The parts are as small as S3 takes them, unless the declared size needs more than
+UploadLimits.MaxParts of them
+*/
+func (i *imageAccess) StartMultipartUpload(userId string, imageId string) (models.MultipartUpload, error) {
+	image, group, err := i.uploadableImage(userId, imageId)
+	if err != nil {
+		return models.MultipartUpload{}, err
+	}
+
+	uploadId, err := i.imageRepo.StartMultipartUpload(image.ImageId, image.ContentType, group.ImagesPrivate())
+	if err != nil {
+		return models.MultipartUpload{}, err
+	}
+
+	partSize, partCount := i.limits.parts(image.Size)
+	upload := &models.MultipartUpload{
+		UploadId:  uploadId,
+		PartSize:  partSize,
+		PartCount: partCount,
+		StartedAt: models.NewTimestamp(),
+	}
+
+	expected := ""
+	if image.Upload != nil {
+		expected = image.Upload.UploadId
+	}
+	if _, err := i.imageRepo.SetMultipartUpload(image.ImageId, expected, upload); err != nil {
+		//nobody will ever know about the upload we just started
+		if err := i.imageRepo.AbortMultipartUpload(image.ImageId, uploadId); err != nil {
+			log.Printf("Failed to abort upload %s of image %s: Error message was %s", uploadId, image.ImageId, err.Error())
+		}
+		return models.MultipartUpload{}, err
+	}
+
+	//the upload we replaced can't be completed anymore, so its parts only cost us money
+	if expected != "" {
+		if err := i.imageRepo.AbortMultipartUpload(image.ImageId, expected); err != nil {
+			log.Printf("Failed to abort upload %s of image %s: Error message was %s", expected, image.ImageId, err.Error())
+		}
+	}
+
+	return *upload, nil
+}
+
+// GetMultipartUpload gets an upload of an image and the parts that are uploaded already, so the client can resume it
+func (i *imageAccess) GetMultipartUpload(userId string, imageId string, uploadId string) (models.MultipartUpload, []models.UploadedPart, error) {
+	image, _, err := i.uploadableImage(userId, imageId)
+	if err != nil {
+		return models.MultipartUpload{}, nil, err
+	}
+
+	upload, err := currentUpload(image, uploadId)
+	if err != nil {
+		return models.MultipartUpload{}, nil, err
+	}
+
+	parts, err := i.imageRepo.GetUploadedParts(image.ImageId, uploadId)
+	if err != nil {
+		return models.MultipartUpload{}, nil, err
+	}
+
+	return upload, parts, nil
+}
+
+// SignParts returns the urls to upload some parts of an upload. A part can be signed again, eg when its url expired
+func (i *imageAccess) SignParts(userId string, imageId string, uploadId string, signReq *requests.SignPartsRequest) ([]models.PartUpload, error) {
+	image, _, err := i.uploadableImage(userId, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := currentUpload(image, uploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]models.PartUpload, 0, len(signReq.PartNumbers))
+	for _, n := range signReq.PartNumbers {
+		//the request only checks what S3 takes, the upload knows how many parts there are
+		if int64(n) > upload.PartCount {
+			return nil, apperrors.Invalid(fmt.Sprintf("partNumbers must be between 1 and %d", upload.PartCount))
+		}
+
+		url, err := i.imageRepo.GetUploadPartUrl(image.ImageId, uploadId, int64(n))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, models.PartUpload{PartNumber: int64(n), Url: url})
+	}
+
+	return parts, nil
+}
+
+/*
+CompleteMultipartUpload puts the parts of an upload together into the file of the image. Every part
+must be uploaded, and together they must be the size the image was created with, like for
+NewUpload. S3 then tells the images topic, and the image goes on like any other upload
+*/
+func (i *imageAccess) CompleteMultipartUpload(userId string, imageId string, uploadId string) (models.Image, error) {
+	image, group, err := i.uploadableImage(userId, imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	upload, err := currentUpload(image, uploadId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	parts, err := i.imageRepo.GetUploadedParts(image.ImageId, uploadId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	//the parts are sorted, so part n is at n-1 unless one is missing
+	size := int64(0)
+	for n := int64(1); n <= upload.PartCount; n++ {
+		if n > int64(len(parts)) || parts[n-1].PartNumber != n {
+			return models.Image{}, apperrors.Invalid(fmt.Sprintf("part %d was not uploaded", n))
+		}
+		size += parts[n-1].Size
+	}
+	if int64(len(parts)) != upload.PartCount {
+		return models.Image{}, apperrors.Invalid(fmt.Sprintf("the upload has %d parts, it must have %d", len(parts), upload.PartCount))
+	}
+	if size != image.Size {
+		return models.Image{}, apperrors.Invalid(fmt.Sprintf("the parts are %d bytes, the image was created with a size of %d", size, image.Size))
+	}
+
+	if err := i.imageRepo.CompleteMultipartUpload(image.ImageId, uploadId, parts); err != nil {
+		return models.Image{}, err
+	}
+
+	//the file is there already, the upload left on the image is only replaced by the next one
+	if updated, err := i.imageRepo.SetMultipartUpload(image.ImageId, uploadId, nil); err != nil {
+		log.Printf("Failed to remove upload %s of image %s: Error message was %s", uploadId, image.ImageId, err.Error())
+	} else {
+		image = updated
+	}
+
+	images := []models.Image{image}
+	if err := i.signPrivateUrls(group, images); err != nil {
+		return models.Image{}, err
+	}
+
+	return images[0], nil
+}
+
+// AbortMultipartUpload stops an upload of an image and deletes the parts that were uploaded
+func (i *imageAccess) AbortMultipartUpload(userId string, imageId string, uploadId string) error {
+	image, _, err := i.uploadableImage(userId, imageId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := currentUpload(image, uploadId); err != nil {
+		return err
+	}
+
+	if err := i.imageRepo.AbortMultipartUpload(image.ImageId, uploadId); err != nil {
+		return err
+	}
+
+	_, err = i.imageRepo.SetMultipartUpload(image.ImageId, uploadId, nil)
+	return err
+}
+
+// uploadableImage gets an image whose file the user can upload, with its group
+func (i *imageAccess) uploadableImage(userId string, imageId string) (models.Image, models.Group, error) {
+	image, err := i.imageRepo.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, models.Group{}, err
+	}
+
+	group, err := i.groups.RequireRole(userId, image.GroupId, models.RoleEditor)
+	if err != nil {
+		return models.Image{}, models.Group{}, err
+	}
+
+	if image.ContentType == "" {
+		return models.Image{}, models.Group{}, ErrNoDeclaredUpload
+	}
+
+	return image, group, nil
+}
+
+// currentUpload returns the upload of an image if it is the one the client has, only the last one started can be completed
+func currentUpload(image models.Image, uploadId string) (models.MultipartUpload, error) {
+	if image.Upload == nil || image.Upload.UploadId != uploadId {
+		return models.MultipartUpload{}, imagesAccess.ErrUploadNotFound
+	}
+
+	return *image.Upload, nil
+}
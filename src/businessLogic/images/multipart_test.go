@@ -0,0 +1,119 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+func TestUploadParts(t *testing.T) {
+	l := UploadLimits{MaxParts: 10}
+	for _, c := range []struct{ size, partSize, partCount int64 }{
+		{1, minPartSize, 1},
+		{minPartSize, minPartSize, 1},
+		{minPartSize + 1, minPartSize, 2},
+		{100 * minPartSize, 10 * minPartSize, 10},
+		{100*minPartSize + 1, 10*minPartSize + 1, 10},
+	} {
+		if partSize, partCount := l.parts(c.size); partSize != c.partSize || partCount != c.partCount {
+			t.Errorf("parts(%d) returned %d, %d, want %d, %d", c.size, partSize, partCount, c.partSize, c.partCount)
+		}
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo().(*imagesAccess.ImageMemoryRepository)
+	ia := &imageAccess{repo, ga, UploadLimits{MaxSize: 200 << 20, ContentTypes: []string{"image/jpeg"}, MaxParts: 2}}
+
+	size := int64(3 * minPartSize)
+	image, _, err := ia.CreateImage("editor", group.Id, &requests.CreateImageRequest{Title: "A big cat", ContentType: "image/jpeg", Size: size})
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	if _, err := ia.StartMultipartUpload("viewer", image.ImageId); apperrors.KindOf(err) != apperrors.KindForbidden {
+		t.Errorf("StartMultipartUpload by a viewer returned %v, want forbidden", err)
+	}
+
+	//the client lost the first one
+	lost, err := ia.StartMultipartUpload("editor", image.ImageId)
+	if err != nil {
+		t.Fatalf("StartMultipartUpload failed: %v", err)
+	}
+	upload, err := ia.StartMultipartUpload("editor", image.ImageId)
+	if err != nil {
+		t.Fatalf("StartMultipartUpload failed: %v", err)
+	}
+	if upload.PartCount != 2 || upload.PartSize != size/2 {
+		t.Errorf("StartMultipartUpload returned %+v, want 2 parts of %d bytes", upload, size/2)
+	}
+	if _, _, err := ia.GetMultipartUpload("editor", image.ImageId, lost.UploadId); err != imagesAccess.ErrUploadNotFound {
+		t.Errorf("GetMultipartUpload of a replaced upload returned %v, want %v", err, imagesAccess.ErrUploadNotFound)
+	}
+
+	if _, err := ia.SignParts("editor", image.ImageId, upload.UploadId, &requests.SignPartsRequest{PartNumbers: []int{1, 3}}); apperrors.KindOf(err) != apperrors.KindValidation {
+		t.Errorf("SignParts of a part after the last one returned %v, want an invalid request", err)
+	}
+	parts, err := ia.SignParts("editor", image.ImageId, upload.UploadId, &requests.SignPartsRequest{PartNumbers: []int{1, 2}})
+	if err != nil || len(parts) != 2 || parts[0].Url == parts[1].Url {
+		t.Fatalf("SignParts returned %+v, %v", parts, err)
+	}
+
+	//the connection drops after the first part
+	repo.UploadPart(upload.UploadId, 1, upload.PartSize)
+	if _, err := ia.CompleteMultipartUpload("editor", image.ImageId, upload.UploadId); apperrors.KindOf(err) != apperrors.KindValidation {
+		t.Errorf("CompleteMultipartUpload without the last part returned %v, want an invalid request", err)
+	}
+
+	//the client resumes with the parts that are missing
+	_, uploaded, err := ia.GetMultipartUpload("editor", image.ImageId, upload.UploadId)
+	if err != nil || len(uploaded) != 1 || uploaded[0].PartNumber != 1 {
+		t.Fatalf("GetMultipartUpload returned %+v, %v", uploaded, err)
+	}
+	repo.UploadPart(upload.UploadId, 2, upload.PartSize-1)
+	if _, err := ia.CompleteMultipartUpload("editor", image.ImageId, upload.UploadId); apperrors.KindOf(err) != apperrors.KindValidation {
+		t.Errorf("CompleteMultipartUpload of fewer bytes than declared returned %v, want an invalid request", err)
+	}
+	repo.UploadPart(upload.UploadId, 2, upload.PartSize)
+
+	completed, err := ia.CompleteMultipartUpload("editor", image.ImageId, upload.UploadId)
+	if err != nil {
+		t.Fatalf("CompleteMultipartUpload failed: %v", err)
+	}
+	if completed.Upload != nil {
+		t.Errorf("the image still has the upload %+v", completed.Upload)
+	}
+	if _, err := ia.CompleteMultipartUpload("editor", image.ImageId, upload.UploadId); err != imagesAccess.ErrUploadNotFound {
+		t.Errorf("CompleteMultipartUpload twice returned %v, want %v", err, imagesAccess.ErrUploadNotFound)
+	}
+}
+
+func TestAbortMultipartUpload(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo().(*imagesAccess.ImageMemoryRepository)
+	ia := NewImageAccess(repo, ga)
+
+	image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	upload, err := ia.StartMultipartUpload("editor", image.ImageId)
+	if err != nil {
+		t.Fatalf("StartMultipartUpload failed: %v", err)
+	}
+	repo.UploadPart(upload.UploadId, 1, image.Size)
+
+	if err := ia.AbortMultipartUpload("editor", image.ImageId, upload.UploadId); err != nil {
+		t.Fatalf("AbortMultipartUpload failed: %v", err)
+	}
+	if _, err := repo.GetUploadedParts(image.ImageId, upload.UploadId); err != imagesAccess.ErrUploadNotFound {
+		t.Errorf("the parts of an aborted upload are still there: %v", err)
+	}
+	if got, _ := repo.GetImage(image.ImageId); got.Upload != nil {
+		t.Errorf("the image still has the aborted upload %+v", got.Upload)
+	}
+}
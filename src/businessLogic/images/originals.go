@@ -0,0 +1,119 @@
+package images
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+Originals takes the metadata out of the originals in the images bucket, which anybody can download,
+so they don't tell where a photo was taken. Like ImageStatuses, only the S3 -> SNS pipeline calls it
+*/
+type Originals interface {
+	Replace(imageId string, file []byte, stripped []byte) (bool, error)
+	Hide(imageId string) error
+	KeepsLocation(imageId string) (bool, error)
+}
+
+type originals struct {
+	imageRepo imagesAccess.Repository
+	groupRepo groupsAccess.Repository
+	policy    models.OriginalsPolicy // the policy of the groups that have none
+}
+
+func NewOriginals(r imagesAccess.Repository, g groupsAccess.Repository, policy models.OriginalsPolicy) Originals {
+	return &originals{r, g, policy}
+}
+
+// DefaultOriginalsPolicy is the policy of a stage that does not set ORIGINALS_POLICY, eg a local run
+const DefaultOriginalsPolicy = models.OriginalsStrip
+
+// OriginalsPolicyFromEnv reads the ORIGINALS_POLICY environment variable. A missing or invalid one is the default
+func OriginalsPolicyFromEnv() models.OriginalsPolicy {
+	s := os.Getenv("ORIGINALS_POLICY")
+	if s == "" {
+		return DefaultOriginalsPolicy
+	}
+	if p := models.OriginalsPolicy(s); p.Valid() {
+		return p
+	}
+
+	log.Printf("Invalid ORIGINALS_POLICY %q. Using %s", s, DefaultOriginalsPolicy)
+	return DefaultOriginalsPolicy
+}
+
+/*
+Replace writes stripped, the file of an image without its metadata, over its original. When the group
+of the image keeps private copies, the file as it was uploaded is copied first, so it is never lost.
+
+It returns false when there was nothing to strip and the original was left alone, and
+imagesAccess.ErrImageNotFound or groupsAccess.ErrGroupNotFound when the image or its group is gone
+*/
+func (o *originals) Replace(imageId string, file []byte, stripped []byte) (bool, error) {
+	if bytes.Equal(file, stripped) {
+		return false, nil
+	}
+
+	image, err := o.imageRepo.GetImage(imageId)
+	if err != nil {
+		return false, err
+	}
+	group, err := o.groupRepo.GetGroup(image.GroupId)
+	if err != nil {
+		return false, err
+	}
+
+	//the images created before createImage asked for it have no content type
+	contentType := image.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(file)
+	}
+
+	if group.GetOriginalsPolicy(o.policy) == models.OriginalsKeepPrivateCopy {
+		if err := o.imageRepo.CopyOriginal(imageId, file, contentType); err != nil {
+			return false, err
+		}
+	}
+
+	//the group may have become private since the file was uploaded, so we tag it like the group is now
+	if err := o.imageRepo.ReplaceOriginal(imageId, stripped, contentType, group.ImagesPrivate()); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+Hide makes sure nobody downloads the original of an image we could not strip. It is tagged private,
+and if even that fails it is deleted, with the private copy if there is one. The image is failed
+anyway, its editors can upload the file again
+*/
+func (o *originals) Hide(imageId string) error {
+	err := o.imageRepo.HideOriginal(imageId)
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("Failed to hide the original of image %s, deleting it: Error message was %s", imageId, err.Error())
+	return o.imageRepo.DeleteOriginal(imageId)
+}
+
+// KeepsLocation tells if the group of an image lets us store where its photo was taken, see models.OriginalsPolicy.KeepsLocation
+func (o *originals) KeepsLocation(imageId string) (bool, error) {
+	image, err := o.imageRepo.GetImage(imageId)
+	if err != nil {
+		return false, err
+	}
+	group, err := o.groupRepo.GetGroup(image.GroupId)
+	if err != nil {
+		return false, err
+	}
+
+	return group.GetOriginalsPolicy(o.policy).KeepsLocation(), nil
+}
@@ -0,0 +1,123 @@
+package images
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// newTestOriginals stores an image in a group with that policy
+func newTestOriginals(t *testing.T, policy models.OriginalsPolicy, stage models.OriginalsPolicy) (Originals, *imagesAccess.ImageMemoryRepository) {
+	t.Helper()
+
+	imageRepo := imagesAccess.NewMemoryRepo().(*imagesAccess.ImageMemoryRepository)
+	groupRepo := groupsAccess.NewMemoryRepo()
+	if _, err := groupRepo.CreateGroup(models.Group{Id: "cats", Name: "Cats", OriginalsPolicy: policy, Timestamp: models.NewTimestamp()}); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if _, err := imageRepo.CreateImage(models.Image{ImageId: "cat", GroupId: "cats", ContentType: "image/jpeg", Timestamp: models.NewTimestamp()}); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	return NewOriginals(imageRepo, groupRepo, stage), imageRepo
+}
+
+func TestReplaceOriginal(t *testing.T) {
+	file, stripped := []byte("with the position"), []byte("without")
+
+	tests := []struct {
+		name        string
+		policy      models.OriginalsPolicy
+		stage       models.OriginalsPolicy
+		privateCopy bool
+	}{
+		{"strip", models.OriginalsStrip, models.OriginalsKeepPrivateCopy, false},
+		{"keep a private copy", models.OriginalsKeepPrivateCopy, models.OriginalsStrip, true},
+		{"policy of the stage", "", models.OriginalsKeepPrivateCopy, true},
+	}
+
+	for _, tt := range tests {
+		o, repo := newTestOriginals(t, tt.policy, tt.stage)
+
+		replaced, err := o.Replace("cat", file, stripped)
+		if err != nil || !replaced {
+			t.Fatalf("%s: Replace returned %v, %v", tt.name, replaced, err)
+		}
+		if got, _ := repo.Original("cat"); !bytes.Equal(got, stripped) {
+			t.Errorf("%s: the original is %q, want %q", tt.name, got, stripped)
+		}
+		if got, ok := repo.PrivateCopy("cat"); ok != tt.privateCopy || (ok && !bytes.Equal(got, file)) {
+			t.Errorf("%s: the private copy is %q, %v, want one: %v", tt.name, got, ok, tt.privateCopy)
+		}
+	}
+}
+
+func TestReplaceOriginalWithoutMetadata(t *testing.T) {
+	o, repo := newTestOriginals(t, models.OriginalsKeepPrivateCopy, models.OriginalsStrip)
+
+	//nothing is written, which is also what stops the pipeline from stripping the files it wrote forever
+	if replaced, err := o.Replace("cat", []byte("clean"), []byte("clean")); err != nil || replaced {
+		t.Errorf("Replace of a file without metadata returned %v, %v, want false", replaced, err)
+	}
+	if _, ok := repo.Original("cat"); ok {
+		t.Errorf("Replace of a file without metadata rewrote it")
+	}
+	if _, ok := repo.PrivateCopy("cat"); ok {
+		t.Errorf("Replace of a file without metadata copied it")
+	}
+
+	if _, err := o.Replace("dog", []byte("file"), []byte("stripped")); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("Replace of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func TestKeepsLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy models.OriginalsPolicy
+		stage  models.OriginalsPolicy
+		want   bool
+	}{
+		{"strip", models.OriginalsStrip, models.OriginalsKeepPrivateCopy, false},
+		{"keep a private copy", models.OriginalsKeepPrivateCopy, models.OriginalsStrip, true},
+		{"policy of the stage", "", models.OriginalsStrip, false},
+	}
+
+	for _, tt := range tests {
+		o, _ := newTestOriginals(t, tt.policy, tt.stage)
+		if keep, err := o.KeepsLocation("cat"); err != nil || keep != tt.want {
+			t.Errorf("%s: KeepsLocation returned %v, %v, want %v", tt.name, keep, err, tt.want)
+		}
+	}
+
+	o, _ := newTestOriginals(t, models.OriginalsKeepPrivateCopy, models.OriginalsStrip)
+	if keep, err := o.KeepsLocation("dog"); err != imagesAccess.ErrImageNotFound || keep {
+		t.Errorf("KeepsLocation of a missing image returned %v, %v, want %v", keep, err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func TestHideOriginal(t *testing.T) {
+	o, repo := newTestOriginals(t, models.OriginalsStrip, models.OriginalsStrip)
+
+	if err := o.Hide("cat"); err != nil {
+		t.Fatalf("Hide failed: %v", err)
+	}
+	if !repo.Hidden("cat") {
+		t.Errorf("Hide did not tag the original private")
+	}
+}
+
+func TestOriginalsPolicyFromEnv(t *testing.T) {
+	setenv(t, "ORIGINALS_POLICY", "keepPrivateCopy")
+	if p := OriginalsPolicyFromEnv(); p != models.OriginalsKeepPrivateCopy {
+		t.Errorf("OriginalsPolicyFromEnv returned %q", p)
+	}
+
+	setenv(t, "ORIGINALS_POLICY", "keep")
+	if p := OriginalsPolicyFromEnv(); p != DefaultOriginalsPolicy {
+		t.Errorf("OriginalsPolicyFromEnv with an invalid ORIGINALS_POLICY returned %q", p)
+	}
+}
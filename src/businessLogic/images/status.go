@@ -0,0 +1,84 @@
+package images
+
+import (
+	"log"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+ImageStatuses moves images through their lifecycle, see models.ImageStatus. Nobody calls it for a
+user: the S3 -> SNS pipeline tells it when files are uploaded and processed, and what it read from
+them, and a scheduled function expires the images whose file never came
+*/
+type ImageStatuses interface {
+	SetStatus(imageId string, status models.ImageStatus) (models.Image, error)
+	SetMetadata(imageId string, metadata models.ImageMetadata) (models.Image, error)
+	ExpirePending(ttl time.Duration, limit int64) (int, error)
+}
+
+type imageStatuses struct {
+	imageRepo imagesAccess.Repository
+	now       func() time.Time
+}
+
+func NewImageStatuses(r imagesAccess.Repository) ImageStatuses {
+	return &imageStatuses{r, time.Now}
+}
+
+/*
+SetStatus gives an image a new status. It returns imagesAccess.ErrImageNotFound for a file that has
+no image, eg because the image was deleted or expired before its file came
+*/
+func (s *imageStatuses) SetStatus(imageId string, status models.ImageStatus) (models.Image, error) {
+	return s.imageRepo.SetImageStatus(imageId, status, models.FormatTimestamp(s.now()))
+}
+
+// SetMetadata saves what was read from the file of an image. Like SetStatus, it returns imagesAccess.ErrImageNotFound for a file that has no image
+func (s *imageStatuses) SetMetadata(imageId string, metadata models.ImageMetadata) (models.Image, error) {
+	return s.imageRepo.SetImageMetadata(imageId, metadata)
+}
+
+/*
+ExpirePending deletes up to limit images that have been pending for longer than ttl, and returns how
+many it deleted. Their file was never uploaded so there is nothing else to clean up: no file, no
+thumbnail and no search document, see elasticSearchSync.
+
+The ttl of an image that is being uploaded in parts counts from when its upload was started, so a big
+file that takes long to upload is not expired under the client. GetPendingImages leaves those out,
+so they don't keep the images behind them from expiring. When that upload is abandoned we abort it
+with the image, so its parts don't cost us anything until the bucket lifecycle deletes them.
+
+A file that still comes after its image expired has no image, resizeImage deletes it
+*/
+func (s *imageStatuses) ExpirePending(ttl time.Duration, limit int64) (int, error) {
+	before := models.FormatTimestamp(s.now().Add(-ttl))
+	pending, err := s.imageRepo.GetPendingImages(before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, image := range pending {
+		err := s.imageRepo.ExpireImage(image.GroupId, image.Timestamp, before)
+		if err == imagesAccess.ErrImageNotPending {
+			continue //its file came since we read it, or an upload was started
+		}
+		if err != nil {
+			//the next run tries again
+			log.Printf("Failed to expire image %s: Error message was %s", image.ImageId, err.Error())
+			continue
+		}
+		expired++
+
+		if image.Upload != nil {
+			if err := s.imageRepo.AbortMultipartUpload(image.ImageId, image.Upload.UploadId); err != nil && err != imagesAccess.ErrUploadNotFound {
+				log.Printf("Failed to abort the upload %s of expired image %s: Error message was %s", image.Upload.UploadId, image.ImageId, err.Error())
+			}
+		}
+	}
+
+	return expired, nil
+}
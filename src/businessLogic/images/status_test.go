@@ -0,0 +1,210 @@
+package images
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/exif"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+func TestImageLifecycle(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo()
+	ia := NewImageAccess(repo, ga)
+	statuses := &imageStatuses{repo, time.Now}
+
+	image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	if image.Status != models.ImageStatusPending {
+		t.Errorf("a new image is %q, want %q", image.Status, models.ImageStatusPending)
+	}
+
+	//nobody wants to see a broken image, unless they ask for the pending ones
+	if images, _, _ := ia.GetImages("", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); len(images) != 0 {
+		t.Errorf("GetImages returned the pending images %+v", images)
+	}
+	if images, _, _ := ia.GetImages("editor", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, true); len(images) != 1 {
+		t.Errorf("GetImages with the pending images returned %+v", images)
+	}
+	//only the editors see what is not published yet
+	for _, user := range []string{"viewer", "stranger", ""} {
+		if _, _, err := ia.GetImages(user, group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, true); err != groups.ErrForbidden {
+			t.Errorf("GetImages with the pending images by %q returned %v, want %v", user, err, groups.ErrForbidden)
+		}
+	}
+
+	for _, status := range []models.ImageStatus{models.ImageStatusUploaded, models.ImageStatusProcessed} {
+		if _, err := statuses.SetStatus(image.ImageId, status); err != nil {
+			t.Fatalf("SetStatus(%s) failed: %v", status, err)
+		}
+	}
+
+	got, err := ia.GetImage("", image.ImageId)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if got.Status != models.ImageStatusProcessed || got.UploadedAt == "" || got.ProcessedAt == "" {
+		t.Errorf("GetImage after the upload returned %+v", got)
+	}
+	if images, _, _ := ia.GetImages("", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); len(images) != 1 {
+		t.Errorf("GetImages after the upload returned %+v", images)
+	}
+}
+
+func TestExpirePending(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo()
+	ia := NewImageAccess(repo, ga)
+	now := time.Now()
+	statuses := &imageStatuses{repo, func() time.Time { return now }}
+
+	var created []models.Image
+	for i := 0; i < 3; i++ {
+		image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+		if err != nil {
+			t.Fatalf("CreateImage failed: %v", err)
+		}
+		created = append(created, image)
+	}
+	//the file of the first one came
+	if _, err := statuses.SetStatus(created[0].ImageId, models.ImageStatusUploaded); err != nil {
+		t.Fatalf("SetStatus failed: %v", err)
+	}
+
+	if n, err := statuses.ExpirePending(time.Hour, 10); err != nil || n != 0 {
+		t.Errorf("ExpirePending of images that are not pending for long returned %d, %v", n, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if n, err := statuses.ExpirePending(time.Hour, 1); err != nil || n != 1 {
+		t.Errorf("ExpirePending with a limit of 1 returned %d, %v", n, err)
+	}
+	if n, err := statuses.ExpirePending(time.Hour, 10); err != nil || n != 1 {
+		t.Errorf("ExpirePending returned %d, %v, want the last pending image", n, err)
+	}
+
+	if _, err := repo.GetImage(created[0].ImageId); err != nil {
+		t.Errorf("the uploaded image expired: %v", err)
+	}
+	for _, image := range created[1:] {
+		if _, err := repo.GetImage(image.ImageId); err != imagesAccess.ErrImageNotFound {
+			t.Errorf("GetImage of an expired image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+		}
+	}
+}
+
+func TestExpirePendingWaitsForUploads(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo()
+	ia := NewImageAccess(repo, ga)
+	now := time.Now()
+	statuses := &imageStatuses{repo, func() time.Time { return now }}
+
+	image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	//a big file, whose upload was started long after the image was created
+	uploadId, err := repo.StartMultipartUpload(image.ImageId, "image/jpeg", false)
+	if err != nil {
+		t.Fatalf("StartMultipartUpload failed: %v", err)
+	}
+	upload := &models.MultipartUpload{UploadId: uploadId, PartSize: 5 << 20, PartCount: 10, StartedAt: models.FormatTimestamp(now.Add(90 * time.Minute))}
+	if _, err := repo.SetMultipartUpload(image.ImageId, "", upload); err != nil {
+		t.Fatalf("SetMultipartUpload failed: %v", err)
+	}
+
+	//and one the client forgot, created after it
+	forgotten, _, err := ia.CreateImage("editor", group.Id, catUpload())
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	//the image that is being uploaded is the oldest, but it does not use up the limit
+	now = now.Add(2 * time.Hour)
+	if n, err := statuses.ExpirePending(time.Hour, 1); err != nil || n != 1 {
+		t.Errorf("ExpirePending behind an image that is being uploaded returned %d, %v, want 1", n, err)
+	}
+	if _, err := repo.GetImage(forgotten.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of the forgotten image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+	if _, err := repo.GetImage(image.ImageId); err != nil {
+		t.Errorf("the image that is being uploaded expired: %v", err)
+	}
+	if _, err := repo.GetUploadedParts(image.ImageId, uploadId); err != nil {
+		t.Errorf("GetUploadedParts of an upload that is still going returned %v", err)
+	}
+
+	//the client gave up on it
+	now = now.Add(time.Hour)
+	if n, err := statuses.ExpirePending(time.Hour, 10); err != nil || n != 1 {
+		t.Errorf("ExpirePending of an image whose upload is old returned %d, %v", n, err)
+	}
+	if _, err := repo.GetUploadedParts(image.ImageId, uploadId); err != imagesAccess.ErrUploadNotFound {
+		t.Errorf("GetUploadedParts of the upload of an expired image returned %v, want %v", err, imagesAccess.ErrUploadNotFound)
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	ga, group := newTestGroupAccess(t, models.VisibilityPublic)
+	repo := imagesAccess.NewMemoryRepo()
+	ia := NewImageAccess(repo, ga)
+	statuses := NewImageStatuses(repo)
+
+	var created []models.Image
+	for i := 0; i < 2; i++ {
+		image, _, err := ia.CreateImage("editor", group.Id, catUpload())
+		if err != nil {
+			t.Fatalf("CreateImage failed: %v", err)
+		}
+		created = append(created, image)
+	}
+
+	//the second image was taken long before the first one was uploaded
+	taken := time.Date(2020, 7, 14, 10, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	file := exif.Metadata{TakenAt: taken, Make: "Canon", Orientation: 6, GPS: &exif.GPS{Latitude: 48.8582, Longitude: 2.2945}}
+	if m := NewImageMetadata(6000, 4000, file, false); m.Location != nil || m.CameraMake != "Canon" {
+		t.Errorf("NewImageMetadata of a group that does not keep the location returned %+v", m)
+	}
+	metadata := NewImageMetadata(6000, 4000, file, true)
+	if _, err := statuses.SetMetadata(created[1].ImageId, metadata); err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	got, err := ia.GetImage("viewer", created[1].ImageId)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if got.Width != 6000 || got.TakenAt != "2020-07-14T08:00:00.000000000Z" || got.CameraMake != "Canon" || got.Location == nil || got.Location.Lat != 48.8582 {
+		t.Errorf("GetImage after SetMetadata returned %+v", got)
+	}
+	//the group is public, but only its members know where the photo was taken
+	for _, user := range []string{"stranger", ""} {
+		if got, err := ia.GetImage(user, created[1].ImageId); err != nil || got.Location != nil || got.CameraMake != "Canon" {
+			t.Errorf("GetImage by %q returned %+v, %v, want no location", user, got, err)
+		}
+	}
+
+	images, _, err := ia.GetImages("editor", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.OldestFirst, imagesAccess.ByTakenAt, true)
+	if err != nil || len(images) != 2 || images[0].ImageId != created[1].ImageId || images[0].Location == nil {
+		t.Errorf("GetImages by the time they were taken returned %+v, %v", images, err)
+	}
+	for _, status := range []models.ImageStatus{models.ImageStatusUploaded, models.ImageStatusProcessed} {
+		if _, err := statuses.SetStatus(created[1].ImageId, status); err != nil {
+			t.Fatalf("SetStatus(%s) failed: %v", status, err)
+		}
+	}
+	if images, _, err := ia.GetImages("", group.Id, imagesAccess.TimeRange{}, 10, "", groupsAccess.OldestFirst, imagesAccess.ByTakenAt, false); err != nil || len(images) != 1 || images[0].Location != nil {
+		t.Errorf("GetImages by an anonymous caller returned %+v, %v, want no location", images, err)
+	}
+
+	if _, err := statuses.SetMetadata("nope", metadata); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("SetMetadata of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
@@ -0,0 +1,100 @@
+package images
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+// UploadLimits are what a client can upload as the file of an image. They are set per stage in serverless.yml
+type UploadLimits struct {
+	MaxSize      int64    // in bytes
+	ContentTypes []string // the MIME types resizeImage can decode, or fewer
+	MaxParts     int64    // how many parts a multipart upload can have. The bigger the file, the bigger the parts
+}
+
+// DefaultUploadLimits are the limits of a stage that does not set IMAGE_MAX_SIZE, IMAGE_CONTENT_TYPES or IMAGE_MAX_PARTS, eg a local run
+var DefaultUploadLimits = UploadLimits{
+	MaxSize:      200 << 20, //our photographers upload JPEGs of up to 200 MB
+	ContentTypes: []string{"image/jpeg", "image/png", "image/gif"},
+	MaxParts:     100,
+}
+
+// minPartSize is the smallest part S3 takes in a multipart upload, except for the last one
+const minPartSize = 5 << 20
+
+// ErrNoDeclaredUpload is returned by NewUpload for the images created before createImage asked for the content type and size
+var ErrNoDeclaredUpload = apperrors.Invalid("the image has no declared content type and size, create it again")
+
+/*
+UploadLimitsFromEnv reads the IMAGE_MAX_SIZE(in bytes), IMAGE_CONTENT_TYPES(comma separated) and
+IMAGE_MAX_PARTS environment variables. A missing or invalid one keeps its default
+*/
+func UploadLimitsFromEnv() UploadLimits {
+	l := DefaultUploadLimits
+
+	if s := os.Getenv("IMAGE_MAX_SIZE"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 {
+			log.Printf("Invalid IMAGE_MAX_SIZE %q. Using %d", s, l.MaxSize)
+		} else {
+			l.MaxSize = n
+		}
+	}
+
+	//S3 takes up to 10000 parts
+	if s := os.Getenv("IMAGE_MAX_PARTS"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 || n > 10000 {
+			log.Printf("Invalid IMAGE_MAX_PARTS %q. Using %d", s, l.MaxParts)
+		} else {
+			l.MaxParts = n
+		}
+	}
+
+	if s := os.Getenv("IMAGE_CONTENT_TYPES"); s != "" {
+		var types []string
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		l.ContentTypes = types
+	}
+
+	return l
+}
+
+// check returns an error when a file of that content type and size can't be uploaded
+func (l UploadLimits) check(contentType string, size int64) error {
+	allowed := false
+	for _, t := range l.ContentTypes {
+		//MIME types are case insensitive, and S3 stores what the client sends
+		if strings.EqualFold(t, contentType) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return apperrors.Invalid("contentType must be one of " + strings.Join(l.ContentTypes, ", "))
+	}
+
+	if size > l.MaxSize {
+		return apperrors.Invalid(fmt.Sprintf("size must be at most %d bytes", l.MaxSize))
+	}
+
+	return nil
+}
+
+// parts returns the size of the parts of a multipart upload of a file of that size, and how many there are
+func (l UploadLimits) parts(size int64) (int64, int64) {
+	partSize := (size + l.MaxParts - 1) / l.MaxParts
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+
+	return partSize, (size + partSize - 1) / partSize
+}
@@ -0,0 +1,192 @@
+/*
+Package cursor turns the LastEvaluatedKey of a DynamoDB Scan or Query into an opaque token we can give to clients.
+
+A token is the base64url encoded key followed by an HMAC-SHA256 signature of the key, so clients
+cannot read it or forge one, and the format is not tied to the schema of our tables:
+
+	base64url({"k":{"id":{"S":"..."}},"e":1620000000}) + "." + base64url(signature)
+
+Every paginated endpoint signs its cursors with a different scope, so a cursor from one listing
+cannot be replayed against another one
+*/
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+var (
+	// ErrInvalidCursor is returned for a token we did not sign, or that was signed for another scope
+	ErrInvalidCursor = apperrors.Invalid("invalid cursor")
+	// ErrExpiredCursor is returned for a token that is older than the ttl of the Codec
+	ErrExpiredCursor = apperrors.Invalid("expired cursor")
+	// ErrNoSecret is returned by a Codec FromEnv created in Lambda without a CURSOR_SECRET. It is our fault, not the client's
+	ErrNoSecret = errors.New("CURSOR_SECRET is not set")
+)
+
+// A Codec signs and verifies cursors
+type Codec struct {
+	secret []byte        // nil when we have none, then the Codec refuses every cursor
+	ttl    time.Duration // zero means cursors never expire
+	now    func() time.Time
+}
+
+// The attributes of a table or index key can only be strings, numbers or binary
+type keyAttr struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+type payload struct {
+	Key    map[string]keyAttr `json:"k"`
+	Expiry int64              `json:"e,omitempty"`
+}
+
+// NewCodec creates a Codec that signs with secret. Cursors expire after ttl, or never when ttl is zero
+func NewCodec(secret []byte, ttl time.Duration) *Codec {
+	return &Codec{secret, ttl, time.Now}
+}
+
+/*
+FromEnv creates a Codec from the CURSOR_SECRET and CURSOR_TTL(eg "24h") environment variables.
+
+Without a CURSOR_SECRET a local run signs with a random secret, so cursors are only valid in the
+process that created them. In Lambda every instance would have its own secret and the cursors would
+break whenever the next page lands on another instance, so there the Codec returns ErrNoSecret instead
+*/
+func FromEnv() *Codec {
+	secret := []byte(os.Getenv("CURSOR_SECRET"))
+	if len(secret) == 0 && !localRun() {
+		log.Println("CURSOR_SECRET is not set. Paginated listings will fail")
+		secret = nil
+	} else if len(secret) == 0 {
+		log.Println("CURSOR_SECRET is not set. Cursors will only be valid in this process")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+
+	var ttl time.Duration
+	if s := os.Getenv("CURSOR_TTL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			log.Printf("Invalid CURSOR_TTL %q. Cursors will not expire", s)
+		}
+		ttl = d
+	}
+
+	return NewCodec(secret, ttl)
+}
+
+// localRun tells if we run outside of Lambda, eg our tests and tools, or on the memory Adapters. Nothing we sign there outlives the process
+func localRun() bool {
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" || os.Getenv("GROUPS_REPOSITORY") == "memory" || os.Getenv("IMAGES_REPOSITORY") == "memory"
+}
+
+// Encode returns the cursor for key. An empty key means there are no more pages so we return an empty string
+func (c *Codec) Encode(scope string, key map[string]*dynamodb.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	if c.secret == nil {
+		return "", ErrNoSecret
+	}
+
+	p := payload{Key: make(map[string]keyAttr, len(key))}
+	for name, v := range key {
+		if v == nil || (v.S == nil && v.N == nil && v.B == nil) {
+			return "", errors.New("cursor: key attribute " + name + " is not a string, number or binary")
+		}
+		p.Key[name] = keyAttr{v.S, v.N, v.B}
+	}
+
+	if c.ttl > 0 {
+		p.Expiry = c.now().Add(c.ttl).Unix()
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(b)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(scope, body)), nil
+}
+
+// Decode verifies a cursor and returns the key it was created from. An empty cursor is the first page so we return a nil key
+func (c *Codec) Decode(scope string, cursor string) (map[string]*dynamodb.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	if c.secret == nil {
+		return nil, ErrNoSecret
+	}
+
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, c.sign(scope, parts[0])) {
+		return nil, ErrInvalidCursor
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var p payload
+	if err := json.Unmarshal(b, &p); err != nil || len(p.Key) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	if p.Expiry != 0 && c.now().Unix() > p.Expiry {
+		return nil, ErrExpiredCursor
+	}
+
+	key := make(map[string]*dynamodb.AttributeValue, len(p.Key))
+	for name, v := range p.Key {
+		key[name] = &dynamodb.AttributeValue{S: v.S, N: v.N, B: v.B}
+	}
+
+	return key, nil
+}
+
+// IsInvalid tells if err means the client sent us a bad cursor
+func IsInvalid(err error) bool {
+	return err == ErrInvalidCursor || err == ErrExpiredCursor
+}
+
+// StringKey is a shorthand for a key made only of string attributes, like the ones our in-memory Repositories build
+func StringKey(attrs map[string]string) map[string]*dynamodb.AttributeValue {
+	key := make(map[string]*dynamodb.AttributeValue, len(attrs))
+	for name, v := range attrs {
+		key[name] = &dynamodb.AttributeValue{S: aws.String(v)}
+	}
+	return key
+}
+
+func (c *Codec) sign(scope, body string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0}) // so that scope "a" + body "bc" is not the same as scope "ab" + body "c"
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
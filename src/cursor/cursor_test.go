@@ -0,0 +1,96 @@
+package cursor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := NewCodec([]byte("secret"), 0)
+	key := map[string]*dynamodb.AttributeValue{
+		"groupId":   {S: aws.String("g1")},
+		"timestamp": {S: aws.String("2021-05-01T12:00:00Z")},
+		"count":     {N: aws.String("42")},
+		"raw":       {B: []byte{0, 1, 2}},
+	}
+
+	token, err := c.Encode("images", key)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	got, err := c.Decode("images", token)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, key) {
+		t.Errorf("Decode returned %v, want %v", got, key)
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	c := NewCodec([]byte("secret"), 0)
+
+	if token, err := c.Encode("groups", nil); token != "" || err != nil {
+		t.Errorf("Encode(nil) = %q, %v, want an empty cursor", token, err)
+	}
+	if key, err := c.Decode("groups", ""); key != nil || err != nil {
+		t.Errorf("Decode(\"\") = %v, %v, want a nil key", key, err)
+	}
+}
+
+func TestRejectsForeignCursors(t *testing.T) {
+	c := NewCodec([]byte("secret"), 0)
+	token, _ := c.Encode("groups", StringKey(map[string]string{"id": "g1"}))
+
+	if _, err := c.Decode("groups:user:u1", token); err != ErrInvalidCursor {
+		t.Errorf("Decode with another scope returned %v, want %v", err, ErrInvalidCursor)
+	}
+	if _, err := NewCodec([]byte("other"), 0).Decode("groups", token); err != ErrInvalidCursor {
+		t.Errorf("Decode with another secret returned %v, want %v", err, ErrInvalidCursor)
+	}
+	for _, bad := range []string{"x", "a.b.c", `{"id":"g1"}`, "eyJrIjp7fX0." + token[len(token)-10:]} {
+		if _, err := c.Decode("groups", bad); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) returned %v, want %v", bad, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(1620000000, 0)
+	c := NewCodec([]byte("secret"), time.Hour)
+	c.now = func() time.Time { return now }
+
+	token, _ := c.Encode("groups", StringKey(map[string]string{"id": "g1"}))
+
+	now = now.Add(59 * time.Minute)
+	if _, err := c.Decode("groups", token); err != nil {
+		t.Errorf("Decode before expiry returned %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := c.Decode("groups", token); err != ErrExpiredCursor {
+		t.Errorf("Decode after expiry returned %v, want %v", err, ErrExpiredCursor)
+	}
+}
+
+func TestFromEnvFailsClosedInLambda(t *testing.T) {
+	os.Setenv("AWS_LAMBDA_FUNCTION_NAME", "getGroups")
+	os.Unsetenv("CURSOR_SECRET")
+	defer os.Unsetenv("AWS_LAMBDA_FUNCTION_NAME")
+
+	//a random secret would break the cursors on every other instance, so there is none
+	c := FromEnv()
+	key := map[string]*dynamodb.AttributeValue{"id": {S: aws.String("1")}}
+	if _, err := c.Encode("groups", key); err != ErrNoSecret {
+		t.Errorf("Encode without a secret in Lambda returned %v, want %v", err, ErrNoSecret)
+	}
+	if _, err := c.Decode("groups", "abc.def"); err != ErrNoSecret {
+		t.Errorf("Decode without a secret in Lambda returned %v, want %v", err, ErrNoSecret)
+	}
+}
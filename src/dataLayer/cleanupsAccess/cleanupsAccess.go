@@ -0,0 +1,161 @@
+package cleanupsAccess
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It stores the images and the groups we
+are deleting until everything about them is gone, see models.ImageCleanup and models.GroupCleanup.
+
+We have two Adapters for it: CleanupDynamoDbRepository for AWS and CleanupMemoryRepository for unit
+tests and running our handlers locally
+*/
+type Repository interface {
+	SaveCleanup(c models.ImageCleanup) error
+	GetDueCleanups(now string, limit int64) ([]models.ImageCleanup, error)
+	DeleteCleanup(imageId string) error
+
+	SaveGroupCleanup(c models.GroupCleanup) error
+	GetDueGroupCleanups(now string, limit int64) ([]models.GroupCleanup, error)
+	DeleteGroupCleanup(groupId string) error
+}
+
+// CleanupDynamoDbRepository is the Adapter that keeps the cleanups in DynamoDB
+type CleanupDynamoDbRepository struct {
+	client     *dynamodb.DynamoDB
+	table      *string // the image cleanups, by imageId
+	groupTable *string // the group cleanups, by groupId
+}
+
+var (
+	tableName      = aws.String(os.Getenv("CLEANUPS_TABLE"))
+	groupTableName = aws.String(os.Getenv("GROUP_CLEANUPS_TABLE"))
+	/*
+		Set CLEANUPS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("CLEANUPS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// Creates a DynamoDb client
+func createDynamoDBClient() *dynamodb.DynamoDB {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(svc.Client)
+	return svc
+}
+
+// NewRepo creates the Repository our handlers should use based on the CLEANUPS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	return &CleanupDynamoDbRepository{createDynamoDBClient(), tableName, groupTableName}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client and tables
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string, groupTable string) Repository {
+	return &CleanupDynamoDbRepository{c, aws.String(table), aws.String(groupTable)}
+}
+
+// SaveCleanup stores a cleanup, or replaces the one of the same image with what is left to do
+func (r *CleanupDynamoDbRepository) SaveCleanup(c models.ImageCleanup) error {
+	item, err := dynamodbattribute.MarshalMap(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.client.PutItem(&dynamodb.PutItemInput{
+		Item:      item,
+		TableName: r.table,
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+/*
+GetDueCleanups returns up to limit cleanups that should be retried at now, a timestamp. A cleanup is
+deleted as soon as it is done, so the table only has the few that failed and we can Scan it
+*/
+func (r *CleanupDynamoDbRepository) GetDueCleanups(now string, limit int64) ([]models.ImageCleanup, error) {
+	items, err := r.scanDue(r.table, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanups := []models.ImageCleanup{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &cleanups); err != nil {
+		return nil, err
+	}
+
+	return cleanups, nil
+}
+
+// scanDue reads up to limit items of a cleanups table whose retryAt is now or before
+func (r *CleanupDynamoDbRepository) scanDue(table *string, now string, limit int64) ([]map[string]*dynamodb.AttributeValue, error) {
+	input := &dynamodb.ScanInput{
+		TableName:        table,
+		FilterExpression: aws.String("retryAt <= :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {
+				S: aws.String(now),
+			},
+		},
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{}
+
+	//the filter is applied after a page is read, so a page can be empty even when the next one is not
+	for int64(len(items)) < limit {
+		result, err := r.client.Scan(input)
+		if err != nil {
+			return nil, apperrors.FromAWS(err)
+		}
+		items = append(items, result.Items...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// DeleteCleanup forgets the cleanup of an image once it is done. It is not an error if there is none
+func (r *CleanupDynamoDbRepository) DeleteCleanup(imageId string) error {
+	if _, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"imageId": {
+				S: aws.String(imageId),
+			},
+		},
+		TableName: r.table,
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
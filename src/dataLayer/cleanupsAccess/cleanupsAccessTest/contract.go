@@ -0,0 +1,178 @@
+/*
+Package cleanupsAccessTest is the contract every Adapter of the cleanupsAccess.Repository Port must pass
+*/
+package cleanupsAccessTest
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() cleanupsAccess.Repository) {
+	t.Run("SaveAndGetDueCleanups", func(t *testing.T) {
+		testSaveAndGetDueCleanups(t, newRepo())
+	})
+	t.Run("SaveCleanupAgain", func(t *testing.T) {
+		testSaveCleanupAgain(t, newRepo())
+	})
+	t.Run("DeleteCleanup", func(t *testing.T) {
+		testDeleteCleanup(t, newRepo())
+	})
+	t.Run("GroupCleanups", func(t *testing.T) {
+		testGroupCleanups(t, newRepo())
+	})
+}
+
+func newCleanup(imageId string, retryAt string) models.ImageCleanup {
+	return models.ImageCleanup{
+		ImageId:   imageId,
+		GroupId:   "g1",
+		Timestamp: "2021-05-01T12:00:00.000000000Z",
+		Steps:     models.CleanupSteps,
+		RetryAt:   retryAt,
+		CreatedAt: "2021-05-01T12:00:00.000000000Z",
+	}
+}
+
+func dueIds(t *testing.T, r cleanupsAccess.Repository, now string, limit int64) []string {
+	t.Helper()
+
+	cleanups, err := r.GetDueCleanups(now, limit)
+	if err != nil {
+		t.Fatalf("GetDueCleanups failed: %v", err)
+	}
+
+	ids := []string{}
+	for _, c := range cleanups {
+		ids = append(ids, c.ImageId)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func testSaveAndGetDueCleanups(t *testing.T, r cleanupsAccess.Repository) {
+	if ids := dueIds(t, r, "2021-05-01T13:00:00.000000000Z", 10); len(ids) != 0 {
+		t.Errorf("GetDueCleanups of an empty Repository returned %v", ids)
+	}
+
+	for _, c := range []models.ImageCleanup{
+		newCleanup("i1", "2021-05-01T12:05:00.000000000Z"),
+		newCleanup("i2", "2021-05-01T12:10:00.000000000Z"),
+		newCleanup("i3", "2021-05-01T14:00:00.000000000Z"),
+	} {
+		if err := r.SaveCleanup(c); err != nil {
+			t.Fatalf("SaveCleanup(%s) failed: %v", c.ImageId, err)
+		}
+	}
+
+	//a cleanup is due at its retryAt, not before
+	if ids := dueIds(t, r, "2021-05-01T12:10:00.000000000Z", 10); !reflect.DeepEqual(ids, []string{"i1", "i2"}) {
+		t.Errorf("the due cleanups are %v, want [i1 i2]", ids)
+	}
+	if ids := dueIds(t, r, "2021-05-01T13:00:00.000000000Z", 1); len(ids) != 1 {
+		t.Errorf("GetDueCleanups with a limit of 1 returned %v", ids)
+	}
+
+	cleanups, _ := r.GetDueCleanups("2021-05-01T12:05:00.000000000Z", 10)
+	if want := newCleanup("i1", "2021-05-01T12:05:00.000000000Z"); len(cleanups) != 1 || !reflect.DeepEqual(cleanups[0], want) {
+		t.Errorf("GetDueCleanups returned %+v, want %+v", cleanups, want)
+	}
+}
+
+func testSaveCleanupAgain(t *testing.T, r cleanupsAccess.Repository) {
+	c := newCleanup("i1", "2021-05-01T12:05:00.000000000Z")
+	if err := r.SaveCleanup(c); err != nil {
+		t.Fatalf("SaveCleanup failed: %v", err)
+	}
+
+	//after a failure we store what is left to do
+	c.Steps = c.Steps[2:]
+	c.Attempts = 1
+	c.LastError = "the bucket is down"
+	c.RetryAt = "2021-05-01T12:10:00.000000000Z"
+	if err := r.SaveCleanup(c); err != nil {
+		t.Fatalf("SaveCleanup of the same image failed: %v", err)
+	}
+
+	cleanups, err := r.GetDueCleanups("2021-05-01T13:00:00.000000000Z", 10)
+	if err != nil {
+		t.Fatalf("GetDueCleanups failed: %v", err)
+	}
+	if len(cleanups) != 1 || !reflect.DeepEqual(cleanups[0], c) {
+		t.Errorf("GetDueCleanups returned %+v, want %+v", cleanups, c)
+	}
+}
+
+func testDeleteCleanup(t *testing.T, r cleanupsAccess.Repository) {
+	if err := r.SaveCleanup(newCleanup("i1", "2021-05-01T12:05:00.000000000Z")); err != nil {
+		t.Fatalf("SaveCleanup failed: %v", err)
+	}
+
+	if err := r.DeleteCleanup("i1"); err != nil {
+		t.Fatalf("DeleteCleanup failed: %v", err)
+	}
+	if ids := dueIds(t, r, "2021-05-01T13:00:00.000000000Z", 10); len(ids) != 0 {
+		t.Errorf("the deleted cleanup is still due: %v", ids)
+	}
+
+	if err := r.DeleteCleanup("i1"); err != nil {
+		t.Errorf("DeleteCleanup of a cleanup that is not there returned %v", err)
+	}
+}
+
+func newGroupCleanup(groupId string, retryAt string) models.GroupCleanup {
+	return models.GroupCleanup{
+		GroupId:   groupId,
+		Group:     models.Group{Id: groupId, Name: "Cats", Tags: []string{"cats"}, Timestamp: "2021-05-01T11:00:00.000000000Z", Version: 3},
+		Steps:     models.GroupCleanupSteps,
+		RetryAt:   retryAt,
+		CreatedAt: "2021-05-01T12:00:00.000000000Z",
+	}
+}
+
+func testGroupCleanups(t *testing.T, r cleanupsAccess.Repository) {
+	for _, c := range []models.GroupCleanup{
+		newGroupCleanup("g1", "2021-05-01T12:05:00.000000000Z"),
+		newGroupCleanup("g2", "2021-05-01T14:00:00.000000000Z"),
+	} {
+		if err := r.SaveGroupCleanup(c); err != nil {
+			t.Fatalf("SaveGroupCleanup(%s) failed: %v", c.GroupId, err)
+		}
+	}
+
+	//the group cleanups are apart from the image cleanups
+	if ids := dueIds(t, r, "2021-05-01T15:00:00.000000000Z", 10); len(ids) != 0 {
+		t.Errorf("GetDueCleanups returned the group cleanups %v", ids)
+	}
+
+	c := newGroupCleanup("g1", "2021-05-01T12:05:00.000000000Z")
+	c.Steps = c.Steps[1:]
+	c.Attempts = 1
+	c.LastError = "the tags table is down"
+	if err := r.SaveGroupCleanup(c); err != nil {
+		t.Fatalf("SaveGroupCleanup of the same group failed: %v", err)
+	}
+
+	cleanups, err := r.GetDueGroupCleanups("2021-05-01T13:00:00.000000000Z", 10)
+	if err != nil {
+		t.Fatalf("GetDueGroupCleanups failed: %v", err)
+	}
+	if len(cleanups) != 1 || !reflect.DeepEqual(cleanups[0], c) {
+		t.Errorf("GetDueGroupCleanups returned %+v, want %+v", cleanups, c)
+	}
+
+	if err := r.DeleteGroupCleanup("g1"); err != nil {
+		t.Fatalf("DeleteGroupCleanup failed: %v", err)
+	}
+	if cleanups, _ := r.GetDueGroupCleanups("2021-05-01T15:00:00.000000000Z", 10); len(cleanups) != 1 || cleanups[0].GroupId != "g2" {
+		t.Errorf("GetDueGroupCleanups after DeleteGroupCleanup returned %+v, want g2", cleanups)
+	}
+	if err := r.DeleteGroupCleanup("g1"); err != nil {
+		t.Errorf("DeleteGroupCleanup of a cleanup that is not there returned %v", err)
+	}
+}
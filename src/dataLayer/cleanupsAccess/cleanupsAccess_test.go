@@ -0,0 +1,58 @@
+package cleanupsAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess/cleanupsAccessTest"
+)
+
+// Like the groupsAccess tests, this only runs when DYNAMODB_ENDPOINT points at a DynamoDB, eg DynamoDB Local
+func TestCleanupDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	cleanupsAccessTest.TestRepository(t, func() cleanupsAccess.Repository {
+		n++
+		table := fmt.Sprintf("Cleanups-test-%d-%d", time.Now().UnixNano(), n)
+		createCleanupsTable(t, client, table, "imageId")
+		createCleanupsTable(t, client, "Group"+table, "groupId")
+
+		return cleanupsAccess.NewDynamoDbRepoWithClient(client, table, "Group"+table)
+	})
+}
+
+// createCleanupsTable creates a table with the same key schema as CleanupsDynamoDBTable or GroupCleanupsDynamoDBTable in serverless.yml
+func createCleanupsTable(t *testing.T, client *dynamodb.DynamoDB, table string, key string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String(key), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String(key), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
@@ -0,0 +1,97 @@
+package cleanupsAccess
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// CleanupMemoryRepository is the in-memory Adapter of our Repository Port
+type CleanupMemoryRepository struct {
+	mu            sync.Mutex
+	cleanups      map[string]models.ImageCleanup // by imageId
+	groupCleanups map[string]models.GroupCleanup // by groupId
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &CleanupMemoryRepository{cleanups: make(map[string]models.ImageCleanup), groupCleanups: make(map[string]models.GroupCleanup)}
+}
+
+// SaveCleanup stores a cleanup, or replaces the one of the same image
+func (r *CleanupMemoryRepository) SaveCleanup(c models.ImageCleanup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//like DynamoDB, we keep our own copy of the steps
+	c.Steps = append([]models.CleanupStep{}, c.Steps...)
+	r.cleanups[c.ImageId] = c
+	return nil
+}
+
+// GetDueCleanups returns up to limit cleanups that should be retried at now, the ones that waited the longest first
+func (r *CleanupMemoryRepository) GetDueCleanups(now string, limit int64) ([]models.ImageCleanup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cleanups := []models.ImageCleanup{}
+	for _, c := range r.cleanups {
+		if c.RetryAt <= now {
+			cleanups = append(cleanups, c)
+		}
+	}
+	sort.Slice(cleanups, func(i, j int) bool { return cleanups[i].RetryAt < cleanups[j].RetryAt })
+
+	if int64(len(cleanups)) > limit {
+		cleanups = cleanups[:limit]
+	}
+	return cleanups, nil
+}
+
+// DeleteCleanup forgets the cleanup of an image
+func (r *CleanupMemoryRepository) DeleteCleanup(imageId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.cleanups, imageId)
+	return nil
+}
+
+// SaveGroupCleanup stores a group cleanup, or replaces the one of the same group
+func (r *CleanupMemoryRepository) SaveGroupCleanup(c models.GroupCleanup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.Steps = append([]models.GroupCleanupStep{}, c.Steps...)
+	r.groupCleanups[c.GroupId] = c
+	return nil
+}
+
+// GetDueGroupCleanups returns up to limit group cleanups that should be retried at now, the ones that waited the longest first
+func (r *CleanupMemoryRepository) GetDueGroupCleanups(now string, limit int64) ([]models.GroupCleanup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cleanups := []models.GroupCleanup{}
+	for _, c := range r.groupCleanups {
+		if c.RetryAt <= now {
+			cleanups = append(cleanups, c)
+		}
+	}
+	sort.Slice(cleanups, func(i, j int) bool { return cleanups[i].RetryAt < cleanups[j].RetryAt })
+
+	if int64(len(cleanups)) > limit {
+		cleanups = cleanups[:limit]
+	}
+	return cleanups, nil
+}
+
+// DeleteGroupCleanup forgets the cleanup of a group
+func (r *CleanupMemoryRepository) DeleteGroupCleanup(groupId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.groupCleanups, groupId)
+	return nil
+}
@@ -0,0 +1,12 @@
+package cleanupsAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess/cleanupsAccessTest"
+)
+
+func TestCleanupMemoryRepository(t *testing.T) {
+	cleanupsAccessTest.TestRepository(t, cleanupsAccess.NewMemoryRepo)
+}
@@ -0,0 +1,57 @@
+package cleanupsAccess
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// SaveGroupCleanup stores the cleanup of a group, or replaces the one of the same group with what is left to do
+func (r *CleanupDynamoDbRepository) SaveGroupCleanup(c models.GroupCleanup) error {
+	item, err := dynamodbattribute.MarshalMap(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.client.PutItem(&dynamodb.PutItemInput{
+		Item:      item,
+		TableName: r.groupTable,
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// GetDueGroupCleanups returns up to limit group cleanups that should be retried at now. Like GetDueCleanups, it scans the few that failed
+func (r *CleanupDynamoDbRepository) GetDueGroupCleanups(now string, limit int64) ([]models.GroupCleanup, error) {
+	items, err := r.scanDue(r.groupTable, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanups := []models.GroupCleanup{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &cleanups); err != nil {
+		return nil, err
+	}
+
+	return cleanups, nil
+}
+
+// DeleteGroupCleanup forgets the cleanup of a group once it is done. It is not an error if there is none
+func (r *CleanupDynamoDbRepository) DeleteGroupCleanup(groupId string) error {
+	if _, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(groupId),
+			},
+		},
+		TableName: r.groupTable,
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package groupsAccess
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The scope of the cursors of ScanGroups
+const scanGroupsScope = "groups:scan"
+
+/*
+ScanGroups gets every group, whatever its visibility, in no particular order. It reads the whole
+table so it is only meant for exports, our handlers use GetAllGroups
+*/
+func (r *GroupDynamoDbRepository) ScanGroups(limit int64, nextKey string) ([]models.Group, string, error) {
+	startKey, err := cursors.Decode(scanGroupsScope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := r.client.Scan(&dynamodb.ScanInput{
+		TableName:         r.table,
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
+
+	return r.groupsPage(scanGroupsScope, result.Items, result.LastEvaluatedKey)
+}
+
+/*
+ImportGroup stores a group exactly as it was exported. Unlike CreateGroup it keeps the version of the group.
+It returns a *ConflictError when the group is already there, so an import that is run again skips it
+*/
+func (r *GroupDynamoDbRepository) ImportGroup(group models.Group) error {
+	item, err := dynamodbattribute.MarshalMap(group)
+	if err != nil {
+		return err
+	}
+	if group.Listed() {
+		item[listingAttr] = &dynamodb.AttributeValue{S: aws.String(listingPublic)}
+	}
+
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		Item:                item,
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_not_exists(id)"), //never overwrite a group
+	})
+	if isConditionalCheckFailed(err) {
+		return &ConflictError{group.Id, 0}
+	}
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package groupsAccess
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+/*
+The image statistics of a group(imageCount, lastImageAt and coverImageId) are kept up to date by the
+groupStats Lambda, from the stream of the Images table. None of these writes bump the version of the
+group: they are not changes the members made, and they must not make their If-Match fail.
+
+A stream can deliver the same record more than once, eg when the Lambda fails half way through a batch
+and the whole batch is retried. Setting the latest image is naturally idempotent, but adding to a counter
+is not. So we remember the id of every event we counted in the ProcessedEvents table, in the same
+transaction as the counter. DynamoDB keeps the records of a stream for 24 hours, so we can forget an
+event once it is older than that
+*/
+const processedEventTTL = 48 * time.Hour
+
+/*
+CountImages adds n to the imageCount of a group, only the first time it is called with eventId.
+Nothing happens if the group does not exist anymore, eg the images of a deleted group are removed
+*/
+func (r *GroupDynamoDbRepository) CountImages(groupId string, eventId string, n int64) error {
+	_, err := r.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName: r.eventsTable,
+					Item: map[string]*dynamodb.AttributeValue{
+						"eventId":   {S: aws.String(eventId)},
+						"expiresAt": {N: aws.String(strconv.FormatInt(time.Now().Add(processedEventTTL).Unix(), 10))},
+					},
+					ConditionExpression: aws.String("attribute_not_exists(eventId)"),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName: r.table,
+					Key: map[string]*dynamodb.AttributeValue{
+						"id": {S: aws.String(groupId)},
+					},
+					UpdateExpression:    aws.String("ADD imageCount :n"),
+					ConditionExpression: aws.String("attribute_exists(id)"), //an update must never create a group
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":n": {N: aws.String(strconv.FormatInt(n, 10))},
+					},
+				},
+			},
+		},
+	})
+	if err != nil && !isTransactionConditionFailed(err) {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+/*
+SetLatestImage makes an image the cover of a group if it is newer than the current cover. Stream records
+can arrive more than once, so an image we already set, or an older one, changes nothing
+*/
+func (r *GroupDynamoDbRepository) SetLatestImage(groupId string, imageId string, timestamp string) error {
+	_, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: r.table,
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {S: aws.String(groupId)},
+		},
+		UpdateExpression:    aws.String("SET lastImageAt = :ts, coverImageId = :imageId"),
+		ConditionExpression: aws.String("attribute_exists(id) AND (attribute_not_exists(lastImageAt) OR lastImageAt < :ts)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":ts":      {S: aws.String(timestamp)},
+			":imageId": {S: aws.String(imageId)},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+/*
+ReplaceLatestImage is called when the image with the given timestamp was removed. If it was the cover
+of the group, the image the caller found to be the newest one left becomes the cover. When imageId is
+empty there is none, and the group has no cover and no lastImageAt anymore.
+
+The timestamp is the sort key of the Images table, so it is enough to tell the images of a group apart
+*/
+func (r *GroupDynamoDbRepository) ReplaceLatestImage(groupId string, removedTimestamp string, imageId string, timestamp string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: r.table,
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {S: aws.String(groupId)},
+		},
+		UpdateExpression:    aws.String("REMOVE lastImageAt, coverImageId"),
+		ConditionExpression: aws.String("lastImageAt = :removed"), //only if the removed image is still the cover
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":removed": {S: aws.String(removedTimestamp)},
+		},
+	}
+	if imageId != "" {
+		input.UpdateExpression = aws.String("SET lastImageAt = :ts, coverImageId = :imageId")
+		input.ExpressionAttributeValues[":ts"] = &dynamodb.AttributeValue{S: aws.String(timestamp)}
+		input.ExpressionAttributeValues[":imageId"] = &dynamodb.AttributeValue{S: aws.String(imageId)}
+	}
+
+	if _, err := r.client.UpdateItem(input); err != nil && !isConditionalCheckFailed(err) {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// isTransactionConditionFailed tells if a transaction was cancelled because the condition of one of its items failed
+func isTransactionConditionFailed(err error) bool {
+	tce, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok {
+		return false
+	}
+	for _, reason := range tce.CancellationReasons {
+		if aws.StringValue(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+	return false
+}
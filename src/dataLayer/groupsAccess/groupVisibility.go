@@ -0,0 +1,88 @@
+package groupsAccess
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+Only the groups whose visibility is changing have a pendingVisibility attribute, so they are the only
+ones in the PendingVisibilityIndex and GetPendingVisibility can read it whole
+*/
+const pendingVisibilityAttr = "pendingVisibility"
+
+// ErrVisibilityChanged is returned by FinishVisibility when the group is not waiting for that visibility anymore
+var ErrVisibilityChanged = apperrors.Conflict("the visibility of the group changed")
+
+/*
+FinishVisibility gives a group the visibility it was waiting for, once the files of its images follow
+it. It is a change of the group like any other, so its version goes up
+*/
+func (r *GroupDynamoDbRepository) FinishVisibility(id string, v models.Visibility) (models.Group, error) {
+	g := models.Group{Visibility: v}
+
+	update := "SET visibility = :visibility, version = if_not_exists(version, :zero) + :one"
+	values := map[string]*dynamodb.AttributeValue{
+		":visibility": {S: aws.String(string(v))},
+		":zero":       {N: aws.String("0")},
+		":one":        {N: aws.String("1")},
+	}
+	remove := " REMOVE " + pendingVisibilityAttr
+	if g.Listed() {
+		update += ", " + listingAttr + " = :listing"
+		values[":listing"] = &dynamodb.AttributeValue{S: aws.String(listingPublic)}
+	} else {
+		remove += ", " + listingAttr
+	}
+
+	result, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		TableName:                 r.table,
+		UpdateExpression:          aws.String(update + remove),
+		ConditionExpression:       aws.String(pendingVisibilityAttr + " = :visibility"), //the owner may have asked for another one since
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if isConditionalCheckFailed(err) {
+		if _, err := r.GetGroup(id); err != nil {
+			return models.Group{}, err
+		}
+		return models.Group{}, ErrVisibilityChanged
+	}
+	if err != nil {
+		return models.Group{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Group{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Group{}, err
+	}
+
+	return updated, nil
+}
+
+// GetPendingVisibility gets up to limit groups that are waiting for their visibility, in no particular order
+func (r *GroupDynamoDbRepository) GetPendingVisibility(limit int64) ([]models.Group, error) {
+	result, err := r.client.Scan(&dynamodb.ScanInput{
+		TableName: r.table,
+		IndexName: r.pendingIndex,
+		Limit:     aws.Int64(limit),
+	})
+	if err != nil {
+		return nil, apperrors.FromAWS(err)
+	}
+
+	groups := []models.Group{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
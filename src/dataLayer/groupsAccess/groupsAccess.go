@@ -1,16 +1,20 @@
 package groupsAccess
 
 import (
-	"encoding/json"
-	"log"
-	"net/url"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/cursor"
 	"github.com/udacity/serverless-golang/src/models"
 )
 
@@ -18,27 +22,134 @@ import (
 This interface is a Port. It provides an interface that is independent from specific technologies
 
 A Port can have multiple Adapters. This means any adapter that belongs to this Port, will implement
-these two methods. In our app, we just have one Adapter(GroupDynamoDbRepository) that belongs to this Port(Repository)
+these methods. In our app, we have two Adapters that belongs to this Port(Repository): GroupDynamoDbRepository
+for AWS and GroupMemoryRepository for unit tests and running our handlers locally
 
 Alternative to arrange your code; but i think mine is fine
 https://github.com/yuraxdrumz/ports-and-adapters-golang/tree/master/internal/pkg/adapters/out/cartRepository
 */
 type Repository interface {
-	GetAllGroups(l int64, n string) ([]models.Group, string)
+	GetAllGroups(l int64, n string, order SortOrder) ([]models.Group, string, error)
+	GetGroupsByUser(userId string, l int64, n string) ([]models.Group, string, error)
 	CreateGroup(group models.Group) (models.Group, error)
+	GetGroup(id string) (models.Group, error)
+	GetGroups(ids []string) ([]models.Group, error)
+	UpdateGroup(group models.Group) (models.Group, error)
+	DeleteGroup(id string, version int64) error
+
+	// Giving a group the visibility its owner asked for once its images follow it, see models.Group.PendingVisibility
+	FinishVisibility(id string, v models.Visibility) (models.Group, error)
+	GetPendingVisibility(l int64) ([]models.Group, error)
+
+	// The image statistics of a group, kept up to date from the Images stream. See groupStats.go
+	CountImages(groupId string, eventId string, n int64) error
+	SetLatestImage(groupId string, imageId string, timestamp string) error
+	ReplaceLatestImage(groupId string, removedTimestamp string, imageId string, timestamp string) error
+
+	// Reading and writing whole stages, for cmd/exportImport. See groupExport.go
+	ScanGroups(l int64, n string) ([]models.Group, string, error)
+	ImportGroup(group models.Group) error
 }
 
-//We can call this an Adapter! It connets to external service
+// We can call this an Adapter! It connets to external service
 type GroupDynamoDbRepository struct {
-	client *dynamodb.DynamoDB
+	client         *dynamodb.DynamoDB
+	table          *string
+	userIdIndex    *string
+	createdAtIndex *string
+	eventsTable    *string // the stream events we already counted, see CountImages
+	pendingIndex   *string // the groups that have a pendingVisibility, see GetPendingVisibility
+}
+
+// ErrGroupNotFound is returned when the group we want to read or write does not exist
+var ErrGroupNotFound = apperrors.NotFound("group not found")
+
+/*
+ConflictError is returned when a write lost a race: the group is not at the version the caller
+read anymore because somebody else changed it in between, or a group with the same id already exists.
+
+Every write bumps the Version of a group, and only succeeds if the group is still at the version
+it had when we read it. This is called optimistic concurrency
+*/
+type ConflictError struct {
+	Id      string
+	Version int64 // the version the caller expected the group to be at
 }
 
-type NextKey struct {
-	Id string `json:"id"`
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("group %s is not at version %d anymore", e.Id, e.Version)
+}
+
+// Is makes a ConflictError an apperrors.ErrConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == apperrors.ErrConflict
+}
+
+// IsConflict tells if err is a *ConflictError
+func IsConflict(err error) bool {
+	var cerr *ConflictError
+	return errors.As(err, &cerr)
+}
+
+// SortOrder is the order GetAllGroups returns the groups in, by the time they were created
+type SortOrder string
+
+const (
+	NewestFirst SortOrder = "newest"
+	OldestFirst SortOrder = "oldest"
+)
+
+// ParseSortOrder reads the sort query parameter of a listing. Without one we list the newest groups first
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch SortOrder(s) {
+	case "", NewestFirst:
+		return NewestFirst, nil
+	case OldestFirst:
+		return OldestFirst, nil
+	}
+	return "", apperrors.Invalid("sort must be newest or oldest")
+}
+
+/*
+Only the public groups have a listing attribute, so they are the only ones in the CreatedAtIndex.
+The index has the same listing value as partition key for all of them and the timestamp as sort key,
+so GetAllGroups is a Query that reads the groups in the order they were created, and never reads an
+unlisted or private group.
+
+A group gets its listing when it is created or updated, see CreateGroup and UpdateGroup. The groups
+created before we had the index get it from cmd/migrateTimestamps
+*/
+const (
+	listingAttr   = "listing"
+	listingPublic = "public"
+)
+
+/*
+The nextKey of our listings is an opaque cursor made from the LastEvaluatedKey. Every listing signs
+its cursors with its own scope. An empty nextKey means the first page when we get it, and no more pages
+when we return it
+*/
+func allGroupsScope(order SortOrder) string {
+	return "groups:" + string(order) //a cursor of the newest groups can't be used to continue the oldest ones
+}
+
+func userGroupsScope(userId string) string {
+	return "groups:user:" + userId
 }
 
 var (
-	tableName = aws.String(os.Getenv("GROUPS_TABLE"))
+	tableName          = aws.String(os.Getenv("GROUPS_TABLE"))
+	userIdIndexName    = aws.String(os.Getenv("USER_ID_INDEX"))
+	createdAtIndexName = aws.String(os.Getenv("CREATED_AT_INDEX"))
+	eventsTableName    = aws.String(os.Getenv("PROCESSED_EVENTS_TABLE"))
+	pendingIndexName   = aws.String(os.Getenv("PENDING_VISIBILITY_INDEX"))
+	/*
+		Set GROUPS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("GROUPS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+	cursors    = cursor.FromEnv()
 )
 
 // Creates a DynamoDb client
@@ -49,90 +160,369 @@ func createDynamoDBClient() *dynamodb.DynamoDB {
 	return svc
 }
 
+// NewRepo creates the Repository our handlers should use based on the GROUPS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
 // NewDynamoDbRepo creates a new DynamoDb Repository
 func NewDynamoDbRepo() Repository {
 	dbc := createDynamoDBClient()
 
-	return &GroupDynamoDbRepository{dbc}
+	return &GroupDynamoDbRepository{
+		client:         dbc,
+		table:          tableName,
+		userIdIndex:    userIdIndexName,
+		createdAtIndex: createdAtIndexName,
+		eventsTable:    eventsTableName,
+		pendingIndex:   pendingIndexName,
+	}
 }
 
-// Get all the groups users created
-func (r *GroupDynamoDbRepository) GetAllGroups(limit int64, nextKey string) ([]models.Group, string) {
-	// Read from DynamoDB
-	var input *dynamodb.ScanInput
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client, tables and indexes.
+// This is handy when we want to point the adapter at DynamoDB Local or at a throwaway test table
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string, userIdIndex string, createdAtIndex string, pendingIndex string, eventsTable string) Repository {
+	return &GroupDynamoDbRepository{
+		client:         c,
+		table:          aws.String(table),
+		userIdIndex:    aws.String(userIdIndex),
+		createdAtIndex: aws.String(createdAtIndex),
+		pendingIndex:   aws.String(pendingIndex),
+		eventsTable:    aws.String(eventsTable),
+	}
+}
 
-	if nextKey == "" {
-		input = &dynamodb.ScanInput{
-			TableName: tableName,
-			Limit:     aws.Int64(limit),
-		}
-	} else {
-		nk := &NextKey{}
-
-		//We decode the key
-		k, _ := url.QueryUnescape(nextKey)
-
-		//parse the key
-		json.Unmarshal([]byte(k), nk)
-
-		input = &dynamodb.ScanInput{
-			TableName: tableName,
-			Limit:     aws.Int64(limit),
-			//ExclusiveStartKey: nk,
-			ExclusiveStartKey: map[string]*dynamodb.AttributeValue{
-				"id": {
-					S: aws.String(nk.Id),
-				},
+/*
+GetAllGroups gets the groups anybody can find, ie the public ones, in the order they were created.
+Unlisted and private groups are not in the CreatedAtIndex, so every page is full until the last one
+*/
+func (r *GroupDynamoDbRepository) GetAllGroups(limit int64, nextKey string, order SortOrder) ([]models.Group, string, error) {
+	scope := allGroupsScope(order)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Read from DynamoDB
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		IndexName:              r.createdAtIndex,
+		KeyConditionExpression: aws.String(listingAttr + " = :listing"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":listing": {
+				S: aws.String(listingPublic),
 			},
-		}
+		},
+		ScanIndexForward:  aws.Bool(order == OldestFirst), //false reads the newest timestamps first
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
 	}
 
-	result, _ := r.client.Scan(input)
+	result, err := r.client.Query(input)
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
 
-	// Construct todos from response
-	var groups []models.Group
-	for _, i := range result.Items {
-		group := models.Group{}
-		if err := dynamodbattribute.UnmarshalMap(i, &group); err != nil {
-			log.Println("Failed to unmarshal")
-			log.Println(err)
-		}
-		groups = append(groups, group)
+	return r.groupsPage(scope, result.Items, result.LastEvaluatedKey)
+}
+
+/*
+GetGroupsByUser gets the groups a user created. We Query the UserIdIndex instead of scanning
+the whole table, so we only read the groups of this user.
+
+The cursor is signed for this user only, so a user cannot continue from a key of another user's groups
+*/
+func (r *GroupDynamoDbRepository) GetGroupsByUser(userId string, limit int64, nextKey string) ([]models.Group, string, error) {
+	scope := userGroupsScope(userId)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
 	}
 
-	var nxt NextKey
-	if err := dynamodbattribute.UnmarshalMap(result.LastEvaluatedKey, &nxt); err != nil {
-		log.Println("Failed to unmarshal")
-		log.Println(err)
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		IndexName:              r.userIdIndex,
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":userId": {
+				S: aws.String(userId),
+			},
+		},
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
 	}
-	//log.Printf("LastEvaluatedKey: %s", nxt.Id)
 
-	var finalKeyValue string
-	if nxt.Id == "" {
+	result, err := r.client.Query(input)
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
 
-		//when the next key is null it means there is no more items ot return
-		finalKeyValue = string("null")
-	} else {
-		//fmt.Sprintf("%v", nxt.Id)
-		out, _ := json.Marshal(nxt)
-		finalKeyValue = string(out)
+	return r.groupsPage(scope, result.Items, result.LastEvaluatedKey)
+}
+
+// groupsPage builds the groups from the items DynamoDB returned and the cursor for the next page
+func (r *GroupDynamoDbRepository) groupsPage(scope string, items []map[string]*dynamodb.AttributeValue, lastKey map[string]*dynamodb.AttributeValue) ([]models.Group, string, error) {
+	var groups []models.Group
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &groups); err != nil {
+		return nil, "", err
+	}
+
+	//when there is no LastEvaluatedKey, there is no more items to return and the cursor is empty
+	nk, err := cursors.Encode(scope, lastKey)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return groups, finalKeyValue
+	return groups, nk, nil
 }
 
-// Store creates a new group team in the in images table.
+// Store creates a new group team in the in images table. A new group is at version 1
 func (r *GroupDynamoDbRepository) CreateGroup(group models.Group) (models.Group, error) {
+	group.Version = 1
+
 	// Write the new item to DynamoDB database
 	item, _ := dynamodbattribute.MarshalMap(group)
+	if group.Listed() {
+		item[listingAttr] = &dynamodb.AttributeValue{S: aws.String(listingPublic)}
+	}
 	input := &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: tableName,
+		Item:                item,
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_not_exists(id)"), //never overwrite another group
 	}
 
 	if _, err := r.client.PutItem(input); err != nil {
+		if isConditionalCheckFailed(err) {
+			return models.Group{}, &ConflictError{group.Id, 0}
+		}
+		return models.Group{}, apperrors.FromAWS(err)
+	}
+
+	return group, nil
+}
+
+// GetGroup returns the group with the given id
+func (r *GroupDynamoDbRepository) GetGroup(id string) (models.Group, error) {
+	result, err := r.client.GetItem(&dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		TableName: r.table,
+	})
+	if err != nil {
+		return models.Group{}, apperrors.FromAWS(err)
+	}
+
+	if result.Item == nil {
+		return models.Group{}, ErrGroupNotFound
+	}
+
+	group := models.Group{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &group); err != nil {
 		return models.Group{}, err
 	}
 
 	return group, nil
 }
+
+/*
+GetGroups returns the groups with the given ids, in the same order. The ids of groups that don't exist
+anymore are left out.
+
+BatchGetItem reads up to 100 keys at a time, and may return some keys as unprocessed when it is throttled.
+We ask again for those until we have them all
+*/
+func (r *GroupDynamoDbRepository) GetGroups(ids []string) ([]models.Group, error) {
+	found := make(map[string]models.Group, len(ids))
+
+	for start := 0; start < len(ids); start += maxBatchGet {
+		end := start + maxBatchGet
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var keys []map[string]*dynamodb.AttributeValue
+		seen := make(map[string]bool)
+		for _, id := range ids[start:end] {
+			if !seen[id] { //BatchGetItem fails on duplicate keys
+				seen[id] = true
+				keys = append(keys, map[string]*dynamodb.AttributeValue{"id": {S: aws.String(id)}})
+			}
+		}
+
+		request := map[string]*dynamodb.KeysAndAttributes{*r.table: {Keys: keys}}
+		for len(request) > 0 {
+			result, err := r.client.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, apperrors.FromAWS(err)
+			}
+
+			var groups []models.Group
+			if err := dynamodbattribute.UnmarshalListOfMaps(result.Responses[*r.table], &groups); err != nil {
+				return nil, err
+			}
+			for _, g := range groups {
+				found[g.Id] = g
+			}
+
+			request = result.UnprocessedKeys
+		}
+	}
+
+	return inOrder(ids, found), nil
+}
+
+// inOrder returns the groups in the order of ids, leaving out the ids we did not find
+func inOrder(ids []string, found map[string]models.Group) []models.Group {
+	var groups []models.Group
+	for _, id := range ids {
+		if g, ok := found[id]; ok {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+/*
+UpdateGroup saves the name, description, visibility, tags and originals policy of an existing group. group.Version must be
+the version the group was at when we read it, otherwise we return a *ConflictError. The group we
+return is at the next version.
+
+It will not create the group if it does not exist. The other attributes, like the image statistics, are
+never changed by an update, except for the listing that follows the visibility
+*/
+func (r *GroupDynamoDbRepository) UpdateGroup(group models.Group) (models.Group, error) {
+	condition, values := versionCondition(group.Version)
+	values[":name"] = &dynamodb.AttributeValue{S: aws.String(group.Name)}
+	values[":description"] = &dynamodb.AttributeValue{S: aws.String(group.Description)}
+	values[":visibility"] = &dynamodb.AttributeValue{S: aws.String(string(group.GetVisibility()))} //a group created before we had visibilities is public, and now it says so
+	values[":next"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(group.Version+1, 10))}
+
+	update := "SET #n = :name, description = :description, visibility = :visibility, version = :next"
+	var remove []string
+	if group.Listed() {
+		update += ", " + listingAttr + " = :listing"
+		values[":listing"] = &dynamodb.AttributeValue{S: aws.String(listingPublic)}
+	} else {
+		remove = append(remove, listingAttr) //takes the group out of the CreatedAtIndex
+	}
+	if len(group.Tags) > 0 {
+		tags, _ := dynamodbattribute.Marshal(group.Tags)
+		update += ", tags = :tags"
+		values[":tags"] = tags
+	} else {
+		remove = append(remove, "tags") //DynamoDB does not store empty lists, and Tags is omitempty
+	}
+	if group.OriginalsPolicy != "" {
+		update += ", originalsPolicy = :originalsPolicy"
+		values[":originalsPolicy"] = &dynamodb.AttributeValue{S: aws.String(string(group.OriginalsPolicy))}
+	} else {
+		remove = append(remove, "originalsPolicy") //the group follows the policy of the stage again
+	}
+	if group.PendingVisibility != "" {
+		update += ", " + pendingVisibilityAttr + " = :pendingVisibility" //puts the group in the PendingVisibilityIndex
+		values[":pendingVisibility"] = &dynamodb.AttributeValue{S: aws.String(string(group.PendingVisibility))}
+	} else {
+		remove = append(remove, pendingVisibilityAttr)
+	}
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(group.Id),
+			},
+		},
+		TableName:                 r.table,
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  map[string]*string{"#n": aws.String("name")}, //name is a reserved word in DynamoDB
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	}
+
+	result, err := r.client.UpdateItem(input)
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return models.Group{}, r.conflictOrNotFound(group.Id, group.Version)
+		}
+		return models.Group{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Group{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Group{}, err
+	}
+
+	return updated, nil
+}
+
+/*
+DeleteGroup deletes a group, if it is still at the given version. Its members, invitations, tags and
+images are deleted by groups.GroupDeleter, which keeps track of them with a models.GroupCleanup
+*/
+func (r *GroupDynamoDbRepository) DeleteGroup(id string, version int64) error {
+	condition, values := versionCondition(version)
+
+	input := &dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		TableName:                 r.table,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	}
+
+	if _, err := r.client.DeleteItem(input); err != nil {
+		if isConditionalCheckFailed(err) {
+			return r.conflictOrNotFound(id, version)
+		}
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+/*
+versionCondition is the condition of a write that expects the group to exist at the given version.
+The groups created before we had versions don't have the attribute, and we read them as version 0
+*/
+func versionCondition(version int64) (string, map[string]*dynamodb.AttributeValue) {
+	if version == 0 {
+		return "attribute_exists(id) AND attribute_not_exists(version)", map[string]*dynamodb.AttributeValue{}
+	}
+
+	return "attribute_exists(id) AND version = :expected", map[string]*dynamodb.AttributeValue{
+		":expected": {
+			N: aws.String(strconv.FormatInt(version, 10)),
+		},
+	}
+}
+
+// conflictOrNotFound finds out which part of versionCondition failed
+func (r *GroupDynamoDbRepository) conflictOrNotFound(id string, version int64) error {
+	if _, err := r.GetGroup(id); err != nil {
+		return err
+	}
+	return &ConflictError{id, version}
+}
+
+// maxBatchGet is the most keys BatchGetItem accepts in one call
+const maxBatchGet = 100
+
+func isConditionalCheckFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
@@ -0,0 +1,727 @@
+/*
+Package groupsAccessTest is the contract every Adapter of the groupsAccess.Repository Port must pass.
+
+Each Adapter calls TestRepository from its own _test.go file, so the DynamoDB and in-memory
+Adapters are held to exactly the same behaviour
+*/
+package groupsAccessTest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() groupsAccess.Repository) {
+	t.Run("CreateGroupReturnsGroup", func(t *testing.T) {
+		testCreateGroupReturnsGroup(t, newRepo())
+	})
+	t.Run("EmptyRepository", func(t *testing.T) {
+		testEmptyRepository(t, newRepo())
+	})
+	t.Run("LimitLargerThanTable", func(t *testing.T) {
+		testLimitLargerThanTable(t, newRepo())
+	})
+	t.Run("Pagination", func(t *testing.T) {
+		testPagination(t, newRepo())
+	})
+	t.Run("SortOrder", func(t *testing.T) {
+		testSortOrder(t, newRepo())
+	})
+	t.Run("InvalidCursor", func(t *testing.T) {
+		testInvalidCursor(t, newRepo())
+	})
+	t.Run("ListsOnlyPublicGroups", func(t *testing.T) {
+		testListsOnlyPublicGroups(t, newRepo())
+	})
+	t.Run("GetGroupsByUser", func(t *testing.T) {
+		testGetGroupsByUser(t, newRepo())
+	})
+	t.Run("GetGroup", func(t *testing.T) {
+		testGetGroup(t, newRepo())
+	})
+	t.Run("GetGroups", func(t *testing.T) {
+		testGetGroups(t, newRepo())
+	})
+	t.Run("UpdateGroup", func(t *testing.T) {
+		testUpdateGroup(t, newRepo())
+	})
+	t.Run("UpdateConflict", func(t *testing.T) {
+		testUpdateConflict(t, newRepo())
+	})
+	t.Run("DeleteGroup", func(t *testing.T) {
+		testDeleteGroup(t, newRepo())
+	})
+	t.Run("PendingVisibility", func(t *testing.T) {
+		testPendingVisibility(t, newRepo())
+	})
+	t.Run("CountImages", func(t *testing.T) {
+		testCountImages(t, newRepo())
+	})
+	t.Run("LatestImage", func(t *testing.T) {
+		testLatestImage(t, newRepo())
+	})
+	t.Run("ScanGroups", func(t *testing.T) {
+		testScanGroups(t, newRepo())
+	})
+	t.Run("ImportGroup", func(t *testing.T) {
+		testImportGroup(t, newRepo())
+	})
+}
+
+func newGroup(i int) models.Group {
+	g := models.Group{
+		Id:          fmt.Sprintf("group-%02d", i),
+		UserId:      fmt.Sprintf("user-%d", i%2), //the even groups belong to user-0 and the odd ones to user-1
+		Name:        fmt.Sprintf("Group %d", i),
+		Description: fmt.Sprintf("Description %d", i),
+		Timestamp:   fmt.Sprintf("2021-05-01T12:00:%02dZ", i), //the groups are created one second apart
+	}
+	if i%3 == 0 {
+		g.Tags = []string{"cats", fmt.Sprintf("tag-%d", i)} //some groups have tags, the others don't
+	}
+	return g
+}
+
+func seed(t *testing.T, r groupsAccess.Repository, n int) map[string]models.Group {
+	t.Helper()
+
+	want := make(map[string]models.Group)
+	for i := 0; i < n; i++ {
+		g, err := r.CreateGroup(newGroup(i))
+		if err != nil {
+			t.Fatalf("CreateGroup(%q) failed: %v", newGroup(i).Id, err)
+		}
+		want[g.Id] = g
+	}
+
+	return want
+}
+
+func testCreateGroupReturnsGroup(t *testing.T, r groupsAccess.Repository) {
+	g := newGroup(1)
+
+	got, err := r.CreateGroup(g)
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	g.Version = 1 //every new group starts at version 1
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("CreateGroup returned %+v, want %+v", got, g)
+	}
+
+	//creating a group never overwrites another one
+	if _, err := r.CreateGroup(newGroup(1)); !groupsAccess.IsConflict(err) {
+		t.Errorf("CreateGroup of an existing id returned %v, want a *ConflictError", err)
+	}
+}
+
+func testEmptyRepository(t *testing.T, r groupsAccess.Repository) {
+	groups, nk, err := r.GetAllGroups(20, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetAllGroups failed: %v", err)
+	}
+
+	if len(groups) != 0 {
+		t.Errorf("GetAllGroups returned %d groups, want 0", len(groups))
+	}
+	if nk != "" {
+		t.Errorf("GetAllGroups nextKey = %q, want none", nk)
+	}
+}
+
+func testLimitLargerThanTable(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 3)
+
+	groups, nk, err := r.GetAllGroups(20, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetAllGroups failed: %v", err)
+	}
+
+	if len(groups) != len(want) {
+		t.Errorf("GetAllGroups returned %d groups, want %d", len(groups), len(want))
+	}
+	if nk != "" {
+		t.Errorf("GetAllGroups nextKey = %q, want none", nk)
+	}
+	for _, g := range groups {
+		if !reflect.DeepEqual(want[g.Id], g) {
+			t.Errorf("GetAllGroups returned %+v, want %+v", g, want[g.Id])
+		}
+	}
+}
+
+func testPagination(t *testing.T, r groupsAccess.Repository) {
+	const limit = 2
+	want := seed(t, r, 5)
+
+	seen := make(map[string]bool)
+	nextKey := ""
+	for page := 0; ; page++ {
+		//5 items with a limit of 2 can never take more than 4 pages(the last one may be empty)
+		if page > 4 {
+			t.Fatalf("GetAllGroups did not stop after %d pages", page)
+		}
+
+		groups, nk, err := r.GetAllGroups(limit, nextKey, groupsAccess.NewestFirst)
+		if err != nil {
+			t.Fatalf("GetAllGroups failed: %v", err)
+		}
+		if len(groups) > limit {
+			t.Fatalf("GetAllGroups returned %d groups, want at most %d", len(groups), limit)
+		}
+
+		for _, g := range groups {
+			if seen[g.Id] {
+				t.Errorf("GetAllGroups returned %q more than once", g.Id)
+			}
+			seen[g.Id] = true
+
+			if !reflect.DeepEqual(want[g.Id], g) {
+				t.Errorf("GetAllGroups returned %+v, want %+v", g, want[g.Id])
+			}
+		}
+
+		if nk == "" {
+			break
+		}
+		if len(groups) < limit {
+			t.Errorf("GetAllGroups returned a nextKey on a short page of %d groups", len(groups))
+		}
+
+		nextKey = nk
+	}
+
+	if len(seen) != len(want) {
+		t.Errorf("GetAllGroups returned %d groups over all pages, want %d", len(seen), len(want))
+	}
+}
+
+func testListsOnlyPublicGroups(t *testing.T, r groupsAccess.Repository) {
+	visibilities := []models.Visibility{"", models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate, models.VisibilityPublic}
+	for i, v := range visibilities {
+		g := newGroup(i)
+		g.UserId = "user-0"
+		g.Visibility = v
+		if _, err := r.CreateGroup(g); err != nil {
+			t.Fatalf("CreateGroup(%q) failed: %v", g.Id, err)
+		}
+	}
+
+	//A page can be short when some groups are filtered out, so we read every page
+	got := make(map[string]bool)
+	nk := ""
+	for pages := 0; pages == 0 || nk != ""; pages++ {
+		if pages > len(visibilities) {
+			t.Fatalf("GetAllGroups never stopped returning a nextKey")
+		}
+
+		groups, next, err := r.GetAllGroups(2, nk, groupsAccess.NewestFirst)
+		if err != nil {
+			t.Fatalf("GetAllGroups failed: %v", err)
+		}
+		for _, g := range groups {
+			got[g.Id] = true
+		}
+		nk = next
+	}
+
+	for i, v := range visibilities {
+		id := newGroup(i).Id
+		if listed := v == "" || v == models.VisibilityPublic; got[id] != listed {
+			t.Errorf("GetAllGroups returned group with visibility %q: %v, want %v", v, got[id], listed)
+		}
+	}
+
+	//users still see all the groups they created
+	groups, _, err := r.GetGroupsByUser("user-0", 20, "")
+	if err != nil {
+		t.Fatalf("GetGroupsByUser failed: %v", err)
+	}
+	if len(groups) != len(visibilities) {
+		t.Errorf("GetGroupsByUser returned %d groups, want %d", len(groups), len(visibilities))
+	}
+}
+
+func testGetGroupsByUser(t *testing.T, r groupsAccess.Repository) {
+	const limit = 2
+	want := seed(t, r, 7)
+
+	seen := make(map[string]bool)
+	nextKey := ""
+	for page := 0; ; page++ {
+		//user-0 has 4 groups so with a limit of 2 we can never need more than 3 pages
+		if page > 3 {
+			t.Fatalf("GetGroupsByUser did not stop after %d pages", page)
+		}
+
+		groups, nk, err := r.GetGroupsByUser("user-0", limit, nextKey)
+		if err != nil {
+			t.Fatalf("GetGroupsByUser failed: %v", err)
+		}
+
+		for _, g := range groups {
+			if g.UserId != "user-0" {
+				t.Errorf("GetGroupsByUser returned %q of %q", g.Id, g.UserId)
+			}
+			if seen[g.Id] {
+				t.Errorf("GetGroupsByUser returned %q more than once", g.Id)
+			}
+			seen[g.Id] = true
+
+			if !reflect.DeepEqual(want[g.Id], g) {
+				t.Errorf("GetGroupsByUser returned %+v, want %+v", g, want[g.Id])
+			}
+		}
+
+		if nk == "" {
+			break
+		}
+		nextKey = nk
+	}
+
+	if len(seen) != 4 {
+		t.Errorf("GetGroupsByUser returned %d groups over all pages, want 4", len(seen))
+	}
+
+	groups, nk, err := r.GetGroupsByUser("nobody", limit, "")
+	if err != nil {
+		t.Fatalf("GetGroupsByUser failed: %v", err)
+	}
+	if len(groups) != 0 || nk != "" {
+		t.Errorf("GetGroupsByUser of a user with no groups returned %d groups and nextKey %q", len(groups), nk)
+	}
+}
+
+func testInvalidCursor(t *testing.T, r groupsAccess.Repository) {
+	seed(t, r, 3)
+
+	_, nk, err := r.GetAllGroups(1, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetAllGroups failed: %v", err)
+	}
+	if nk == "" {
+		t.Fatal("GetAllGroups returned no nextKey")
+	}
+
+	//change one character of the cursor so that it does not match its signature anymore
+	tampered := "x" + nk[1:]
+	if nk[0] == 'x' {
+		tampered = "y" + nk[1:]
+	}
+
+	for name, c := range map[string]string{
+		"garbage":  "not-a-cursor",
+		"raw key":  `{"id":"group-00"}`,
+		"tampered": tampered,
+	} {
+		if _, _, err := r.GetAllGroups(1, c, groupsAccess.NewestFirst); !cursor.IsInvalid(err) {
+			t.Errorf("GetAllGroups with a %s cursor returned %v, want an invalid cursor error", name, err)
+		}
+	}
+
+	//a cursor of one listing cannot be used for another one
+	if _, _, err := r.GetGroupsByUser("user-0", 1, nk); !cursor.IsInvalid(err) {
+		t.Errorf("GetGroupsByUser with a GetAllGroups cursor returned %v, want an invalid cursor error", err)
+	}
+	if _, _, err := r.GetAllGroups(1, nk, groupsAccess.OldestFirst); !cursor.IsInvalid(err) {
+		t.Errorf("GetAllGroups(oldest) with a cursor of the newest groups returned %v, want an invalid cursor error", err)
+	}
+}
+
+func testSortOrder(t *testing.T, r groupsAccess.Repository) {
+	seed(t, r, 5)
+
+	tests := []struct {
+		order groupsAccess.SortOrder
+		want  []string
+	}{
+		{groupsAccess.NewestFirst, []string{"group-04", "group-03", "group-02", "group-01", "group-00"}},
+		{groupsAccess.OldestFirst, []string{"group-00", "group-01", "group-02", "group-03", "group-04"}},
+	}
+
+	for _, tt := range tests {
+		//the order must hold across pages, not just inside one
+		var got []string
+		nk := ""
+		for pages := 0; pages == 0 || nk != ""; pages++ {
+			if pages > len(tt.want) {
+				t.Fatalf("GetAllGroups(%s) never stopped returning a nextKey", tt.order)
+			}
+
+			groups, next, err := r.GetAllGroups(2, nk, tt.order)
+			if err != nil {
+				t.Fatalf("GetAllGroups(%s) failed: %v", tt.order, err)
+			}
+			for _, g := range groups {
+				got = append(got, g.Id)
+			}
+			nk = next
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetAllGroups(%s) returned %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func testGetGroup(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 2)
+
+	got, err := r.GetGroup("group-01")
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want["group-01"]) {
+		t.Errorf("GetGroup returned %+v, want %+v", got, want["group-01"])
+	}
+
+	if _, err := r.GetGroup("missing"); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("GetGroup of a missing group returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
+
+func testGetGroups(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 4)
+
+	//the groups come back in the order of the ids, and the missing ones are left out
+	groups, err := r.GetGroups([]string{"group-03", "missing", "group-00", "group-01"})
+	if err != nil {
+		t.Fatalf("GetGroups failed: %v", err)
+	}
+
+	wantGroups := []models.Group{want["group-03"], want["group-00"], want["group-01"]}
+	if !reflect.DeepEqual(groups, wantGroups) {
+		t.Errorf("GetGroups returned %+v, want %+v", groups, wantGroups)
+	}
+
+	if groups, err := r.GetGroups(nil); err != nil || len(groups) != 0 {
+		t.Errorf("GetGroups(nil) returned %v, %v, want no groups", groups, err)
+	}
+}
+
+func testUpdateGroup(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 1)
+
+	g := want["group-00"]
+	g.Name = "Renamed"
+	g.Visibility = models.VisibilityPrivate
+	g.Tags = []string{"renamed"}
+	g.OriginalsPolicy = models.OriginalsKeepPrivateCopy
+	updated, err := r.UpdateGroup(g)
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+
+	g.Version++ //every write bumps the version
+	if !reflect.DeepEqual(updated, g) {
+		t.Errorf("UpdateGroup returned %+v, want %+v", updated, g)
+	}
+
+	got, err := r.GetGroup(g.Id)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("GetGroup after UpdateGroup returned %+v, want %+v", got, g)
+	}
+
+	//an update without tags removes them, and without a policy the group goes back to the one of the stage
+	g.Tags = nil
+	g.OriginalsPolicy = ""
+	updated, err = r.UpdateGroup(g)
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if updated.Tags != nil {
+		t.Errorf("UpdateGroup without tags kept %v", updated.Tags)
+	}
+	if updated.OriginalsPolicy != "" {
+		t.Errorf("UpdateGroup without an originals policy kept %q", updated.OriginalsPolicy)
+	}
+	g.Version++
+
+	//an update must never create a group
+	if _, err := r.UpdateGroup(newGroup(7)); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("UpdateGroup of a missing group returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+	if _, err := r.GetGroup(newGroup(7).Id); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("UpdateGroup of a missing group created it")
+	}
+}
+
+func testUpdateConflict(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 1)
+
+	//two users read the group at the same version, and both try to rename it
+	first, second := want["group-00"], want["group-00"]
+	first.Name = "First"
+	second.Name = "Second"
+
+	if _, err := r.UpdateGroup(first); err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if _, err := r.UpdateGroup(second); !groupsAccess.IsConflict(err) {
+		t.Errorf("UpdateGroup of a stale version returned %v, want a *ConflictError", err)
+	}
+
+	if got, _ := r.GetGroup("group-00"); got.Name != "First" {
+		t.Errorf("the stale UpdateGroup overwrote the group: %+v", got)
+	}
+}
+
+func testDeleteGroup(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 2)
+
+	if err := r.DeleteGroup("group-00", want["group-00"].Version+1); !groupsAccess.IsConflict(err) {
+		t.Errorf("DeleteGroup of a stale version returned %v, want a *ConflictError", err)
+	}
+	if _, err := r.GetGroup("group-00"); err != nil {
+		t.Fatalf("DeleteGroup of a stale version deleted the group: %v", err)
+	}
+
+	if err := r.DeleteGroup("group-00", want["group-00"].Version); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	if _, err := r.GetGroup("group-00"); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("GetGroup after DeleteGroup returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+	if _, err := r.GetGroup("group-01"); err != nil {
+		t.Errorf("DeleteGroup removed another group: %v", err)
+	}
+
+	if err := r.DeleteGroup("group-00", want["group-00"].Version); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("DeleteGroup of a missing group returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
+
+func testPendingVisibility(t *testing.T, r groupsAccess.Repository) {
+	seed(t, r, 2)
+	g, err := r.GetGroup(newGroup(0).Id)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+
+	if groups, err := r.GetPendingVisibility(10); err != nil || len(groups) != 0 {
+		t.Fatalf("GetPendingVisibility with no pending group returned %+v, %v", groups, err)
+	}
+	if _, err := r.FinishVisibility(g.Id, models.VisibilityPrivate); err != groupsAccess.ErrVisibilityChanged {
+		t.Errorf("FinishVisibility of a group that is not waiting returned %v, want %v", err, groupsAccess.ErrVisibilityChanged)
+	}
+
+	g.PendingVisibility = models.VisibilityPrivate
+	g, err = r.UpdateGroup(g)
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if g.PendingVisibility != models.VisibilityPrivate || g.Private() {
+		t.Errorf("UpdateGroup returned %+v, want a public group waiting to be private", g)
+	}
+
+	groups, err := r.GetPendingVisibility(10)
+	if err != nil {
+		t.Fatalf("GetPendingVisibility failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Id != g.Id {
+		t.Errorf("GetPendingVisibility returned %+v, want %s", groups, g.Id)
+	}
+
+	if _, err := r.FinishVisibility(g.Id, models.VisibilityUnlisted); err != groupsAccess.ErrVisibilityChanged {
+		t.Errorf("FinishVisibility of another visibility returned %v, want %v", err, groupsAccess.ErrVisibilityChanged)
+	}
+	finished, err := r.FinishVisibility(g.Id, models.VisibilityPrivate)
+	if err != nil {
+		t.Fatalf("FinishVisibility failed: %v", err)
+	}
+	if !finished.Private() || finished.PendingVisibility != "" || finished.Version != g.Version+1 {
+		t.Errorf("FinishVisibility returned %+v, want a private group at version %d", finished, g.Version+1)
+	}
+
+	//a private group is not listed anymore
+	if listed, _, _ := r.GetAllGroups(10, "", groupsAccess.NewestFirst); len(listed) != 1 || listed[0].Id == g.Id {
+		t.Errorf("GetAllGroups after FinishVisibility returned %+v", listed)
+	}
+	if groups, _ := r.GetPendingVisibility(10); len(groups) != 0 {
+		t.Errorf("GetPendingVisibility after FinishVisibility returned %+v", groups)
+	}
+	if _, err := r.FinishVisibility("group-99", models.VisibilityPrivate); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("FinishVisibility of a missing group returned %v, want %v", err, groupsAccess.ErrGroupNotFound)
+	}
+}
+
+func testCountImages(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 1)
+	g := want["group-00"]
+
+	//e1 is delivered twice, like a stream can do
+	for _, e := range []struct {
+		id string
+		n  int64
+	}{{"e1", 1}, {"e1", 1}, {"e2", 1}, {"e3", 1}, {"e4", -1}} {
+		if err := r.CountImages(g.Id, e.id, e.n); err != nil {
+			t.Fatalf("CountImages(%s) failed: %v", e.id, err)
+		}
+	}
+
+	got, err := r.GetGroup(g.Id)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if got.ImageCount != 2 {
+		t.Errorf("imageCount = %d, want 2", got.ImageCount)
+	}
+	//the statistics are not a write of the members, so the version stays the same
+	if got.Version != g.Version {
+		t.Errorf("CountImages changed the version from %d to %d", g.Version, got.Version)
+	}
+
+	//an update of the group keeps its statistics
+	got.Name = "Renamed"
+	updated, err := r.UpdateGroup(got)
+	if err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	if updated.ImageCount != 2 {
+		t.Errorf("UpdateGroup returned imageCount %d, want 2", updated.ImageCount)
+	}
+
+	//the images of a deleted group are removed after it, and must not bring it back
+	if err := r.CountImages("missing", "e5", -1); err != nil {
+		t.Errorf("CountImages of a missing group returned %v, want nil", err)
+	}
+	if _, err := r.GetGroup("missing"); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("CountImages of a missing group created it")
+	}
+}
+
+func testLatestImage(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 1)
+	g := want["group-00"]
+
+	const older, newer = "2021-05-01T12:00:00.000000000Z", "2021-05-01T12:00:01.000000000Z"
+
+	//the images can be seen out of order, and more than once
+	for _, image := range []struct{ id, ts string }{{"new", newer}, {"old", older}, {"new", newer}} {
+		if err := r.SetLatestImage(g.Id, image.id, image.ts); err != nil {
+			t.Fatalf("SetLatestImage(%s) failed: %v", image.id, err)
+		}
+	}
+
+	got, _ := r.GetGroup(g.Id)
+	if got.CoverImageId != "new" || got.LastImageAt != newer {
+		t.Errorf("cover = %q at %q, want new at %q", got.CoverImageId, got.LastImageAt, newer)
+	}
+
+	//removing an image that is not the cover changes nothing
+	if err := r.ReplaceLatestImage(g.Id, older, "", ""); err != nil {
+		t.Fatalf("ReplaceLatestImage failed: %v", err)
+	}
+	if got, _ := r.GetGroup(g.Id); got.CoverImageId != "new" {
+		t.Errorf("removing another image changed the cover to %q", got.CoverImageId)
+	}
+
+	//removing the cover makes the newest image left the cover
+	if err := r.ReplaceLatestImage(g.Id, newer, "old", older); err != nil {
+		t.Fatalf("ReplaceLatestImage failed: %v", err)
+	}
+	if got, _ := r.GetGroup(g.Id); got.CoverImageId != "old" || got.LastImageAt != older {
+		t.Errorf("removing the cover left %q at %q, want old at %q", got.CoverImageId, got.LastImageAt, older)
+	}
+
+	//and when there is none left, the group has no cover
+	if err := r.ReplaceLatestImage(g.Id, older, "", ""); err != nil {
+		t.Fatalf("ReplaceLatestImage failed: %v", err)
+	}
+	if got, _ := r.GetGroup(g.Id); got.CoverImageId != "" || got.LastImageAt != "" {
+		t.Errorf("removing the last image left %q at %q", got.CoverImageId, got.LastImageAt)
+	}
+
+	if err := r.SetLatestImage("missing", "new", newer); err != nil {
+		t.Errorf("SetLatestImage of a missing group returned %v, want nil", err)
+	}
+	if _, err := r.GetGroup("missing"); err != groupsAccess.ErrGroupNotFound {
+		t.Errorf("SetLatestImage of a missing group created it")
+	}
+}
+
+func testScanGroups(t *testing.T, r groupsAccess.Repository) {
+	want := seed(t, r, 5)
+
+	//unlike GetAllGroups, a scan also reads the unlisted and private groups
+	for id, v := range map[string]models.Visibility{"group-01": models.VisibilityUnlisted, "group-02": models.VisibilityPrivate} {
+		g := want[id]
+		g.Visibility = v
+		updated, err := r.UpdateGroup(g)
+		if err != nil {
+			t.Fatalf("UpdateGroup(%s) failed: %v", id, err)
+		}
+		want[id] = updated
+	}
+
+	got := make(map[string]models.Group)
+	nextKey := ""
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("ScanGroups did not stop after %d pages", pages)
+		}
+
+		groups, nk, err := r.ScanGroups(2, nextKey)
+		if err != nil {
+			t.Fatalf("ScanGroups failed: %v", err)
+		}
+		for _, g := range groups {
+			if _, ok := got[g.Id]; ok {
+				t.Errorf("ScanGroups returned %s twice", g.Id)
+			}
+			got[g.Id] = g
+		}
+
+		if nk == "" {
+			break
+		}
+		nextKey = nk
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanGroups returned %+v, want %+v", got, want)
+	}
+}
+
+func testImportGroup(t *testing.T, r groupsAccess.Repository) {
+	g := newGroup(1)
+	g.Version = 7 //an exported group keeps its version
+	g.ImageCount = 3
+
+	if err := r.ImportGroup(g); err != nil {
+		t.Fatalf("ImportGroup failed: %v", err)
+	}
+	got, err := r.GetGroup(g.Id)
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("GetGroup returned %+v, want %+v", got, g)
+	}
+
+	//the imported public groups are listed like the others
+	groups, _, _ := r.GetAllGroups(10, "", groupsAccess.NewestFirst)
+	if len(groups) != 1 || groups[0].Id != g.Id {
+		t.Errorf("GetAllGroups returned %+v, want the imported group", groups)
+	}
+
+	//importing again must not overwrite the group, so an import can be run twice
+	changed := g
+	changed.Name = "Changed"
+	if err := r.ImportGroup(changed); !groupsAccess.IsConflict(err) {
+		t.Errorf("ImportGroup of an existing group returned %v, want a *ConflictError", err)
+	}
+	if got, _ := r.GetGroup(g.Id); got.Name != g.Name {
+		t.Errorf("ImportGroup overwrote the group: %+v", got)
+	}
+}
@@ -0,0 +1,114 @@
+package groupsAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess/groupsAccessTest"
+)
+
+/*
+This test needs a DynamoDB to talk to, so it only runs when DYNAMODB_ENDPOINT is set. Eg with DynamoDB Local
+
+	docker run -p 8000:8000 amazon/dynamodb-local
+	DYNAMODB_ENDPOINT=http://localhost:8000 AWS_REGION=ca-central-1 go test ./src/dataLayer/...
+*/
+func TestGroupDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	groupsAccessTest.TestRepository(t, func() groupsAccess.Repository {
+		n++
+		table := fmt.Sprintf("Groups-test-%d-%d", time.Now().UnixNano(), n)
+		createGroupsTable(t, client, table)
+		createEventsTable(t, client, table+"-events")
+
+		return groupsAccess.NewDynamoDbRepoWithClient(client, table, "UserIdIndex", "CreatedAtIndex", "PendingVisibilityIndex", table+"-events")
+	})
+}
+
+// createGroupsTable creates a table with the same key schema as GroupsDynamoDBTable in serverless.yml
+func createGroupsTable(t *testing.T, client *dynamodb.DynamoDB, table string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("userId"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("listing"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("timestamp"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("pendingVisibility"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("UserIdIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("userId"), KeyType: aws.String("HASH")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+			{
+				IndexName: aws.String("CreatedAtIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("listing"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("timestamp"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+			{
+				IndexName: aws.String("PendingVisibilityIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("pendingVisibility"), KeyType: aws.String("HASH")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
+
+// createEventsTable creates a table with the same key schema as ProcessedEventsDynamoDBTable in serverless.yml
+func createEventsTable(t *testing.T, client *dynamodb.DynamoDB, table string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("eventId"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("eventId"), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
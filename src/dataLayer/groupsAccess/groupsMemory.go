@@ -0,0 +1,313 @@
+package groupsAccess
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+GroupMemoryRepository is our second Adapter for the Repository Port. It keeps the groups in a map
+so that we can unit test the businessLogic and run our handlers locally without AWS.
+
+It paginates the same way GroupDynamoDbRepository does: the nextKey is a signed cursor made from
+the key of the last item returned, and an empty nextKey means there are no more items
+*/
+type GroupMemoryRepository struct {
+	mu     sync.RWMutex
+	groups map[string]models.Group
+	events map[string]bool // the stream events we already counted
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &GroupMemoryRepository{groups: make(map[string]models.Group), events: make(map[string]bool)}
+}
+
+// GetAllGroups gets the groups anybody can find, ie the public ones, in the order they were created
+func (r *GroupMemoryRepository) GetAllGroups(limit int64, nextKey string, order SortOrder) ([]models.Group, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	//like the CreatedAtIndex, the key of a page has the listing and timestamp of the last group
+	lastKey := func(g models.Group) map[string]string {
+		return map[string]string{"id": g.Id, listingAttr: listingPublic, "timestamp": g.Timestamp}
+	}
+
+	return r.page(allGroupsScope(order), models.Group.Listed, byTimestamp(order), lastKey, limit, nextKey)
+}
+
+// GetGroupsByUser gets the groups a user created
+func (r *GroupMemoryRepository) GetGroupsByUser(userId string, limit int64, nextKey string) ([]models.Group, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	//the LastEvaluatedKey of the UserIdIndex has the userId as well
+	lastKey := func(g models.Group) map[string]string {
+		return map[string]string{"id": g.Id, "userId": g.UserId}
+	}
+
+	return r.page(userGroupsScope(userId), func(g models.Group) bool { return g.UserId == userId }, byId, lastKey, limit, nextKey)
+}
+
+// byId is the order of a Query without a sort key. We just need an order that is stable between calls
+func byId(a, b models.Group) bool {
+	return a.Id < b.Id
+}
+
+// byTimestamp is the order of the CreatedAtIndex. Groups created at the same time are in the order of their ids
+func byTimestamp(order SortOrder) func(a, b models.Group) bool {
+	return func(a, b models.Group) bool {
+		if order == OldestFirst {
+			a, b = b, a
+		}
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp > b.Timestamp
+		}
+		return a.Id > b.Id
+	}
+}
+
+// page returns one page of the groups that match, in the order of less. The caller must hold the lock
+func (r *GroupMemoryRepository) page(scope string, match func(models.Group) bool, less func(a, b models.Group) bool, lastKey func(models.Group) map[string]string, limit int64, nextKey string) ([]models.Group, string, error) {
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var matches []models.Group
+	for _, g := range r.groups {
+		if match(g) {
+			matches = append(matches, g)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+
+	start := 0
+	if id, ok := startKey["id"]; ok {
+		//just like ExclusiveStartKey, we start right after the key we were given
+		after := models.Group{Id: aws.StringValue(id.S)}
+		if ts, ok := startKey["timestamp"]; ok {
+			after.Timestamp = aws.StringValue(ts.S)
+		}
+		start = sort.Search(len(matches), func(i int) bool { return less(after, matches[i]) })
+	}
+
+	var groups []models.Group
+	for _, g := range matches[start:] {
+		if int64(len(groups)) >= limit {
+			break
+		}
+		groups = append(groups, g)
+	}
+
+	/*
+		DynamoDB returns a LastEvaluatedKey whenever the Limit was reached, even when the last item
+		in the table happens to be the last item of the page. We do the same thing here
+	*/
+	if len(groups) == 0 || int64(len(groups)) < limit {
+		return groups, "", nil
+	}
+
+	nk, err := cursors.Encode(scope, cursor.StringKey(lastKey(groups[len(groups)-1])))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return groups, nk, nil
+}
+
+// Store creates a new group in memory. A new group is at version 1
+func (r *GroupMemoryRepository) CreateGroup(group models.Group) (models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[group.Id]; ok {
+		return models.Group{}, &ConflictError{group.Id, 0}
+	}
+
+	group.Version = 1
+	r.groups[group.Id] = group
+
+	return group, nil
+}
+
+// GetGroup returns the group with the given id
+func (r *GroupMemoryRepository) GetGroup(id string) (models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return models.Group{}, ErrGroupNotFound
+	}
+
+	return group, nil
+}
+
+// GetGroups returns the groups with the given ids, in the same order. The ids of groups that don't exist are left out
+func (r *GroupMemoryRepository) GetGroups(ids []string) ([]models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return inOrder(ids, r.groups), nil
+}
+
+// UpdateGroup saves the name, description, visibility, tags and originals policy of an existing group if it is still at group.Version
+func (r *GroupMemoryRepository) UpdateGroup(group models.Group) (models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[group.Id]
+	if !ok {
+		return models.Group{}, ErrGroupNotFound
+	}
+	if stored.Version != group.Version {
+		return models.Group{}, &ConflictError{group.Id, group.Version}
+	}
+
+	stored.Name = group.Name
+	stored.Description = group.Description
+	stored.Visibility = group.GetVisibility()
+	stored.OriginalsPolicy = group.OriginalsPolicy
+	stored.PendingVisibility = group.PendingVisibility
+	stored.Tags = nil
+	if len(group.Tags) > 0 {
+		stored.Tags = group.Tags
+	}
+	stored.Version++
+	r.groups[group.Id] = stored
+
+	return stored, nil
+}
+
+// FinishVisibility gives a group the visibility it was waiting for
+func (r *GroupMemoryRepository) FinishVisibility(id string, v models.Visibility) (models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[id]
+	if !ok {
+		return models.Group{}, ErrGroupNotFound
+	}
+	if stored.PendingVisibility != v {
+		return models.Group{}, ErrVisibilityChanged
+	}
+
+	stored.Visibility = v
+	stored.PendingVisibility = ""
+	stored.Version++
+	r.groups[id] = stored
+
+	return stored, nil
+}
+
+// GetPendingVisibility gets up to limit groups that are waiting for their visibility
+func (r *GroupMemoryRepository) GetPendingVisibility(limit int64) ([]models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := []models.Group{}
+	for _, g := range r.groups {
+		if g.PendingVisibility != "" && int64(len(groups)) < limit {
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+// DeleteGroup deletes a group if it is still at the given version
+func (r *GroupMemoryRepository) DeleteGroup(id string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[id]
+	if !ok {
+		return ErrGroupNotFound
+	}
+	if stored.Version != version {
+		return &ConflictError{id, version}
+	}
+	delete(r.groups, id)
+
+	return nil
+}
+
+// CountImages adds n to the imageCount of a group, only the first time it is called with eventId
+func (r *GroupMemoryRepository) CountImages(groupId string, eventId string, n int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[groupId]
+	if !ok || r.events[eventId] {
+		return nil
+	}
+
+	r.events[eventId] = true
+	stored.ImageCount += n
+	r.groups[groupId] = stored
+
+	return nil
+}
+
+// SetLatestImage makes an image the cover of a group if it is newer than the current cover
+func (r *GroupMemoryRepository) SetLatestImage(groupId string, imageId string, timestamp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[groupId]
+	if !ok || (stored.LastImageAt != "" && stored.LastImageAt >= timestamp) {
+		return nil
+	}
+
+	stored.LastImageAt = timestamp
+	stored.CoverImageId = imageId
+	r.groups[groupId] = stored
+
+	return nil
+}
+
+// ReplaceLatestImage gives a group another cover, or none when imageId is empty, if the removed image was its cover
+func (r *GroupMemoryRepository) ReplaceLatestImage(groupId string, removedTimestamp string, imageId string, timestamp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.groups[groupId]
+	if !ok || stored.LastImageAt != removedTimestamp {
+		return nil
+	}
+
+	stored.LastImageAt = timestamp
+	stored.CoverImageId = imageId
+	r.groups[groupId] = stored
+
+	return nil
+}
+
+// ScanGroups gets every group, whatever its visibility
+func (r *GroupMemoryRepository) ScanGroups(limit int64, nextKey string) ([]models.Group, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lastKey := func(g models.Group) map[string]string {
+		return map[string]string{"id": g.Id}
+	}
+
+	return r.page(scanGroupsScope, func(models.Group) bool { return true }, byId, lastKey, limit, nextKey)
+}
+
+// ImportGroup stores a group as it is, unless there already is a group with its id
+func (r *GroupMemoryRepository) ImportGroup(group models.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[group.Id]; ok {
+		return &ConflictError{group.Id, 0}
+	}
+	r.groups[group.Id] = group
+
+	return nil
+}
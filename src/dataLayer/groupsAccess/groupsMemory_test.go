@@ -0,0 +1,12 @@
+package groupsAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess/groupsAccessTest"
+)
+
+func TestGroupMemoryRepository(t *testing.T) {
+	groupsAccessTest.TestRepository(t, groupsAccess.NewMemoryRepo)
+}
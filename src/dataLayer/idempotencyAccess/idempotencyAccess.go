@@ -0,0 +1,190 @@
+package idempotencyAccess
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It stores the requests that were sent
+with an Idempotency-Key, and their responses. See the idempotency package for how we use it.
+
+We have two Adapters for it: IdempotencyDynamoDbRepository for AWS and IdempotencyMemoryRepository
+for unit tests and running our handlers locally
+*/
+type Repository interface {
+	Claim(r models.IdempotencyRecord, now time.Time) (models.IdempotencyRecord, bool, error)
+	Complete(r models.IdempotencyRecord) error
+	Release(r models.IdempotencyRecord) error
+}
+
+// IdempotencyDynamoDbRepository is the Adapter that keeps the idempotency keys in DynamoDB
+type IdempotencyDynamoDbRepository struct {
+	client *dynamodb.DynamoDB
+	table  *string
+}
+
+var (
+	tableName = aws.String(os.Getenv("IDEMPOTENCY_TABLE"))
+	/*
+		Set IDEMPOTENCY_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("IDEMPOTENCY_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// Creates a DynamoDb client
+func createDynamoDBClient() *dynamodb.DynamoDB {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(svc.Client)
+	return svc
+}
+
+// NewRepo creates the Repository our handlers should use based on the IDEMPOTENCY_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	return &IdempotencyDynamoDbRepository{createDynamoDBClient(), tableName}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client and table
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string) Repository {
+	return &IdempotencyDynamoDbRepository{c, aws.String(table)}
+}
+
+/*
+Claim stores r, a request we are about to handle, and returns true. When somebody already has the key,
+it returns their record and false instead.
+
+The key is free when nobody has it, when the record of the last request expired, or when the same
+request was being handled but its lock ran out, eg because the Lambda that had it timed out. Many
+retries can arrive at the same time, so the put is conditional and only one of them gets the key.
+
+When the put fails we read the record that has the key. It can be released before we read it, then we try again
+*/
+func (r *IdempotencyDynamoDbRepository) Claim(rec models.IdempotencyRecord, now time.Time) (models.IdempotencyRecord, bool, error) {
+	item, err := dynamodbattribute.MarshalMap(rec)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: r.table,
+		ConditionExpression: aws.String("attribute_not_exists(#k) OR expiresAt <= :now OR " +
+			"(requestHash = :hash AND attribute_not_exists(statusCode) AND lockedUntil <= :now)"),
+		ExpressionAttributeNames: map[string]*string{"#k": aws.String("key")}, //key is a reserved word in DynamoDB
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now":  {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+			":hash": {S: aws.String(rec.RequestHash)},
+		},
+	}
+
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		_, err := r.client.PutItem(input)
+		if err == nil {
+			return rec, true, nil
+		}
+		if !isConditionalCheckFailed(err) {
+			return models.IdempotencyRecord{}, false, apperrors.FromAWS(err)
+		}
+
+		result, err := r.client.GetItem(&dynamodb.GetItemInput{
+			Key: map[string]*dynamodb.AttributeValue{
+				"key": {S: aws.String(rec.Key)},
+			},
+			TableName:      r.table,
+			ConsistentRead: aws.Bool(true), //we must see the record that made our put fail
+		})
+		if err != nil {
+			return models.IdempotencyRecord{}, false, apperrors.FromAWS(err)
+		}
+		if result.Item == nil {
+			continue
+		}
+
+		existing := models.IdempotencyRecord{}
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &existing); err != nil {
+			return models.IdempotencyRecord{}, false, err
+		}
+		return existing, false, nil
+	}
+
+	return models.IdempotencyRecord{}, false, apperrors.Conflict("the Idempotency-Key is being used by another request, try again later")
+}
+
+// Complete stores the response of a request we claimed, as long as nobody took the key from us in between
+func (r *IdempotencyDynamoDbRepository) Complete(rec models.IdempotencyRecord) error {
+	item, err := dynamodbattribute.MarshalMap(rec)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:                item,
+		TableName:           r.table,
+		ConditionExpression: aws.String("requestHash = :hash AND attribute_not_exists(statusCode)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":hash": {S: aws.String(rec.RequestHash)},
+		},
+	}
+
+	if _, err := r.client.PutItem(input); err != nil {
+		if isConditionalCheckFailed(err) {
+			return apperrors.Conflict("the Idempotency-Key was claimed by another request")
+		}
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+/*
+Release gives up a key we claimed without storing a response, eg because handling the request failed
+with an error the client should retry. The next request with the key is handled again
+*/
+func (r *IdempotencyDynamoDbRepository) Release(rec models.IdempotencyRecord) error {
+	input := &dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"key": {S: aws.String(rec.Key)},
+		},
+		TableName:           r.table,
+		ConditionExpression: aws.String("requestHash = :hash AND attribute_not_exists(statusCode)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":hash": {S: aws.String(rec.RequestHash)},
+		},
+	}
+
+	if _, err := r.client.DeleteItem(input); err != nil && !isConditionalCheckFailed(err) {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// maxClaimAttempts is how many times Claim tries when the key keeps changing hands under it
+const maxClaimAttempts = 3
+
+func isConditionalCheckFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
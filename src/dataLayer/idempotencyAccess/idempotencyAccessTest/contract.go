@@ -0,0 +1,136 @@
+/*
+Package idempotencyAccessTest is the contract every Adapter of the idempotencyAccess.Repository Port must pass
+*/
+package idempotencyAccessTest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() idempotencyAccess.Repository) {
+	t.Run("ClaimAndComplete", func(t *testing.T) {
+		testClaimAndComplete(t, newRepo())
+	})
+	t.Run("Release", func(t *testing.T) {
+		testRelease(t, newRepo())
+	})
+	t.Run("ExpiredRecord", func(t *testing.T) {
+		testExpiredRecord(t, newRepo())
+	})
+	t.Run("LockRunsOut", func(t *testing.T) {
+		testLockRunsOut(t, newRepo())
+	})
+}
+
+// now is a fixed time so the tests don't depend on the clock
+var now = time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func record(key, hash string) models.IdempotencyRecord {
+	return models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: hash,
+		LockedUntil: now.Add(time.Minute).Unix(),
+		ExpiresAt:   now.Add(24 * time.Hour).Unix(),
+	}
+}
+
+func claim(t *testing.T, r idempotencyAccess.Repository, rec models.IdempotencyRecord, at time.Time) (models.IdempotencyRecord, bool) {
+	t.Helper()
+
+	got, claimed, err := r.Claim(rec, at)
+	if err != nil {
+		t.Fatalf("Claim(%s) failed: %v", rec.Key, err)
+	}
+	return got, claimed
+}
+
+func testClaimAndComplete(t *testing.T, r idempotencyAccess.Repository) {
+	rec := record("user-1:k1", "h1")
+	if _, claimed := claim(t, r, rec, now); !claimed {
+		t.Fatal("Claim of a new key returned false")
+	}
+
+	//a retry while we are still handling the request gets our record, without a response
+	got, claimed := claim(t, r, rec, now)
+	if claimed || got.Completed() || got.RequestHash != "h1" {
+		t.Errorf("Claim of a key in progress returned %+v, %v", got, claimed)
+	}
+
+	rec.StatusCode = 201
+	rec.Body = `{"id":"1"}`
+	rec.Headers = map[string]string{"Content-Type": "application/json"}
+	if err := r.Complete(rec); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	//a retry after we answered gets the response, even with another body
+	got, claimed = claim(t, r, record("user-1:k1", "h2"), now)
+	if claimed || got.StatusCode != 201 || got.Body != rec.Body || got.Headers["Content-Type"] != "application/json" || got.RequestHash != "h1" {
+		t.Errorf("Claim of a completed key returned %+v, %v", got, claimed)
+	}
+
+	//a completed record can't be completed again
+	if err := r.Complete(rec); !errors.Is(err, apperrors.ErrConflict) {
+		t.Errorf("Complete of a completed record returned %v, want a conflict", err)
+	}
+
+	//other keys are not affected
+	if _, claimed := claim(t, r, record("user-2:k1", "h1"), now); !claimed {
+		t.Error("Claim of another key returned false")
+	}
+}
+
+func testRelease(t *testing.T, r idempotencyAccess.Repository) {
+	rec := record("user-1:k1", "h1")
+	claim(t, r, rec, now)
+
+	//only the request that has the key can release it
+	if err := r.Release(record("user-1:k1", "other")); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, claimed := claim(t, r, rec, now); claimed {
+		t.Fatal("Release of another request freed the key")
+	}
+
+	if err := r.Release(rec); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, claimed := claim(t, r, record("user-1:k1", "h2"), now); !claimed {
+		t.Error("Claim of a released key returned false")
+	}
+}
+
+func testExpiredRecord(t *testing.T, r idempotencyAccess.Repository) {
+	rec := record("user-1:k1", "h1")
+	claim(t, r, rec, now)
+	rec.StatusCode = 201
+	if err := r.Complete(rec); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	//DynamoDB may not have deleted the record yet, but it expired
+	if _, claimed := claim(t, r, record("user-1:k1", "h2"), now.Add(25*time.Hour)); !claimed {
+		t.Error("Claim of an expired key returned false")
+	}
+}
+
+func testLockRunsOut(t *testing.T, r idempotencyAccess.Repository) {
+	claim(t, r, record("user-1:k1", "h1"), now)
+	later := now.Add(2 * time.Minute)
+
+	//the request that had the key never finished, eg its Lambda timed out. Another request can't take the key over
+	if _, claimed := claim(t, r, record("user-1:k1", "h2"), later); claimed {
+		t.Error("Claim with another request took over the key")
+	}
+	//but a retry of the same request can
+	if _, claimed := claim(t, r, record("user-1:k1", "h1"), later); !claimed {
+		t.Error("Claim of the same request after the lock ran out returned false")
+	}
+}
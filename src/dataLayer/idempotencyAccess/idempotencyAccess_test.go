@@ -0,0 +1,57 @@
+package idempotencyAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess/idempotencyAccessTest"
+)
+
+// Like the groupsAccess tests, this only runs when DYNAMODB_ENDPOINT points at a DynamoDB, eg DynamoDB Local
+func TestIdempotencyDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	idempotencyAccessTest.TestRepository(t, func() idempotencyAccess.Repository {
+		n++
+		table := fmt.Sprintf("Idempotency-test-%d-%d", time.Now().UnixNano(), n)
+		createIdempotencyTable(t, client, table)
+
+		return idempotencyAccess.NewDynamoDbRepoWithClient(client, table)
+	})
+}
+
+// createIdempotencyTable creates a table with the same key schema as IdempotencyDynamoDBTable in serverless.yml
+func createIdempotencyTable(t *testing.T, client *dynamodb.DynamoDB, table string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("key"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("key"), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
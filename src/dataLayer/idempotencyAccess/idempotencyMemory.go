@@ -0,0 +1,65 @@
+package idempotencyAccess
+
+import (
+	"sync"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// IdempotencyMemoryRepository is the in-memory Adapter of our Repository Port. The records are keyed by their Key
+type IdempotencyMemoryRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &IdempotencyMemoryRepository{records: make(map[string]models.IdempotencyRecord)}
+}
+
+// Claim stores r and returns true, or returns the record that already has the key and false
+func (r *IdempotencyMemoryRepository) Claim(rec models.IdempotencyRecord, now time.Time) (models.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.records[rec.Key]
+	lockRanOut := existing.RequestHash == rec.RequestHash && !existing.Completed() && now.Unix() >= existing.LockedUntil
+	if ok && !existing.Expired(now) && !lockRanOut {
+		return existing, false, nil
+	}
+
+	r.records[rec.Key] = rec
+	return rec, true, nil
+}
+
+// Complete stores the response of a request we claimed, as long as nobody took the key from us in between
+func (r *IdempotencyMemoryRepository) Complete(rec models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.claimedBy(rec) {
+		return apperrors.Conflict("the Idempotency-Key was claimed by another request")
+	}
+
+	r.records[rec.Key] = rec
+	return nil
+}
+
+// Release gives up a key we claimed without storing a response
+func (r *IdempotencyMemoryRepository) Release(rec models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.claimedBy(rec) {
+		delete(r.records, rec.Key)
+	}
+	return nil
+}
+
+// claimedBy tells if the key is still claimed by the request of rec. The caller must hold the lock
+func (r *IdempotencyMemoryRepository) claimedBy(rec models.IdempotencyRecord) bool {
+	existing, ok := r.records[rec.Key]
+	return ok && existing.RequestHash == rec.RequestHash && !existing.Completed()
+}
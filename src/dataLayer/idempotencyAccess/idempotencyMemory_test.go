@@ -0,0 +1,12 @@
+package idempotencyAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess/idempotencyAccessTest"
+)
+
+func TestIdempotencyMemoryRepository(t *testing.T) {
+	idempotencyAccessTest.TestRepository(t, idempotencyAccess.NewMemoryRepo)
+}
@@ -0,0 +1,95 @@
+package imagesAccess
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+/*
+An ImageRecord is a row of the Images table with all of its attributes. cmd/exportImport copies the
+images of a stage into another one with them, so we keep the attributes we don't know about as well
+*/
+type ImageRecord map[string]interface{}
+
+// The scope of the cursors of GetGroupImages. They are not the cursors of GetImagesByGroup, see groupImagesScope
+func exportImagesScope(groupId string) string {
+	return "images:export:" + groupId
+}
+
+// groupId and timestamp are the key of the Images table
+func (i ImageRecord) key() (string, string, error) {
+	groupId, _ := i["groupId"].(string)
+	timestamp, _ := i["timestamp"].(string)
+	if groupId == "" || timestamp == "" {
+		return "", "", apperrors.Invalid("an image needs a groupId and a timestamp")
+	}
+	return groupId, timestamp, nil
+}
+
+/*
+GetGroupImages gets the images of a group with all their attributes, the oldest first, the pending
+ones too. It is only meant for exports, our handlers use GetImagesByGroup
+*/
+func (r *ImageDynamoDbRepository) GetGroupImages(groupId string, limit int64, nextKey string) ([]ImageRecord, string, error) {
+	scope := exportImagesScope(groupId)
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := r.client.Query(&dynamodb.QueryInput{
+		TableName:              r.table,
+		KeyConditionExpression: aws.String("groupId = :groupId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":groupId": {
+				S: aws.String(groupId),
+			},
+		},
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
+
+	var images []ImageRecord
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &images); err != nil {
+		return nil, "", err
+	}
+
+	nk, err := cursors.Encode(scope, result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return images, nk, nil
+}
+
+// ImportImage stores an image exactly as it was exported. It returns ErrImageExists when the image is already there
+func (r *ImageDynamoDbRepository) ImportImage(image ImageRecord) error {
+	if _, _, err := image.key(); err != nil {
+		return err
+	}
+
+	item, err := dynamodbattribute.MarshalMap(image)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		Item:                item,
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_not_exists(groupId)"), //never overwrite an image
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrImageExists
+	}
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
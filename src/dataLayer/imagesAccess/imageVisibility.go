@@ -0,0 +1,99 @@
+package imagesAccess
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The S3 object tag that tells our bucket policy an image must not be readable by anybody
+const VisibilityTagKey = "visibility"
+
+// How many images of a group we tag per Query page
+const visibilityPageSize = 1000
+
+/*
+SetImagesVisibility tags the images of a group in the images bucket. Our bucket policy only lets
+anybody read the objects that are not tagged visibility=private, so this is what makes the images
+of a private group private. Thumbnails are never public so we leave them alone. The failed images
+stay private when the group becomes public: their original may still have its metadata, see HideOriginal.
+
+Tagging an image again is harmless, so after a failure the caller simply calls it again
+*/
+func (r *ImageDynamoDbRepository) SetImagesVisibility(groupId string, v models.Visibility) error {
+	if r.s3Client == nil {
+		return nil
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		KeyConditionExpression: aws.String("groupId = :groupId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":groupId": {
+				S: aws.String(groupId),
+			},
+		},
+		//we only need the ids and statuses, not the whole image
+		ProjectionExpression: aws.String("imageId, #status"),
+		ExpressionAttributeNames: map[string]*string{
+			"#status": aws.String("status"), //status is a reserved word
+		},
+		Limit: aws.Int64(visibilityPageSize),
+	}
+
+	//A group can have more images than a single Query page(1MB) can return, so we keep going until there is no LastEvaluatedKey
+	for {
+		result, err := r.client.Query(input)
+		if err != nil {
+			return apperrors.FromAWS(err)
+		}
+
+		var images []models.Image
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &images); err != nil {
+			return err
+		}
+		for _, image := range images {
+			if v != models.VisibilityPrivate && image.GetStatus() == models.ImageStatusFailed {
+				continue
+			}
+			if err := r.setVisibility(image.ImageId, v); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// setVisibility tags or untags the file of one image
+func (r *ImageDynamoDbRepository) setVisibility(imageId string, v models.Visibility) error {
+	var err error
+	if v == models.VisibilityPrivate {
+		_, err = r.s3Client.PutObjectTagging(&s3.PutObjectTaggingInput{
+			Bucket:  aws.String(r.bucket),
+			Key:     aws.String(imageId),
+			Tagging: &s3.Tagging{TagSet: []*s3.Tag{{Key: aws.String(VisibilityTagKey), Value: aws.String(string(v))}}},
+		})
+	} else {
+		_, err = r.s3Client.DeleteObjectTagging(&s3.DeleteObjectTaggingInput{
+			Bucket: aws.String(r.bucket),
+			Key:    aws.String(imageId),
+		})
+	}
+
+	//the row of an image is created before the client uploads it, so the object may not be there(yet)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil
+	}
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+	return nil
+}
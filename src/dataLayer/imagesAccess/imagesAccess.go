@@ -0,0 +1,876 @@
+package imagesAccess
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It stores the images of the groups, and
+gives the urls of their files in the images bucket.
+
+We have two Adapters for it: ImageDynamoDbRepository for AWS and ImageMemoryRepository for unit
+tests and running our handlers locally
+*/
+type Repository interface {
+	CreateImage(image models.Image) (models.Image, error)
+	GetImage(imageId string) (models.Image, error)
+	GetImagesByGroup(groupId string, r TimeRange, l int64, n string, order groupsAccess.SortOrder, by SortBy, includePending bool) ([]models.Image, string, error)
+	UpdateImage(image models.Image) (models.Image, error)
+	DeleteImage(groupId string, timestamp string) error
+
+	// The lifecycle of the file of an image, see models.ImageStatus
+	SetImageStatus(imageId string, status models.ImageStatus, at string) (models.Image, error)
+	GetPendingImages(before string, limit int64) ([]models.Image, error)
+	ExpireImage(groupId string, timestamp string, before string) error
+	SetImageMetadata(imageId string, metadata models.ImageMetadata) (models.Image, error)
+	SetMultipartUpload(imageId string, expected string, upload *models.MultipartUpload) (models.Image, error)
+
+	// The urls of the files of the images
+	PublicUrl(imageId string) string
+	GetUploadUrl(imageId string, contentType string, size int64, private bool) (models.ImageUpload, error)
+	GetPrivateUrl(imageId string) (string, error)
+
+	// Deleting the files of an image. It is not an error if they are not there
+	DeleteOriginal(imageId string) error
+	DeleteThumbnail(imageId string) error
+
+	// Rewriting the original of an image without its metadata, and keeping a private copy of it. See exif.Strip
+	ReplaceOriginal(imageId string, file []byte, contentType string, private bool) error
+	CopyOriginal(imageId string, file []byte, contentType string) error
+	HideOriginal(imageId string) error
+
+	// Uploading the file of an image in parts, see models.MultipartUpload
+	StartMultipartUpload(imageId string, contentType string, private bool) (string, error)
+	GetUploadPartUrl(imageId string, uploadId string, partNumber int64) (string, error)
+	GetUploadedParts(imageId string, uploadId string) ([]models.UploadedPart, error)
+	CompleteMultipartUpload(imageId string, uploadId string, parts []models.UploadedPart) error
+	AbortMultipartUpload(imageId string, uploadId string) error
+
+	// Tagging the files of the images of a group for its visibility. See imageVisibility.go
+	SetImagesVisibility(groupId string, v models.Visibility) error
+
+	// Reading and writing the images of whole stages, for cmd/exportImport. See imageExport.go
+	GetGroupImages(groupId string, l int64, n string) ([]ImageRecord, string, error)
+	ImportImage(image ImageRecord) error
+}
+
+var (
+	// ErrImageNotFound is returned when the image we want to read does not exist
+	ErrImageNotFound = apperrors.NotFound("image not found")
+	// ErrImageExists is returned by CreateImage and ImportImage when there already is an image with the same key
+	ErrImageExists = apperrors.Conflict("image already exists")
+	// ErrImageChanged is returned by UpdateImage when the image is not at the version we read anymore
+	ErrImageChanged = apperrors.Conflict("image was changed by somebody else")
+	// ErrInvalidStatus is returned by SetImageStatus when the image can't go from its status to the new one
+	ErrInvalidStatus = apperrors.Conflict("image can't get that status")
+	// ErrImageNotPending is returned by ExpireImage when the image is not pending anymore, is being uploaded in parts, or is gone
+	ErrImageNotPending = apperrors.Conflict("image is not pending")
+	// ErrUploadChanged is returned by SetMultipartUpload when the image does not have the upload we expected anymore
+	ErrUploadChanged = apperrors.Conflict("another upload of the image was started or finished")
+	// ErrUploadNotFound is returned for a multipart upload S3 does not know, eg because it was completed or aborted
+	ErrUploadNotFound = apperrors.NotFound("upload not found")
+)
+
+/*
+Only the images that are still pending have a pending attribute, so they are the only ones in the
+PendingIndex. Like the CreatedAtIndex of the Groups table, they all have the same pending value as
+partition key and the timestamp as sort key, so we can Query the oldest ones.
+
+An image gets it from CreateImage and loses it when its file is uploaded, see SetImageStatus
+*/
+const (
+	pendingAttr  = "pending"
+	pendingValue = "pending"
+)
+
+/*
+Every image has a takenAtKey attribute, the sort key of the TakenAtIndex that lists the images of a
+group by the time they were taken. It is the takenAt of the image, or its timestamp when we don't
+know when it was taken: until its file is processed, or when the file does not say.
+
+The images created before we had it are not in the index, until their file is processed again
+*/
+const takenAtKeyAttr = "takenAtKey"
+
+// takenAtKey returns the takenAtKey of an image
+func takenAtKey(image models.Image) string {
+	if image.TakenAt != "" {
+		return image.TakenAt
+	}
+	return image.Timestamp
+}
+
+// SortBy is the time GetImagesByGroup sorts the images by. The SortOrder says which ones come first
+type SortBy string
+
+const (
+	ByCreatedAt SortBy = "createdAt"
+	ByTakenAt   SortBy = "takenAt" // the images we don't know the time of are where they were created, see takenAtKey
+)
+
+// ParseSortBy reads the sort query parameter of getImages. Without one we sort the images by the time they were created
+func ParseSortBy(s string) (SortBy, error) {
+	switch SortBy(s) {
+	case "", ByCreatedAt:
+		return ByCreatedAt, nil
+	case ByTakenAt:
+		return ByTakenAt, nil
+	}
+	return "", apperrors.Invalid("sort must be createdAt or takenAt")
+}
+
+/*
+statusesBefore are the statuses an image must have to get a status. Any image can get uploaded
+again, that is a new file. An image is only processed or failed once its file was uploaded
+*/
+var statusesBefore = map[models.ImageStatus][]models.ImageStatus{
+	models.ImageStatusProcessed: {models.ImageStatusUploaded, models.ImageStatusProcessed, models.ImageStatusFailed},
+	models.ImageStatusFailed:    {models.ImageStatusUploaded, models.ImageStatusProcessed, models.ImageStatusFailed},
+}
+
+// canChangeStatus tells if an image with status from can get status to
+func canChangeStatus(from models.ImageStatus, to models.ImageStatus) bool {
+	if to == models.ImageStatusUploaded {
+		return true
+	}
+	for _, s := range statusesBefore[to] {
+		if s == from {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+A TimeRange selects the images created, or taken with ByTakenAt, between From and To, both included.
+They are timestamps in models.TimestampFormat, and an empty one means there is no bound on that side
+*/
+type TimeRange struct {
+	From string
+	To   string
+}
+
+/*
+The nextKey of GetImagesByGroup is a cursor signed for the group, order, sort and range it was made
+for. A cursor from another range could point outside of it
+*/
+func groupImagesScope(groupId string, r TimeRange, order groupsAccess.SortOrder, by SortBy) string {
+	scope := "images:" + groupId + ":" + string(order) + ":" + r.From + ":" + r.To
+	if by == ByTakenAt {
+		//the cursors we gave out before we had SortBy are sorted by createdAt, they keep working
+		scope += ":" + string(by)
+	}
+	return scope
+}
+
+const (
+	uploadUrlExpiry  = 5 * time.Minute  // how long the client has to start uploading a new image
+	privateUrlExpiry = 15 * time.Minute // how long the links to the images of a private group work
+)
+
+// ImageDynamoDbRepository is the Adapter that keeps the images in DynamoDB and their files in S3
+type ImageDynamoDbRepository struct {
+	client       *dynamodb.DynamoDB
+	table        *string
+	imageIdIndex *string
+	pendingIndex *string
+	takenAtIndex *string
+	// The buckets. Only set by NewDynamoDbRepo, a Repository created by NewDynamoDbRepoWithClient can't sign urls or delete files
+	s3Client         *s3.S3
+	bucket           string
+	thumbnailsBucket string
+	originalsBucket  string // the private copies of the originals, see CopyOriginal
+}
+
+var (
+	tableName            = aws.String(os.Getenv("IMAGES_TABLE"))
+	imageIdIndexName     = aws.String(os.Getenv("IMAGE_ID_INDEX"))
+	pendingIndexName     = aws.String(os.Getenv("PENDING_IMAGES_INDEX"))
+	takenAtIndexName     = aws.String(os.Getenv("TAKEN_AT_INDEX"))
+	bucketName           = os.Getenv("IMAGES_S3_BUCKET")
+	thumbnailsBucketName = os.Getenv("THUMBNAILS_S3_BUCKET")
+	originalsBucketName  = os.Getenv("ORIGINALS_S3_BUCKET")
+	/*
+		Set IMAGES_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("IMAGES_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+	cursors    = cursor.FromEnv()
+)
+
+// Creates a DynamoDb client and an S3 client
+func createClients() (*dynamodb.DynamoDB, *s3.S3) {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	s3c := s3.New(sess)                        // Create S3 service client
+	xray.AWS(svc.Client)
+	xray.AWS(s3c.Client)
+	return svc, s3c
+}
+
+// NewRepo creates the Repository our handlers should use based on the IMAGES_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	dbc, s3c := createClients()
+
+	return &ImageDynamoDbRepository{
+		client:           dbc,
+		table:            tableName,
+		imageIdIndex:     imageIdIndexName,
+		pendingIndex:     pendingIndexName,
+		takenAtIndex:     takenAtIndexName,
+		s3Client:         s3c,
+		bucket:           bucketName,
+		thumbnailsBucket: thumbnailsBucketName,
+		originalsBucket:  originalsBucketName,
+	}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client, table and indexes
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string, imageIdIndex string, pendingIndex string, takenAtIndex string) Repository {
+	return &ImageDynamoDbRepository{
+		client:       c,
+		table:        aws.String(table),
+		imageIdIndex: aws.String(imageIdIndex),
+		pendingIndex: aws.String(pendingIndex),
+		takenAtIndex: aws.String(takenAtIndex),
+	}
+}
+
+// CreateImage stores a new image. The client uploads its file afterwards, see GetUploadUrl
+func (r *ImageDynamoDbRepository) CreateImage(image models.Image) (models.Image, error) {
+	item, err := dynamodbattribute.MarshalMap(image)
+	if err != nil {
+		return models.Image{}, err
+	}
+	if image.Status == models.ImageStatusPending {
+		item[pendingAttr] = &dynamodb.AttributeValue{S: aws.String(pendingValue)} //puts it in the PendingIndex
+	}
+	item[takenAtKeyAttr] = &dynamodb.AttributeValue{S: aws.String(takenAtKey(image))}
+
+	_, err = r.client.PutItem(&dynamodb.PutItemInput{
+		Item:                item,
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_not_exists(groupId)"), //never overwrite another image
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return models.Image{}, ErrImageExists
+	}
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	return image, nil
+}
+
+// GetImage returns the image with the given id. The imageId is not part of the key of the Images table, so we Query the ImageIdIndex
+func (r *ImageDynamoDbRepository) GetImage(imageId string) (models.Image, error) {
+	result, err := r.client.Query(&dynamodb.QueryInput{
+		TableName:              r.table,
+		IndexName:              r.imageIdIndex,
+		KeyConditionExpression: aws.String("imageId = :imageId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":imageId": {
+				S: aws.String(imageId),
+			},
+		},
+	})
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	if len(result.Items) == 0 {
+		return models.Image{}, ErrImageNotFound
+	}
+
+	image := models.Image{}
+	if err := dynamodbattribute.UnmarshalMap(result.Items[0], &image); err != nil {
+		return models.Image{}, err
+	}
+
+	return image, nil
+}
+
+/*
+GetImagesByGroup gets one page of the images of a group that were created in the time range. The
+timestamp is the range key of the Images table, so the range is part of the KeyConditionExpression
+and we only read the images in it. ByTakenAt does the same with the TakenAtIndex and its takenAtKey.
+
+The pending images are filtered out after the Limit, unless includePending is set. So a page can
+have less than limit images, or none at all, and still have a nextKey
+*/
+func (r *ImageDynamoDbRepository) GetImagesByGroup(groupId string, tr TimeRange, limit int64, nextKey string, order groupsAccess.SortOrder, by SortBy, includePending bool) ([]models.Image, string, error) {
+	scope := groupImagesScope(groupId, tr, order, by)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyCondition, values := timeRangeCondition(tr)
+	values[":groupId"] = &dynamodb.AttributeValue{S: aws.String(groupId)}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 r.table,
+		KeyConditionExpression:    aws.String("groupId = :groupId" + keyCondition), //we specify that we want the images that has the the groupId we want
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(order == groupsAccess.OldestFirst), //false reverses the order of the list. The latest images will be first
+		Limit:                     aws.Int64(limit),
+		ExclusiveStartKey:         startKey,
+	}
+	names := map[string]*string{}
+	if keyCondition != "" {
+		names["#ts"] = aws.String("timestamp") //timestamp is a reserved word in DynamoDB
+	}
+	if by == ByTakenAt {
+		input.IndexName = r.takenAtIndex
+		if keyCondition != "" {
+			names["#ts"] = aws.String(takenAtKeyAttr)
+		}
+	}
+	if !includePending {
+		//the images created before we had statuses don't have one, they are not pending
+		input.FilterExpression = aws.String("attribute_not_exists(#status) OR #status <> :pending")
+		names["#status"] = aws.String("status") //so is status
+		values[":pending"] = &dynamodb.AttributeValue{S: aws.String(string(models.ImageStatusPending))}
+	}
+	if len(names) > 0 {
+		input.ExpressionAttributeNames = names
+	}
+
+	result, err := r.client.Query(input)
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
+
+	images := []models.Image{}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &images); err != nil {
+		return nil, "", err
+	}
+
+	//when there is no LastEvaluatedKey, there is no more images to return and the cursor is empty
+	nk, err := cursors.Encode(scope, result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return images, nk, nil
+}
+
+/*
+UpdateImage saves the title, description, alt text and tags of an image, like groupsAccess does with
+groups: only if it is still at the version we read, otherwise we return ErrImageChanged. The image we
+return is at the next version.
+
+The update goes through the Images stream like any other write, so elasticSearchSync indexes it again
+*/
+func (r *ImageDynamoDbRepository) UpdateImage(image models.Image) (models.Image, error) {
+	//an image that was never updated may not have a version at all
+	condition := "attribute_exists(groupId) AND version = :expected"
+	if image.Version == 0 {
+		condition = "attribute_exists(groupId) AND (version = :expected OR attribute_not_exists(version))"
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":expected": {N: aws.String(strconv.FormatInt(image.Version, 10))},
+		":next":     {N: aws.String(strconv.FormatInt(image.Version+1, 10))},
+		":title":    {S: aws.String(image.Title)},
+	}
+	update := "SET title = :title, version = :next"
+
+	//DynamoDB does not store empty strings in our items or empty lists, and the fields are omitempty
+	var remove []string
+	if image.Description != "" {
+		update += ", description = :description"
+		values[":description"] = &dynamodb.AttributeValue{S: aws.String(image.Description)}
+	} else {
+		remove = append(remove, "description")
+	}
+	if image.AltText != "" {
+		update += ", altText = :altText"
+		values[":altText"] = &dynamodb.AttributeValue{S: aws.String(image.AltText)}
+	} else {
+		remove = append(remove, "altText")
+	}
+	if len(image.Tags) > 0 {
+		tags, _ := dynamodbattribute.Marshal(image.Tags)
+		update += ", tags = :tags"
+		values[":tags"] = tags
+	} else {
+		remove = append(remove, "tags")
+	}
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	result, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(image.GroupId),
+			},
+			"timestamp": {
+				S: aws.String(image.Timestamp),
+			},
+		},
+		TableName:                 r.table,
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		//either the image is gone or somebody else changed it
+		if _, err := r.GetImage(image.ImageId); err != nil {
+			return models.Image{}, err
+		}
+		return models.Image{}, ErrImageChanged
+	}
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Image{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Image{}, err
+	}
+
+	return updated, nil
+}
+
+// DeleteImage deletes the row of an image. The Images stream tells groupStats and elasticSearchSync about it
+func (r *ImageDynamoDbRepository) DeleteImage(groupId string, timestamp string) error {
+	//Deleting an item that is not there is not an error in DynamoDB, so this can be retried
+	if _, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(groupId),
+			},
+			"timestamp": {
+				S: aws.String(timestamp),
+			},
+		},
+		TableName: r.table,
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// timeRangeCondition is the part of the KeyConditionExpression on the timestamp, with its values
+func timeRangeCondition(tr TimeRange) (string, map[string]*dynamodb.AttributeValue) {
+	values := map[string]*dynamodb.AttributeValue{}
+	if tr.From != "" {
+		values[":from"] = &dynamodb.AttributeValue{S: aws.String(tr.From)}
+	}
+	if tr.To != "" {
+		values[":to"] = &dynamodb.AttributeValue{S: aws.String(tr.To)}
+	}
+
+	switch {
+	case tr.From != "" && tr.To != "":
+		return " AND #ts BETWEEN :from AND :to", values
+	case tr.From != "":
+		return " AND #ts >= :from", values
+	case tr.To != "":
+		return " AND #ts <= :to", values
+	}
+	return "", values
+}
+
+/*
+SetImageStatus gives an image a new status, and sets the time it got it. The times of the later
+statuses are removed, eg a new upload removes processedAt. It returns ErrInvalidStatus when the image
+can't get that status, see canChangeStatus.
+
+It does not change the version: PATCH /images/{imageId} does not conflict with the upload pipeline
+*/
+func (r *ImageDynamoDbRepository) SetImageStatus(imageId string, status models.ImageStatus, at string) (models.Image, error) {
+	var update string
+	switch status {
+	case models.ImageStatusUploaded:
+		update = "SET #status = :status, uploadedAt = :at REMOVE #pending, processedAt, failedAt" //takes it out of the PendingIndex
+	case models.ImageStatusProcessed:
+		update = "SET #status = :status, processedAt = :at REMOVE failedAt"
+	case models.ImageStatusFailed:
+		update = "SET #status = :status, failedAt = :at REMOVE processedAt"
+	default:
+		return models.Image{}, ErrInvalidStatus //an image is only pending when it is created
+	}
+
+	//the imageId is not part of the key, we need the groupId and timestamp to update the image
+	image, err := r.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	names := map[string]*string{"#status": aws.String("status")} //status is a reserved word in DynamoDB
+	if status == models.ImageStatusUploaded {
+		names["#pending"] = aws.String(pendingAttr)
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":status": {S: aws.String(string(status))},
+		":at":     {S: aws.String(at)},
+	}
+	condition := "attribute_exists(groupId)"
+	if before := statusesBefore[status]; len(before) > 0 {
+		var placeholders []string
+		for i, s := range before {
+			p := ":before" + strconv.Itoa(i)
+			values[p] = &dynamodb.AttributeValue{S: aws.String(string(s))}
+			placeholders = append(placeholders, p)
+		}
+		condition += " AND #status IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	result, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(image.GroupId),
+			},
+			"timestamp": {
+				S: aws.String(image.Timestamp),
+			},
+		},
+		TableName:                 r.table,
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		//either the image was deleted since we read it, or it does not have a status it can change from
+		if _, err := r.GetImage(imageId); err != nil {
+			return models.Image{}, err
+		}
+		return models.Image{}, ErrInvalidStatus
+	}
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Image{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Image{}, err
+	}
+
+	return updated, nil
+}
+
+// metadataAttrs are the attributes of models.ImageMetadata
+var metadataAttrs = []string{"width", "height", "takenAt", "cameraMake", "cameraModel", "orientation", "location"}
+
+/*
+SetImageMetadata saves what was read from the file of an image, and moves the image to its takenAt in
+the TakenAtIndex. A field the file did not have is removed: it may come from an earlier file.
+
+Like SetImageStatus, it does not change the version
+*/
+func (r *ImageDynamoDbRepository) SetImageMetadata(imageId string, metadata models.ImageMetadata) (models.Image, error) {
+	image, err := r.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	//the fields are omitempty, so the ones the file did not have are not in the map
+	item, err := dynamodbattribute.MarshalMap(metadata)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	image.ImageMetadata = metadata
+	names := map[string]*string{"#takenAtKey": aws.String(takenAtKeyAttr)}
+	values := map[string]*dynamodb.AttributeValue{":takenAtKey": {S: aws.String(takenAtKey(image))}}
+	set := []string{"#takenAtKey = :takenAtKey"}
+	var remove []string
+	for i, attr := range metadataAttrs {
+		//some of them, like location, are reserved words in DynamoDB
+		name := "#m" + strconv.Itoa(i)
+		names[name] = aws.String(attr)
+		if v, ok := item[attr]; ok {
+			values[":m"+strconv.Itoa(i)] = v
+			set = append(set, name+" = :m"+strconv.Itoa(i))
+		} else {
+			remove = append(remove, name)
+		}
+	}
+	update := "SET " + strings.Join(set, ", ")
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	result, err := r.client.UpdateItem(&dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(image.GroupId),
+			},
+			"timestamp": {
+				S: aws.String(image.Timestamp),
+			},
+		},
+		TableName:                 r.table,
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(groupId)"), //the image may have been deleted since we read it
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return models.Image{}, ErrImageNotFound
+	}
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Image{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Image{}, err
+	}
+
+	return updated, nil
+}
+
+/*
+SetMultipartUpload replaces the multipart upload of an image, or removes it when upload is nil. Like
+UpdateImage, it only does so if the image still has the upload we expected, whose uploadId is expected
+or "" for none. Otherwise it returns ErrUploadChanged
+*/
+func (r *ImageDynamoDbRepository) SetMultipartUpload(imageId string, expected string, upload *models.MultipartUpload) (models.Image, error) {
+	image, err := r.GetImage(imageId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	values := map[string]*dynamodb.AttributeValue{}
+	condition := "attribute_exists(groupId) AND attribute_not_exists(multipartUpload)"
+	if expected != "" {
+		condition = "attribute_exists(groupId) AND multipartUpload.uploadId = :expected"
+		values[":expected"] = &dynamodb.AttributeValue{S: aws.String(expected)}
+	}
+
+	update := "REMOVE multipartUpload"
+	if upload != nil {
+		u, err := dynamodbattribute.Marshal(upload)
+		if err != nil {
+			return models.Image{}, err
+		}
+		update = "SET multipartUpload = :upload"
+		values[":upload"] = u
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(image.GroupId),
+			},
+			"timestamp": {
+				S: aws.String(image.Timestamp),
+			},
+		},
+		TableName:           r.table,
+		UpdateExpression:    aws.String(update),
+		ConditionExpression: aws.String(condition),
+		ReturnValues:        aws.String(dynamodb.ReturnValueAllNew),
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values //DynamoDB does not take an empty map
+	}
+
+	result, err := r.client.UpdateItem(input)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		if _, err := r.GetImage(imageId); err != nil {
+			return models.Image{}, err
+		}
+		return models.Image{}, ErrUploadChanged
+	}
+	if err != nil {
+		return models.Image{}, apperrors.FromAWS(err)
+	}
+
+	updated := models.Image{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &updated); err != nil {
+		return models.Image{}, err
+	}
+
+	return updated, nil
+}
+
+/*
+GetPendingImages returns the oldest images that are still pending and were created before the given
+timestamp. Like ExpireImage, it leaves out the ones with a multipart upload started at or after before.
+
+Those can be many, and they are the oldest, so we filter them out in the query and keep reading pages
+until we have limit images or there are no more
+*/
+func (r *ImageDynamoDbRepository) GetPendingImages(before string, limit int64) ([]models.Image, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		IndexName:              r.pendingIndex,
+		KeyConditionExpression: aws.String("#pending = :pending AND #ts < :before"),
+		FilterExpression:       aws.String("attribute_not_exists(multipartUpload) OR multipartUpload.startedAt < :before"),
+		ExpressionAttributeNames: map[string]*string{
+			"#pending": aws.String(pendingAttr),
+			"#ts":      aws.String("timestamp"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {S: aws.String(pendingValue)},
+			":before":  {S: aws.String(before)},
+		},
+		ScanIndexForward: aws.Bool(true), //the oldest first
+		Limit:            aws.Int64(limit),
+	}
+
+	images := []models.Image{}
+	for int64(len(images)) < limit {
+		result, err := r.client.Query(input)
+		if err != nil {
+			return nil, apperrors.FromAWS(err)
+		}
+
+		var page []models.Image
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		images = append(images, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+	if int64(len(images)) > limit {
+		images = images[:limit]
+	}
+
+	return images, nil
+}
+
+/*
+ExpireImage deletes an image that is still pending. If its file was uploaded since we read it, we
+keep it and return ErrImageNotPending. Like DeleteImage, the Images stream tells groupStats about it.
+
+A big file can take longer to upload in parts than a pending image lives, so an image with a multipart
+upload that was started at or after before is not expired either, see models.MultipartUpload
+*/
+func (r *ImageDynamoDbRepository) ExpireImage(groupId string, timestamp string, before string) error {
+	_, err := r.client.DeleteItem(&dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"groupId": {
+				S: aws.String(groupId),
+			},
+			"timestamp": {
+				S: aws.String(timestamp),
+			},
+		},
+		TableName:                r.table,
+		ConditionExpression:      aws.String("#status = :pending AND (attribute_not_exists(multipartUpload) OR multipartUpload.startedAt < :before)"),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("status")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pending": {S: aws.String(string(models.ImageStatusPending))},
+			":before":  {S: aws.String(before)},
+		},
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrImageNotPending
+	}
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// PublicUrl is where anybody can download an image, unless it belongs to a private group
+func (r *ImageDynamoDbRepository) PublicUrl(imageId string) string {
+	return "https://" + r.bucket + ".s3.amazonaws.com/" + imageId
+}
+
+/*
+GetUploadUrl returns a presigned POST to upload the file of an image, of that content type and size
+only. The images of a private group are tagged visibility=private on upload, so our bucket policy
+does not let anybody read them. The tag is one of the signed fields, see uploadPolicy
+*/
+func (r *ImageDynamoDbRepository) GetUploadUrl(imageId string, contentType string, size int64, private bool) (models.ImageUpload, error) {
+	creds, err := r.s3Client.Config.Credentials.Get()
+	if err != nil {
+		return models.ImageUpload{}, err
+	}
+
+	p := uploadPolicy{
+		bucket:      r.bucket,
+		region:      aws.StringValue(r.s3Client.Config.Region),
+		key:         imageId,
+		contentType: contentType,
+		size:        size,
+		private:     private,
+	}
+	return p.presign(creds, time.Now())
+}
+
+// GetPrivateUrl returns a link to an image that works for a little while, even if the bucket does not let anybody read it
+func (r *ImageDynamoDbRepository) GetPrivateUrl(imageId string) (string, error) {
+	//More on GetObject here https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.GetObjectRequest
+	req, _ := r.s3Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(imageId),
+	})
+
+	return req.Presign(privateUrlExpiry)
+}
+
+// DeleteOriginal deletes the file the client uploaded from the images bucket, and its private copy if we kept one
+func (r *ImageDynamoDbRepository) DeleteOriginal(imageId string) error {
+	if err := r.deleteObject(r.bucket, imageId); err != nil {
+		return err
+	}
+	if r.originalsBucket == "" {
+		return nil
+	}
+	return r.deleteObject(r.originalsBucket, imageId)
+}
+
+// DeleteThumbnail deletes the thumbnail resizeImage made. It stores it with a .jpeg extension
+func (r *ImageDynamoDbRepository) DeleteThumbnail(imageId string) error {
+	return r.deleteObject(r.thumbnailsBucket, imageId+".jpeg")
+}
+
+func (r *ImageDynamoDbRepository) deleteObject(bucket string, key string) error {
+	if r.s3Client == nil {
+		return nil
+	}
+
+	//Deleting an object that does not exist is not an error in S3
+	if _, err := r.s3Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
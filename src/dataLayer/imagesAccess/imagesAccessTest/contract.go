@@ -0,0 +1,605 @@
+/*
+Package imagesAccessTest is the contract every Adapter of the imagesAccess.Repository Port must pass.
+The urls and the multipart uploads need a bucket, so they are not part of it
+*/
+package imagesAccessTest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() imagesAccess.Repository) {
+	t.Run("CreateAndGetImage", func(t *testing.T) {
+		testCreateAndGetImage(t, newRepo())
+	})
+	t.Run("CreateImageTwice", func(t *testing.T) {
+		testCreateImageTwice(t, newRepo())
+	})
+	t.Run("GetImagesByGroup", func(t *testing.T) {
+		testGetImagesByGroup(t, newRepo())
+	})
+	t.Run("GetImagesByGroupPages", func(t *testing.T) {
+		testGetImagesByGroupPages(t, newRepo())
+	})
+	t.Run("GetImagesByGroupInRange", func(t *testing.T) {
+		testGetImagesByGroupInRange(t, newRepo())
+	})
+	t.Run("UpdateImage", func(t *testing.T) {
+		testUpdateImage(t, newRepo())
+	})
+	t.Run("DeleteImage", func(t *testing.T) {
+		testDeleteImage(t, newRepo())
+	})
+	t.Run("PendingImages", func(t *testing.T) {
+		testPendingImages(t, newRepo())
+	})
+	t.Run("SetImageStatus", func(t *testing.T) {
+		testSetImageStatus(t, newRepo())
+	})
+	t.Run("SetImageMetadata", func(t *testing.T) {
+		testSetImageMetadata(t, newRepo())
+	})
+	t.Run("GetImagesByTakenAt", func(t *testing.T) {
+		testGetImagesByTakenAt(t, newRepo())
+	})
+	t.Run("SetMultipartUpload", func(t *testing.T) {
+		testSetMultipartUpload(t, newRepo())
+	})
+	t.Run("GroupImages", func(t *testing.T) {
+		testGroupImages(t, newRepo())
+	})
+}
+
+func newImage(groupId string, i int) models.Image {
+	return models.Image{
+		ImageId:   fmt.Sprintf("%s-image-%d", groupId, i),
+		GroupId:   groupId,
+		Title:     fmt.Sprintf("Image %d", i),
+		Timestamp: fmt.Sprintf("2021-05-01T12:00:%02d.000000000Z", i), //the images are created one second apart
+		ImageUrl:  fmt.Sprintf("https://bucket/%s-image-%d", groupId, i),
+	}
+}
+
+func testCreateAndGetImage(t *testing.T, r imagesAccess.Repository) {
+	want := newImage("g1", 1)
+
+	got, err := r.CreateImage(want)
+	if err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateImage returned %+v, want %+v", got, want)
+	}
+
+	got, err = r.GetImage(want.ImageId)
+	if err != nil {
+		t.Fatalf("GetImage failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetImage returned %+v, want %+v", got, want)
+	}
+
+	if _, err := r.GetImage("missing"); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func testCreateImageTwice(t *testing.T, r imagesAccess.Repository) {
+	image := newImage("g1", 1)
+	if _, err := r.CreateImage(image); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	//an image with the same group and timestamp must not overwrite the first one
+	other := image
+	other.ImageId = "other"
+	if _, err := r.CreateImage(other); err != imagesAccess.ErrImageExists {
+		t.Errorf("CreateImage with the key of another image returned %v, want %v", err, imagesAccess.ErrImageExists)
+	}
+	if got, _ := r.GetImage(image.ImageId); !reflect.DeepEqual(got, image) {
+		t.Errorf("the first image was overwritten: %+v", got)
+	}
+}
+
+func testGetImagesByGroup(t *testing.T, r imagesAccess.Repository) {
+	images, nk, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false)
+	if err != nil {
+		t.Fatalf("GetImagesByGroup of an empty group failed: %v", err)
+	}
+	if len(images) != 0 || nk != "" {
+		t.Errorf("GetImagesByGroup of an empty group returned %+v, %q", images, nk)
+	}
+
+	for _, i := range []int{1, 0, 2} {
+		if _, err := r.CreateImage(newImage("g1", i)); err != nil {
+			t.Fatalf("CreateImage(%d) failed: %v", i, err)
+		}
+	}
+	if _, err := r.CreateImage(newImage("g2", 0)); err != nil {
+		t.Fatalf("CreateImage of another group failed: %v", err)
+	}
+
+	images, _, err = r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false)
+	if err != nil {
+		t.Fatalf("GetImagesByGroup failed: %v", err)
+	}
+
+	//the latest first
+	want := []models.Image{newImage("g1", 2), newImage("g1", 1), newImage("g1", 0)}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("GetImagesByGroup returned %+v, want %+v", images, want)
+	}
+}
+
+// getAllImages follows the nextKey until the last page
+func getAllImages(t *testing.T, r imagesAccess.Repository, tr imagesAccess.TimeRange, order groupsAccess.SortOrder, by imagesAccess.SortBy) []models.Image {
+	t.Helper()
+
+	var all []models.Image
+	nextKey := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("GetImagesByGroup never returned an empty nextKey")
+		}
+
+		images, nk, err := r.GetImagesByGroup("g1", tr, 2, nextKey, order, by, false)
+		if err != nil {
+			t.Fatalf("GetImagesByGroup failed: %v", err)
+		}
+		if len(images) > 2 {
+			t.Fatalf("GetImagesByGroup returned %d images, more than the limit", len(images))
+		}
+		all = append(all, images...)
+
+		if nk == "" {
+			return all
+		}
+		nextKey = nk
+	}
+}
+
+func testGetImagesByGroupPages(t *testing.T, r imagesAccess.Repository) {
+	for i := 0; i < 5; i++ {
+		if _, err := r.CreateImage(newImage("g1", i)); err != nil {
+			t.Fatalf("CreateImage(%d) failed: %v", i, err)
+		}
+	}
+
+	newest := getAllImages(t, r, imagesAccess.TimeRange{}, groupsAccess.NewestFirst, imagesAccess.ByCreatedAt)
+	want := []models.Image{newImage("g1", 4), newImage("g1", 3), newImage("g1", 2), newImage("g1", 1), newImage("g1", 0)}
+	if !reflect.DeepEqual(newest, want) {
+		t.Errorf("the pages of the newest images are %+v, want %+v", newest, want)
+	}
+
+	oldest := getAllImages(t, r, imagesAccess.TimeRange{}, groupsAccess.OldestFirst, imagesAccess.ByCreatedAt)
+	want = []models.Image{newImage("g1", 0), newImage("g1", 1), newImage("g1", 2), newImage("g1", 3), newImage("g1", 4)}
+	if !reflect.DeepEqual(oldest, want) {
+		t.Errorf("the pages of the oldest images are %+v, want %+v", oldest, want)
+	}
+
+	//a cursor only works for the listing it was made for
+	_, nk, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 2, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false)
+	if err != nil || nk == "" {
+		t.Fatalf("GetImagesByGroup returned %q, %v, want a nextKey", nk, err)
+	}
+	if _, _, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 2, nk, groupsAccess.OldestFirst, imagesAccess.ByCreatedAt, false); err == nil {
+		t.Error("GetImagesByGroup accepted a nextKey made for another order")
+	}
+	if _, _, err := r.GetImagesByGroup("g2", imagesAccess.TimeRange{}, 2, nk, groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); err == nil {
+		t.Error("GetImagesByGroup accepted a nextKey made for another group")
+	}
+}
+
+func testGetImagesByGroupInRange(t *testing.T, r imagesAccess.Repository) {
+	for i := 0; i < 5; i++ {
+		if _, err := r.CreateImage(newImage("g1", i)); err != nil {
+			t.Fatalf("CreateImage(%d) failed: %v", i, err)
+		}
+	}
+
+	from, to := newImage("g1", 1).Timestamp, newImage("g1", 3).Timestamp
+	for _, c := range []struct {
+		tr   imagesAccess.TimeRange
+		want []models.Image
+	}{
+		//both bounds are included
+		{imagesAccess.TimeRange{From: from, To: to}, []models.Image{newImage("g1", 3), newImage("g1", 2), newImage("g1", 1)}},
+		{imagesAccess.TimeRange{From: to}, []models.Image{newImage("g1", 4), newImage("g1", 3)}},
+		{imagesAccess.TimeRange{To: from}, []models.Image{newImage("g1", 1), newImage("g1", 0)}},
+		{imagesAccess.TimeRange{From: "2021-06-01T00:00:00.000000000Z"}, nil},
+	} {
+		if got := getAllImages(t, r, c.tr, groupsAccess.NewestFirst, imagesAccess.ByCreatedAt); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("the images in %+v are %+v, want %+v", c.tr, got, c.want)
+		}
+	}
+}
+
+func testDeleteImage(t *testing.T, r imagesAccess.Repository) {
+	image := newImage("g1", 1)
+	for _, i := range []models.Image{image, newImage("g1", 2)} {
+		if _, err := r.CreateImage(i); err != nil {
+			t.Fatalf("CreateImage failed: %v", err)
+		}
+	}
+
+	if err := r.DeleteImage(image.GroupId, image.Timestamp); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, err := r.GetImage(image.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of a deleted image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+	if images, _, _ := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); len(images) != 1 || images[0].ImageId != newImage("g1", 2).ImageId {
+		t.Errorf("after DeleteImage the group has %+v, want only the other image", images)
+	}
+
+	//a cleanup that is retried deletes the row again
+	if err := r.DeleteImage(image.GroupId, image.Timestamp); err != nil {
+		t.Errorf("DeleteImage of an image that is not there returned %v", err)
+	}
+}
+
+func testUpdateImage(t *testing.T, r imagesAccess.Repository) {
+	image := newImage("g1", 1)
+	if _, err := r.CreateImage(image); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	update := image
+	update.Title = "A cat"
+	update.Description = "Our cat on the sofa"
+	update.AltText = "A grey cat asleep on a red sofa"
+	update.Tags = []string{"cats", "sofa"}
+	update.ImageUrl = "https://elsewhere/" //an update only changes the metadata
+
+	want := image
+	want.Title, want.Description, want.AltText, want.Tags = update.Title, update.Description, update.AltText, update.Tags
+	want.Version = 1
+
+	got, err := r.UpdateImage(update)
+	if err != nil {
+		t.Fatalf("UpdateImage failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateImage returned %+v, want %+v", got, want)
+	}
+	if got, _ := r.GetImage(image.ImageId); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetImage after UpdateImage returned %+v, want %+v", got, want)
+	}
+
+	//somebody else updated it since we read version 0
+	if _, err := r.UpdateImage(update); err != imagesAccess.ErrImageChanged {
+		t.Errorf("UpdateImage of an old version returned %v, want %v", err, imagesAccess.ErrImageChanged)
+	}
+
+	//the optional fields can be removed
+	removed := want
+	removed.Description, removed.AltText, removed.Tags = "", "", nil
+	got, err = r.UpdateImage(removed)
+	if err != nil {
+		t.Fatalf("UpdateImage that removes the optional fields failed: %v", err)
+	}
+	removed.Version = 2
+	if !reflect.DeepEqual(got, removed) {
+		t.Errorf("UpdateImage returned %+v, want %+v", got, removed)
+	}
+
+	missing := newImage("g1", 2)
+	if _, err := r.UpdateImage(missing); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("UpdateImage of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func newPendingImage(groupId string, i int) models.Image {
+	image := newImage(groupId, i)
+	image.Status = models.ImageStatusPending
+	return image
+}
+
+func testPendingImages(t *testing.T, r imagesAccess.Repository) {
+	//an image created before we had statuses, and three pending ones
+	for _, image := range []models.Image{newImage("g1", 0), newPendingImage("g1", 1), newPendingImage("g1", 2), newPendingImage("g2", 3)} {
+		if _, err := r.CreateImage(image); err != nil {
+			t.Fatalf("CreateImage(%s) failed: %v", image.ImageId, err)
+		}
+	}
+
+	images, _, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false)
+	if err != nil {
+		t.Fatalf("GetImagesByGroup failed: %v", err)
+	}
+	if len(images) != 1 || images[0].ImageId != newImage("g1", 0).ImageId {
+		t.Errorf("GetImagesByGroup without the pending images returned %+v", images)
+	}
+	if images, _, _ := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 10, "", groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, true); len(images) != 3 {
+		t.Errorf("GetImagesByGroup with the pending images returned %d images, want 3", len(images))
+	}
+
+	//the oldest first, and only the ones created before the timestamp
+	pending, err := r.GetPendingImages(newImage("g1", 3).Timestamp, 10)
+	if err != nil {
+		t.Fatalf("GetPendingImages failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ImageId != newImage("g1", 1).ImageId || pending[1].ImageId != newImage("g1", 2).ImageId {
+		t.Errorf("GetPendingImages returned %+v", pending)
+	}
+	if pending, _ := r.GetPendingImages(newImage("g1", 9).Timestamp, 1); len(pending) != 1 {
+		t.Errorf("GetPendingImages with a limit of 1 returned %d images", len(pending))
+	}
+
+	//an image that was uploaded is not pending anymore, so it does not expire
+	if _, err := r.SetImageStatus(newImage("g1", 2).ImageId, models.ImageStatusUploaded, "2021-05-01T13:00:00.000000000Z"); err != nil {
+		t.Fatalf("SetImageStatus failed: %v", err)
+	}
+	if pending, _ := r.GetPendingImages(newImage("g1", 9).Timestamp, 10); len(pending) != 2 {
+		t.Errorf("GetPendingImages after an upload returned %+v", pending)
+	}
+	before := newImage("g1", 9).Timestamp
+	for _, image := range []models.Image{newImage("g1", 0), newImage("g1", 2)} {
+		if err := r.ExpireImage(image.GroupId, image.Timestamp, before); err != imagesAccess.ErrImageNotPending {
+			t.Errorf("ExpireImage(%s) returned %v, want %v", image.ImageId, err, imagesAccess.ErrImageNotPending)
+		}
+	}
+
+	//an image that is being uploaded in parts only expires when its upload is old enough
+	uploading := newPendingImage("g2", 3)
+	if _, err := r.SetMultipartUpload(uploading.ImageId, "", &models.MultipartUpload{UploadId: "u1", StartedAt: newImage("g2", 5).Timestamp}); err != nil {
+		t.Fatalf("SetMultipartUpload failed: %v", err)
+	}
+	if pending, _ := r.GetPendingImages(newImage("g2", 4).Timestamp, 10); len(pending) != 1 || pending[0].ImageId != newPendingImage("g1", 1).ImageId {
+		t.Errorf("GetPendingImages returned %+v, want no image that is being uploaded", pending)
+	}
+	if pending, _ := r.GetPendingImages(before, 10); len(pending) != 2 {
+		t.Errorf("GetPendingImages returned %+v, want the image whose upload is too old", pending)
+	}
+	if err := r.ExpireImage(uploading.GroupId, uploading.Timestamp, newImage("g2", 4).Timestamp); err != imagesAccess.ErrImageNotPending {
+		t.Errorf("ExpireImage of an image that is being uploaded returned %v, want %v", err, imagesAccess.ErrImageNotPending)
+	}
+	if err := r.ExpireImage(uploading.GroupId, uploading.Timestamp, before); err != nil {
+		t.Errorf("ExpireImage of an image whose upload is too old returned %v, want nil", err)
+	}
+
+	expired := newPendingImage("g1", 1)
+	if err := r.ExpireImage(expired.GroupId, expired.Timestamp, before); err != nil {
+		t.Fatalf("ExpireImage failed: %v", err)
+	}
+	if _, err := r.GetImage(expired.ImageId); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("GetImage of an expired image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func testSetImageStatus(t *testing.T, r imagesAccess.Repository) {
+	image := newPendingImage("g1", 1)
+	if _, err := r.CreateImage(image); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	//a pending image has to be uploaded first
+	if _, err := r.SetImageStatus(image.ImageId, models.ImageStatusProcessed, "2021-05-01T13:00:00.000000000Z"); err != imagesAccess.ErrInvalidStatus {
+		t.Errorf("SetImageStatus of a pending image to processed returned %v, want %v", err, imagesAccess.ErrInvalidStatus)
+	}
+	if _, err := r.SetImageStatus(image.ImageId, models.ImageStatusPending, "2021-05-01T13:00:00.000000000Z"); err != imagesAccess.ErrInvalidStatus {
+		t.Errorf("SetImageStatus to pending returned %v, want %v", err, imagesAccess.ErrInvalidStatus)
+	}
+
+	steps := []struct {
+		status models.ImageStatus
+		at     string
+		want   func(models.Image) bool
+	}{
+		{models.ImageStatusUploaded, "2021-05-01T13:00:00.000000000Z", func(i models.Image) bool { return i.UploadedAt == "2021-05-01T13:00:00.000000000Z" }},
+		{models.ImageStatusFailed, "2021-05-01T13:01:00.000000000Z", func(i models.Image) bool { return i.FailedAt == "2021-05-01T13:01:00.000000000Z" }},
+		{models.ImageStatusProcessed, "2021-05-01T13:02:00.000000000Z", func(i models.Image) bool {
+			return i.ProcessedAt == "2021-05-01T13:02:00.000000000Z" && i.FailedAt == ""
+		}},
+		//a new file starts over
+		{models.ImageStatusUploaded, "2021-05-01T14:00:00.000000000Z", func(i models.Image) bool {
+			return i.UploadedAt == "2021-05-01T14:00:00.000000000Z" && i.ProcessedAt == ""
+		}},
+	}
+	for _, s := range steps {
+		got, err := r.SetImageStatus(image.ImageId, s.status, s.at)
+		if err != nil {
+			t.Fatalf("SetImageStatus(%s) failed: %v", s.status, err)
+		}
+		if got.Status != s.status || !s.want(got) || got.Title != image.Title || got.Version != 0 {
+			t.Errorf("SetImageStatus(%s) returned %+v", s.status, got)
+		}
+	}
+
+	if got, _ := r.GetImage(image.ImageId); got.Status != models.ImageStatusUploaded {
+		t.Errorf("GetImage after SetImageStatus returned %+v", got)
+	}
+	if _, err := r.SetImageStatus("missing", models.ImageStatusUploaded, "2021-05-01T13:00:00.000000000Z"); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("SetImageStatus of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func testSetMultipartUpload(t *testing.T, r imagesAccess.Repository) {
+	image := newPendingImage("g1", 1)
+	if _, err := r.CreateImage(image); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	first := &models.MultipartUpload{UploadId: "u1", PartSize: 5 << 20, PartCount: 3, StartedAt: "2021-05-01T13:00:00.000000000Z"}
+	got, err := r.SetMultipartUpload(image.ImageId, "", first)
+	if err != nil {
+		t.Fatalf("SetMultipartUpload failed: %v", err)
+	}
+	if got.Upload == nil || *got.Upload != *first || got.Title != image.Title {
+		t.Errorf("SetMultipartUpload returned %+v", got)
+	}
+
+	//only the client that knows the upload in progress can replace it
+	second := &models.MultipartUpload{UploadId: "u2", PartSize: 5 << 20, PartCount: 3, StartedAt: "2021-05-01T14:00:00.000000000Z"}
+	for _, expected := range []string{"", "u3"} {
+		if _, err := r.SetMultipartUpload(image.ImageId, expected, second); err != imagesAccess.ErrUploadChanged {
+			t.Errorf("SetMultipartUpload expecting %q returned %v, want %v", expected, err, imagesAccess.ErrUploadChanged)
+		}
+	}
+	if _, err := r.SetMultipartUpload(image.ImageId, "u1", second); err != nil {
+		t.Fatalf("SetMultipartUpload of the next upload failed: %v", err)
+	}
+
+	if got, err = r.SetMultipartUpload(image.ImageId, "u2", nil); err != nil || got.Upload != nil {
+		t.Errorf("SetMultipartUpload to remove the upload returned %+v, %v", got, err)
+	}
+	if got, _ := r.GetImage(image.ImageId); got.Upload != nil {
+		t.Errorf("GetImage after the upload was removed returned %+v", got.Upload)
+	}
+	if _, err := r.SetMultipartUpload("missing", "", first); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("SetMultipartUpload of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func testSetImageMetadata(t *testing.T, r imagesAccess.Repository) {
+	image := newImage("g1", 1)
+	if _, err := r.CreateImage(image); err != nil {
+		t.Fatalf("CreateImage failed: %v", err)
+	}
+
+	metadata := models.ImageMetadata{
+		Width:       6000,
+		Height:      4000,
+		TakenAt:     "2020-07-14T08:00:00.000000000Z",
+		CameraMake:  "Canon",
+		CameraModel: "Canon EOS R5",
+		Orientation: 6,
+		Location:    &models.GeoPoint{Lat: 48.858222, Lon: 2.2945},
+	}
+	updated, err := r.SetImageMetadata(image.ImageId, metadata)
+	if err != nil {
+		t.Fatalf("SetImageMetadata failed: %v", err)
+	}
+	if !reflect.DeepEqual(updated.ImageMetadata, metadata) || updated.Version != image.Version || updated.Title != image.Title {
+		t.Errorf("SetImageMetadata returned %+v", updated)
+	}
+	if got, _ := r.GetImage(image.ImageId); !reflect.DeepEqual(got, updated) {
+		t.Errorf("GetImage returned %+v, want %+v", got, updated)
+	}
+
+	//a new file without a position or a time does not keep the ones of the old file
+	metadata = models.ImageMetadata{Width: 800, Height: 600}
+	if updated, err := r.SetImageMetadata(image.ImageId, metadata); err != nil || !reflect.DeepEqual(updated.ImageMetadata, metadata) {
+		t.Errorf("SetImageMetadata of a file without EXIF returned %+v, %v", updated, err)
+	}
+
+	if _, err := r.SetImageMetadata("nope", metadata); err != imagesAccess.ErrImageNotFound {
+		t.Errorf("SetImageMetadata of a missing image returned %v, want %v", err, imagesAccess.ErrImageNotFound)
+	}
+}
+
+func testGetImagesByTakenAt(t *testing.T, r imagesAccess.Repository) {
+	takenAt := map[int]string{
+		0: "2019-01-01T00:00:00.000000000Z",
+		2: "2020-01-01T00:00:00.000000000Z",
+		3: "", //we don't know when it was taken, it stays where it was created
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := r.CreateImage(newImage("g1", i)); err != nil {
+			t.Fatalf("CreateImage(%d) failed: %v", i, err)
+		}
+		if at, ok := takenAt[i]; ok {
+			if _, err := r.SetImageMetadata(newImage("g1", i).ImageId, models.ImageMetadata{TakenAt: at}); err != nil {
+				t.Fatalf("SetImageMetadata(%d) failed: %v", i, err)
+			}
+		}
+	}
+
+	ids := func(images []models.Image) []string {
+		var ids []string
+		for _, i := range images {
+			ids = append(ids, i.ImageId)
+		}
+		return ids
+	}
+
+	got := ids(getAllImages(t, r, imagesAccess.TimeRange{}, groupsAccess.OldestFirst, imagesAccess.ByTakenAt))
+	want := []string{"g1-image-0", "g1-image-2", "g1-image-1", "g1-image-3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the images by the time they were taken are %v, want %v", got, want)
+	}
+
+	//the range is on the time they were taken too
+	got = ids(getAllImages(t, r, imagesAccess.TimeRange{To: "2020-12-31T23:59:59.999999999Z"}, groupsAccess.NewestFirst, imagesAccess.ByTakenAt))
+	want = []string{"g1-image-2", "g1-image-0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the images taken before 2021 are %v, want %v", got, want)
+	}
+
+	//a cursor sorted by one time does not work for the other
+	_, nk, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 2, "", groupsAccess.NewestFirst, imagesAccess.ByTakenAt, false)
+	if err != nil || nk == "" {
+		t.Fatalf("GetImagesByGroup returned %q, %v, want a nextKey", nk, err)
+	}
+	if _, _, err := r.GetImagesByGroup("g1", imagesAccess.TimeRange{}, 2, nk, groupsAccess.NewestFirst, imagesAccess.ByCreatedAt, false); err == nil {
+		t.Error("GetImagesByGroup accepted a nextKey made for another sort")
+	}
+}
+
+func newImageRecord(groupId string, i int) imagesAccess.ImageRecord {
+	return imagesAccess.ImageRecord{
+		"groupId":   groupId,
+		"imageId":   fmt.Sprintf("%s-image-%d", groupId, i),
+		"timestamp": fmt.Sprintf("2021-05-01T12:00:%02d.000000000Z", i),
+		"title":     fmt.Sprintf("Image %d", i),
+		"unknown":   "kept", //an attribute we don't know about
+	}
+}
+
+func testGroupImages(t *testing.T, r imagesAccess.Repository) {
+	for _, i := range []int{2, 0, 1} {
+		if err := r.ImportImage(newImageRecord("group-00", i)); err != nil {
+			t.Fatalf("ImportImage(%d) failed: %v", i, err)
+		}
+	}
+	if err := r.ImportImage(newImageRecord("group-01", 0)); err != nil {
+		t.Fatalf("ImportImage of another group failed: %v", err)
+	}
+
+	var got []imagesAccess.ImageRecord
+	nextKey := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("GetGroupImages did not stop after %d pages", pages)
+		}
+
+		images, nk, err := r.GetGroupImages("group-00", 2, nextKey)
+		if err != nil {
+			t.Fatalf("GetGroupImages failed: %v", err)
+		}
+		got = append(got, images...)
+
+		if nk == "" {
+			break
+		}
+		nextKey = nk
+	}
+
+	want := []imagesAccess.ImageRecord{newImageRecord("group-00", 0), newImageRecord("group-00", 1), newImageRecord("group-00", 2)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetGroupImages returned %+v, want %+v", got, want)
+	}
+
+	//an imported image is an image like the others
+	if image, err := r.GetImage("group-00-image-1"); err != nil || image.Title != "Image 1" {
+		t.Errorf("GetImage of an imported image returned %+v, %v", image, err)
+	}
+
+	//importing again must not overwrite the image
+	changed := newImageRecord("group-00", 0)
+	changed["title"] = "Changed"
+	if err := r.ImportImage(changed); err != imagesAccess.ErrImageExists {
+		t.Errorf("ImportImage of an existing image returned %v, want %v", err, imagesAccess.ErrImageExists)
+	}
+
+	if err := r.ImportImage(imagesAccess.ImageRecord{"imageId": "no-key"}); err == nil {
+		t.Error("ImportImage of an image without a key did not fail")
+	}
+}
@@ -0,0 +1,87 @@
+package imagesAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess/imagesAccessTest"
+)
+
+// Like the groupsAccess tests, this only runs when DYNAMODB_ENDPOINT points at a DynamoDB, eg DynamoDB Local
+func TestImageDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	imagesAccessTest.TestRepository(t, func() imagesAccess.Repository {
+		n++
+		table := fmt.Sprintf("Images-test-%d-%d", time.Now().UnixNano(), n)
+		createImagesTable(t, client, table)
+
+		return imagesAccess.NewDynamoDbRepoWithClient(client, table, "ImageIdIndex", "PendingIndex", "TakenAtIndex")
+	})
+}
+
+// createImagesTable creates a table with the same key schema and indexes as ImagesDynamoDBTable in serverless.yml
+func createImagesTable(t *testing.T, client *dynamodb.DynamoDB, table string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("groupId"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("timestamp"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("imageId"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("pending"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("takenAtKey"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("groupId"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("timestamp"), KeyType: aws.String("RANGE")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("ImageIdIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("imageId"), KeyType: aws.String("HASH")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+			{
+				IndexName: aws.String("PendingIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("pending"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("timestamp"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+			{
+				IndexName: aws.String("TakenAtIndex"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("groupId"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("takenAtKey"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
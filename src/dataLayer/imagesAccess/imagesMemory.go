@@ -0,0 +1,618 @@
+package imagesAccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+ImageMemoryRepository is the in-memory Adapter of our Repository Port. The images are keyed by id.
+
+There is no bucket behind it, so its urls can't be downloaded from or uploaded to. They are only
+there so the handlers have something to return when we run them locally. The originals our pipeline
+rewrites are kept, so tests can check them, see Original and PrivateCopy
+*/
+type ImageMemoryRepository struct {
+	mu     sync.RWMutex
+	images map[string]models.Image
+	// The multipart uploads that were started and not completed or aborted, by uploadId
+	uploads     map[string]*memoryUpload
+	lastUploadN int
+	// The files written by ReplaceOriginal and CopyOriginal, by imageId
+	originals     map[string][]byte
+	privateCopies map[string][]byte
+	hidden        map[string]bool // the originals HideOriginal tagged private
+	// The images we imported, with the attributes models.Image does not have, by imageId. See ImportImage
+	records map[string]ImageRecord
+}
+
+// memoryUpload is a multipart upload, with the parts that were uploaded with UploadPart
+type memoryUpload struct {
+	imageId string
+	parts   map[int64]models.UploadedPart
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &ImageMemoryRepository{
+		images:        make(map[string]models.Image),
+		uploads:       make(map[string]*memoryUpload),
+		originals:     make(map[string][]byte),
+		privateCopies: make(map[string][]byte),
+		hidden:        make(map[string]bool),
+		records:       make(map[string]ImageRecord),
+	}
+}
+
+// CreateImage stores a new image
+func (r *ImageMemoryRepository) CreateImage(image models.Image) (models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//like the Images table, the key of an image is its group and timestamp
+	for _, i := range r.images {
+		if i.GroupId == image.GroupId && i.Timestamp == image.Timestamp {
+			return models.Image{}, ErrImageExists
+		}
+	}
+	r.images[image.ImageId] = image
+
+	return image, nil
+}
+
+// GetImage returns the image with the given id
+func (r *ImageMemoryRepository) GetImage(imageId string) (models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	image, ok := r.images[imageId]
+	if !ok {
+		return models.Image{}, ErrImageNotFound
+	}
+
+	return image, nil
+}
+
+/*
+GetImagesByGroup gets one page of the images of a group that were created, or taken, in the time range. It
+paginates like the DynamoDB Adapter: the nextKey is a signed cursor made from the key of the last image
+*/
+func (r *ImageMemoryRepository) GetImagesByGroup(groupId string, tr TimeRange, limit int64, nextKey string, order groupsAccess.SortOrder, by SortBy, includePending bool) ([]models.Image, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope := groupImagesScope(groupId, tr, order, by)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	//the time we sort by, like the range key of the table or of the TakenAtIndex
+	sortKey := func(i models.Image) string { return i.Timestamp }
+	if by == ByTakenAt {
+		sortKey = takenAtKey
+	}
+
+	var matches []models.Image
+	for _, i := range r.images {
+		if i.GroupId == groupId && (tr.From == "" || sortKey(i) >= tr.From) && (tr.To == "" || sortKey(i) <= tr.To) {
+			matches = append(matches, i)
+		}
+	}
+	//the timestamp is the range key of the Images table, so it is unique in a group. Many images can be taken at the same time
+	less := func(a, b models.Image) bool {
+		if sortKey(a) != sortKey(b) {
+			return sortKey(a) > sortKey(b)
+		}
+		return a.Timestamp > b.Timestamp
+	}
+	if order == groupsAccess.OldestFirst {
+		newestFirst := less
+		less = func(a, b models.Image) bool { return newestFirst(b, a) }
+	}
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+
+	start := 0
+	if ts, ok := startKey["timestamp"]; ok {
+		//just like ExclusiveStartKey, we start right after the key we were given
+		after := models.Image{Timestamp: aws.StringValue(ts.S)}
+		if k, ok := startKey[takenAtKeyAttr]; ok {
+			after.TakenAt = aws.StringValue(k.S)
+		}
+		start = sort.Search(len(matches), func(i int) bool { return less(after, matches[i]) })
+	}
+
+	//like the FilterExpression of the DynamoDB Adapter, we filter the pending images after the limit
+	page := matches[start:]
+	if int64(len(page)) > limit {
+		page = page[:limit]
+	}
+	images := []models.Image{}
+	for _, i := range page {
+		if includePending || i.GetStatus() != models.ImageStatusPending {
+			images = append(images, i)
+		}
+	}
+
+	//DynamoDB returns a LastEvaluatedKey whenever the Limit was reached, so do we
+	if len(page) == 0 || int64(len(page)) < limit {
+		return images, "", nil
+	}
+
+	last := page[len(page)-1]
+	key := map[string]string{"groupId": last.GroupId, "timestamp": last.Timestamp}
+	if by == ByTakenAt {
+		key[takenAtKeyAttr] = takenAtKey(last) //like the LastEvaluatedKey of an index, it has the keys of the table and of the index
+	}
+	nk, err := cursors.Encode(scope, cursor.StringKey(key))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return images, nk, nil
+}
+
+// UpdateImage saves an image if it is still at the version we read
+func (r *ImageMemoryRepository) UpdateImage(image models.Image) (models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.images[image.ImageId]
+	if !ok || existing.GroupId != image.GroupId || existing.Timestamp != image.Timestamp {
+		return models.Image{}, ErrImageNotFound
+	}
+	if existing.Version != image.Version {
+		return models.Image{}, ErrImageChanged
+	}
+
+	//only what an update can change, the rest stays as it was stored
+	existing.Title = image.Title
+	existing.Description = image.Description
+	existing.AltText = image.AltText
+	existing.Tags = append([]string(nil), image.Tags...)
+	if len(existing.Tags) == 0 {
+		existing.Tags = nil
+	}
+	existing.Version++
+	r.images[image.ImageId] = existing
+
+	return existing, nil
+}
+
+// DeleteImage deletes the image with the given key, if it is there
+func (r *ImageMemoryRepository) DeleteImage(groupId string, timestamp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, i := range r.images {
+		if i.GroupId == groupId && i.Timestamp == timestamp {
+			delete(r.images, id)
+			delete(r.records, id)
+		}
+	}
+
+	return nil
+}
+
+// SetImageStatus gives an image a new status like the DynamoDB Adapter does, without changing its version
+func (r *ImageMemoryRepository) SetImageStatus(imageId string, status models.ImageStatus, at string) (models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[imageId]
+	if !ok {
+		return models.Image{}, ErrImageNotFound
+	}
+	if status == models.ImageStatusPending || !canChangeStatus(image.Status, status) {
+		return models.Image{}, ErrInvalidStatus
+	}
+
+	image.Status = status
+	switch status {
+	case models.ImageStatusUploaded:
+		image.UploadedAt, image.ProcessedAt, image.FailedAt = at, "", ""
+	case models.ImageStatusProcessed:
+		image.ProcessedAt, image.FailedAt = at, ""
+	case models.ImageStatusFailed:
+		image.FailedAt, image.ProcessedAt = at, ""
+	}
+	r.images[imageId] = image
+
+	return image, nil
+}
+
+// SetImageMetadata saves what was read from the file of an image, without changing its version
+func (r *ImageMemoryRepository) SetImageMetadata(imageId string, metadata models.ImageMetadata) (models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[imageId]
+	if !ok {
+		return models.Image{}, ErrImageNotFound
+	}
+	image.ImageMetadata = metadata
+	r.images[imageId] = image
+
+	return image, nil
+}
+
+// SetMultipartUpload replaces the multipart upload of an image if it still has the one we expected, like the DynamoDB Adapter
+func (r *ImageMemoryRepository) SetMultipartUpload(imageId string, expected string, upload *models.MultipartUpload) (models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[imageId]
+	if !ok {
+		return models.Image{}, ErrImageNotFound
+	}
+
+	current := ""
+	if image.Upload != nil {
+		current = image.Upload.UploadId
+	}
+	if current != expected {
+		return models.Image{}, ErrUploadChanged
+	}
+
+	image.Upload = nil
+	if upload != nil {
+		u := *upload //so the caller can't change what we store
+		image.Upload = &u
+	}
+	r.images[imageId] = image
+
+	return image, nil
+}
+
+// GetPendingImages returns the oldest images that are still pending and were created before the given timestamp, without the ones being uploaded in parts since then
+func (r *ImageMemoryRepository) GetPendingImages(before string, limit int64) ([]models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := []models.Image{}
+	for _, i := range r.images {
+		if i.Status == models.ImageStatusPending && i.Timestamp < before && (i.Upload == nil || i.Upload.StartedAt < before) {
+			images = append(images, i)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Timestamp < images[j].Timestamp })
+	if int64(len(images)) > limit {
+		images = images[:limit]
+	}
+
+	return images, nil
+}
+
+// ExpireImage deletes an image if it is still pending, and not being uploaded in parts since before
+func (r *ImageMemoryRepository) ExpireImage(groupId string, timestamp string, before string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, i := range r.images {
+		if i.GroupId == groupId && i.Timestamp == timestamp && i.Status == models.ImageStatusPending && (i.Upload == nil || i.Upload.StartedAt < before) {
+			delete(r.images, id)
+			return nil
+		}
+	}
+
+	return ErrImageNotPending
+}
+
+// PublicUrl is the url of an image in our fake bucket
+func (r *ImageMemoryRepository) PublicUrl(imageId string) string {
+	return "memory://images/" + imageId
+}
+
+// GetUploadUrl returns the same fields as the DynamoDB Adapter, except for the signature, so the handlers behave the same way
+func (r *ImageMemoryRepository) GetUploadUrl(imageId string, contentType string, size int64, private bool) (models.ImageUpload, error) {
+	upload := models.ImageUpload{
+		Url:    r.PublicUrl(imageId) + "?upload",
+		Fields: map[string]string{"key": imageId, "Content-Type": contentType},
+	}
+	if private {
+		upload.Fields["tagging"] = privateTagging
+	}
+
+	return upload, nil
+}
+
+// GetPrivateUrl returns a url that is different from the public one, like a signed url would be
+func (r *ImageMemoryRepository) GetPrivateUrl(imageId string) (string, error) {
+	return r.PublicUrl(imageId) + "?signed", nil
+}
+
+// DeleteOriginal deletes the files ReplaceOriginal and CopyOriginal wrote. There is no bucket behind our urls
+func (r *ImageMemoryRepository) DeleteOriginal(imageId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.originals, imageId)
+	delete(r.privateCopies, imageId)
+	delete(r.hidden, imageId)
+	return nil
+}
+
+// DeleteThumbnail has nothing to delete either
+func (r *ImageMemoryRepository) DeleteThumbnail(imageId string) error {
+	return nil
+}
+
+// ReplaceOriginal keeps the file, see Original
+func (r *ImageMemoryRepository) ReplaceOriginal(imageId string, file []byte, contentType string, private bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.originals[imageId] = file
+	return nil
+}
+
+// CopyOriginal keeps the file, see PrivateCopy
+func (r *ImageMemoryRepository) CopyOriginal(imageId string, file []byte, contentType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.privateCopies[imageId] = file
+	return nil
+}
+
+// HideOriginal remembers the original was tagged private, see Hidden
+func (r *ImageMemoryRepository) HideOriginal(imageId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hidden[imageId] = true
+	return nil
+}
+
+// Hidden tells if HideOriginal was called for the original of an image
+func (r *ImageMemoryRepository) Hidden(imageId string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.hidden[imageId]
+}
+
+// Original returns the last file ReplaceOriginal wrote for an image, if any
+func (r *ImageMemoryRepository) Original(imageId string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.originals[imageId]
+	return file, ok
+}
+
+// PrivateCopy returns the last file CopyOriginal wrote for an image, if any
+func (r *ImageMemoryRepository) PrivateCopy(imageId string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.privateCopies[imageId]
+	return file, ok
+}
+
+// StartMultipartUpload starts a multipart upload. Its parts are added with UploadPart, since nobody can PUT to our urls
+func (r *ImageMemoryRepository) StartMultipartUpload(imageId string, contentType string, private bool) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastUploadN++
+	uploadId := fmt.Sprintf("upload-%d", r.lastUploadN)
+	r.uploads[uploadId] = &memoryUpload{imageId, make(map[int64]models.UploadedPart)}
+
+	return uploadId, nil
+}
+
+// GetUploadPartUrl returns a url that is different for every part, like a signed url would be
+func (r *ImageMemoryRepository) GetUploadPartUrl(imageId string, uploadId string, partNumber int64) (string, error) {
+	return fmt.Sprintf("%s?uploadId=%s&partNumber=%d", r.PublicUrl(imageId), uploadId, partNumber), nil
+}
+
+/*
+UploadPart stands in for the PUT of a part by a client, in our tests and local runs. Like S3, a part
+that is uploaded again replaces the one that was there
+*/
+func (r *ImageMemoryRepository) UploadPart(uploadId string, partNumber int64, size int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.uploads[uploadId]
+	if !ok {
+		return ErrUploadNotFound
+	}
+	u.parts[partNumber] = models.UploadedPart{PartNumber: partNumber, Size: size, ETag: fmt.Sprintf(`"%s-%d-%d"`, uploadId, partNumber, size)}
+
+	return nil
+}
+
+// GetUploadedParts returns the parts of a multipart upload that were uploaded, by part number
+func (r *ImageMemoryRepository) GetUploadedParts(imageId string, uploadId string) ([]models.UploadedPart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.uploads[uploadId]
+	if !ok || u.imageId != imageId {
+		return nil, ErrUploadNotFound
+	}
+
+	parts := []models.UploadedPart{}
+	for _, p := range u.parts {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	return parts, nil
+}
+
+// CompleteMultipartUpload forgets the upload. Like S3, it fails if a part is not the one that was uploaded
+func (r *ImageMemoryRepository) CompleteMultipartUpload(imageId string, uploadId string, parts []models.UploadedPart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.uploads[uploadId]
+	if !ok || u.imageId != imageId {
+		return ErrUploadNotFound
+	}
+	for _, p := range parts {
+		if u.parts[p.PartNumber].ETag != p.ETag {
+			return apperrors.Invalid(fmt.Sprintf("part %d was not uploaded", p.PartNumber))
+		}
+	}
+	delete(r.uploads, uploadId)
+
+	return nil
+}
+
+// AbortMultipartUpload forgets the upload and its parts
+func (r *ImageMemoryRepository) AbortMultipartUpload(imageId string, uploadId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.uploads[uploadId]; ok && u.imageId == imageId {
+		delete(r.uploads, uploadId)
+	}
+
+	return nil
+}
+
+// SetImagesVisibility does nothing since there is no bucket behind us
+func (r *ImageMemoryRepository) SetImagesVisibility(groupId string, v models.Visibility) error {
+	return nil
+}
+
+/*
+GetGroupImages gets the images of a group with all their attributes, the oldest first. The images we
+imported keep the attributes we don't know about, like in the Images table
+*/
+func (r *ImageMemoryRepository) GetGroupImages(groupId string, limit int64, nextKey string) ([]ImageRecord, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope := exportImagesScope(groupId)
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var matches []models.Image
+	for _, i := range r.images {
+		if i.GroupId == groupId {
+			matches = append(matches, i)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Timestamp < matches[j].Timestamp })
+
+	start := 0
+	if ts, ok := startKey["timestamp"]; ok {
+		//right after the key we were given
+		start = sort.Search(len(matches), func(i int) bool { return matches[i].Timestamp > aws.StringValue(ts.S) })
+	}
+
+	page := matches[start:]
+	if int64(len(page)) > limit {
+		page = page[:limit]
+	}
+	images := []ImageRecord{}
+	for _, i := range page {
+		rec, err := r.record(i)
+		if err != nil {
+			return nil, "", err
+		}
+		images = append(images, rec)
+	}
+
+	//like GetImagesByGroup, we return a cursor whenever the limit was reached
+	if len(page) == 0 || int64(len(page)) < limit {
+		return images, "", nil
+	}
+
+	last := page[len(page)-1]
+	nk, err := cursors.Encode(scope, cursor.StringKey(map[string]string{"groupId": last.GroupId, "timestamp": last.Timestamp}))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return images, nk, nil
+}
+
+/*
+record is the row an image would have in the Images table. An imported image that did not change since
+is the row it was imported with, otherwise we write what changed over it
+*/
+func (r *ImageMemoryRepository) record(image models.Image) (ImageRecord, error) {
+	imported, ok := r.records[image.ImageId]
+	if ok {
+		if i, err := decodeImage(imported); err == nil && reflect.DeepEqual(i, image) {
+			return copyRecord(imported), nil
+		}
+	}
+
+	b, err := json.Marshal(image)
+	if err != nil {
+		return nil, err
+	}
+	var fields ImageRecord
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	rec := copyRecord(imported)
+	for k, v := range fields {
+		rec[k] = v
+	}
+	return rec, nil
+}
+
+func copyRecord(record ImageRecord) ImageRecord {
+	rec := ImageRecord{}
+	for k, v := range record {
+		rec[k] = v
+	}
+	return rec
+}
+
+// decodeImage reads the attributes of a record we know about
+func decodeImage(record ImageRecord) (models.Image, error) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return models.Image{}, err
+	}
+	var image models.Image
+	err = json.Unmarshal(b, &image)
+	return image, err
+}
+
+// ImportImage stores an image as it is, unless there already is an image with its key
+func (r *ImageMemoryRepository) ImportImage(record ImageRecord) error {
+	groupId, timestamp, err := record.key()
+	if err != nil {
+		return err
+	}
+
+	image, err := decodeImage(record)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.images {
+		if i.GroupId == groupId && i.Timestamp == timestamp {
+			return ErrImageExists
+		}
+	}
+	r.images[image.ImageId] = image
+	r.records[image.ImageId] = copyRecord(record)
+
+	return nil
+}
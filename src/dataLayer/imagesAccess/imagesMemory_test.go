@@ -0,0 +1,12 @@
+package imagesAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess/imagesAccessTest"
+)
+
+func TestImageMemoryRepository(t *testing.T) {
+	imagesAccessTest.TestRepository(t, imagesAccess.NewMemoryRepo)
+}
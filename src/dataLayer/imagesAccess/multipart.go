@@ -0,0 +1,130 @@
+package imagesAccess
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// How long the client has to upload a part. They are big and the connection may be slow
+const partUrlExpiry = time.Hour
+
+/*
+StartMultipartUpload starts an upload of the file of an image in parts and returns its uploadId.
+Like GetUploadUrl, the files of the images of a private group are tagged visibility=private. The
+content type is set here, the parts can't change it.
+More on multipart uploads here https://docs.aws.amazon.com/AmazonS3/latest/userguide/mpuoverview.html
+*/
+func (r *ImageDynamoDbRepository) StartMultipartUpload(imageId string, contentType string, private bool) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(r.bucket),
+		Key:         aws.String(imageId),
+		ContentType: aws.String(contentType),
+	}
+	if private {
+		input.Tagging = aws.String(VisibilityTagKey + "=" + string(models.VisibilityPrivate))
+	}
+
+	result, err := r.s3Client.CreateMultipartUpload(input)
+	if err != nil {
+		return "", apperrors.FromAWS(err)
+	}
+
+	return aws.StringValue(result.UploadId), nil
+}
+
+// GetUploadPartUrl returns a presigned url to PUT one part of a multipart upload
+func (r *ImageDynamoDbRepository) GetUploadPartUrl(imageId string, uploadId string, partNumber int64) (string, error) {
+	//More on UploadPart here https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.UploadPartRequest
+	req, _ := r.s3Client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(r.bucket),
+		Key:        aws.String(imageId),
+		UploadId:   aws.String(uploadId),
+		PartNumber: aws.Int64(partNumber),
+	})
+
+	return req.Presign(partUrlExpiry)
+}
+
+/*
+GetUploadedParts returns the parts of a multipart upload that are in S3, by part number. S3 keeps
+track of them for us, so the client does not have to remember anything to resume an upload
+*/
+func (r *ImageDynamoDbRepository) GetUploadedParts(imageId string, uploadId string) ([]models.UploadedPart, error) {
+	parts := []models.UploadedPart{}
+
+	//ListParts returns up to 1000 parts per page
+	err := r.s3Client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(r.bucket),
+		Key:      aws.String(imageId),
+		UploadId: aws.String(uploadId),
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, p := range page.Parts {
+			parts = append(parts, models.UploadedPart{
+				PartNumber: aws.Int64Value(p.PartNumber),
+				Size:       aws.Int64Value(p.Size),
+				ETag:       aws.StringValue(p.ETag),
+			})
+		}
+		return true
+	})
+	if isNoSuchUpload(err) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, apperrors.FromAWS(err)
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// CompleteMultipartUpload puts the parts together into the file of the image. S3 tells the images topic about it, like any other upload
+func (r *ImageDynamoDbRepository) CompleteMultipartUpload(imageId string, uploadId string, parts []models.UploadedPart) error {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			PartNumber: aws.Int64(p.PartNumber),
+			ETag:       aws.String(p.ETag),
+		})
+	}
+
+	_, err := r.s3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(r.bucket),
+		Key:             aws.String(imageId),
+		UploadId:        aws.String(uploadId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if isNoSuchUpload(err) {
+		return ErrUploadNotFound
+	}
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// AbortMultipartUpload stops a multipart upload and deletes its parts. It is not an error if the upload is not there anymore
+func (r *ImageDynamoDbRepository) AbortMultipartUpload(imageId string, uploadId string) error {
+	_, err := r.s3Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(r.bucket),
+		Key:      aws.String(imageId),
+		UploadId: aws.String(uploadId),
+	})
+	if err != nil && !isNoSuchUpload(err) {
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+func isNoSuchUpload(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == s3.ErrCodeNoSuchUpload
+}
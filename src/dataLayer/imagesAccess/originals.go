@@ -0,0 +1,75 @@
+package imagesAccess
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+The user metadata we give the originals we rewrite. Writing one sends the images bucket an event
+like any upload, so resizeImage looks for it to skip the files it wrote itself. Clients can't set it:
+it is not one of the fields of the POST policy they upload with, see uploadPolicy
+*/
+const strippedMetadataKey = "Stripped"
+
+// IsStripped tells if the S3 object with that user metadata is an original ReplaceOriginal wrote
+func IsStripped(metadata map[string]*string) bool {
+	for k, v := range metadata {
+		if strings.EqualFold(k, strippedMetadataKey) && aws.StringValue(v) == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ReplaceOriginal writes a new file over the original of an image, the one everybody downloads. Like
+on upload, the file of an image of a private group is tagged visibility=private
+*/
+func (r *ImageDynamoDbRepository) ReplaceOriginal(imageId string, file []byte, contentType string, private bool) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(r.bucket),
+		Key:         aws.String(imageId),
+		Body:        bytes.NewReader(file),
+		ContentType: aws.String(contentType),
+		Metadata:    map[string]*string{strippedMetadataKey: aws.String("true")},
+	}
+	if private {
+		input.Tagging = aws.String(VisibilityTagKey + "=" + string(models.VisibilityPrivate))
+	}
+
+	if _, err := r.s3Client.PutObject(input); err != nil {
+		return apperrors.FromAWS(err)
+	}
+	return nil
+}
+
+/*
+HideOriginal tags the original of an image visibility=private, so nobody can download it anymore. We do
+this when we could not strip it. Its image is failed, and SetImagesVisibility leaves the failed images private
+*/
+func (r *ImageDynamoDbRepository) HideOriginal(imageId string) error {
+	if r.s3Client == nil {
+		return nil
+	}
+	return r.setVisibility(imageId, models.VisibilityPrivate)
+}
+
+// CopyOriginal keeps the file of an image as it was uploaded in the originals bucket, which nobody can read
+func (r *ImageDynamoDbRepository) CopyOriginal(imageId string, file []byte, contentType string) error {
+	_, err := r.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(r.originalsBucket),
+		Key:         aws.String(imageId),
+		Body:        bytes.NewReader(file),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return apperrors.FromAWS(err)
+	}
+	return nil
+}
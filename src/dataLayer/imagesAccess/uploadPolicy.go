@@ -0,0 +1,94 @@
+package imagesAccess
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+A presigned PUT can't limit the size of the file: the SDK does not sign the Content-Length. A POST
+policy can, with a content-length-range condition, so this is how our clients upload images.
+More on POST policies here https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+*/
+type uploadPolicy struct {
+	bucket      string
+	region      string
+	key         string
+	contentType string
+	size        int64
+	private     bool
+}
+
+const postPolicyAlgorithm = "AWS4-HMAC-SHA256"
+
+// The tags of the images of a private group, in the XML the tagging field of a POST takes
+var privateTagging = "<Tagging><TagSet><Tag><Key>" + VisibilityTagKey + "</Key><Value>" +
+	string(models.VisibilityPrivate) + "</Value></Tag></TagSet></Tagging>"
+
+/*
+presign signs the policy with creds. Every field we return is also a condition of the policy, so the
+client can't change them, and S3 rejects a file that is not exactly the size the client declared
+*/
+func (p uploadPolicy) presign(creds credentials.Value, now time.Time) (models.ImageUpload, error) {
+	now = now.UTC()
+	date := now.Format("20060102")
+
+	fields := map[string]string{
+		"key":              p.key,
+		"Content-Type":     p.contentType,
+		"x-amz-algorithm":  postPolicyAlgorithm,
+		"x-amz-credential": creds.AccessKeyID + "/" + date + "/" + p.region + "/s3/aws4_request",
+		"x-amz-date":       now.Format("20060102T150405Z"),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken //our Lambda functions run with temporary credentials
+	}
+	if p.private {
+		fields["tagging"] = privateTagging
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": p.bucket},
+		[]interface{}{"content-length-range", p.size, p.size},
+	}
+	for name, value := range fields {
+		conditions = append(conditions, map[string]string{name: value})
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(uploadUrlExpiry).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return models.ImageUpload{}, err
+	}
+
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey(creds.SecretAccessKey, date, p.region), fields["policy"]))
+
+	return models.ImageUpload{
+		Url:    "https://" + p.bucket + ".s3." + p.region + ".amazonaws.com/",
+		Fields: fields,
+	}, nil
+}
+
+// signingKey is the Signature Version 4 key for S3, see https://docs.aws.amazon.com/general/latest/gr/sigv4-calculate-signature.html
+func signingKey(secret, date, region string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, "s3")
+	return hmacSHA256(k, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,83 @@
+package imagesAccess
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+func TestUploadPolicy(t *testing.T) {
+	p := uploadPolicy{bucket: "images", region: "ca-central-1", key: "abc", contentType: "image/png", size: 2048, private: true}
+	creds := credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+	now := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	upload, err := p.presign(creds, now)
+	if err != nil {
+		t.Fatalf("presign failed: %v", err)
+	}
+	if upload.Url != "https://images.s3.ca-central-1.amazonaws.com/" {
+		t.Errorf("the upload url is %q", upload.Url)
+	}
+
+	want := map[string]string{
+		"key":                  "abc",
+		"Content-Type":         "image/png",
+		"tagging":              privateTagging,
+		"x-amz-credential":     "AKID/20210501/ca-central-1/s3/aws4_request",
+		"x-amz-date":           "20210501T100000Z",
+		"x-amz-security-token": "token",
+	}
+	for name, value := range want {
+		if upload.Fields[name] != value {
+			t.Errorf("field %s is %q, want %q", name, upload.Fields[name], value)
+		}
+	}
+
+	b, err := base64.StdEncoding.DecodeString(upload.Fields["policy"])
+	if err != nil {
+		t.Fatalf("the policy is not base64: %v", err)
+	}
+	var policy struct {
+		Expiration string            `json:"expiration"`
+		Conditions []json.RawMessage `json:"conditions"`
+	}
+	if err := json.Unmarshal(b, &policy); err != nil {
+		t.Fatalf("the policy is not JSON: %v", err)
+	}
+	if policy.Expiration != "2021-05-01T10:05:00.000Z" {
+		t.Errorf("the policy expires at %s", policy.Expiration)
+	}
+
+	//S3 only checks what is in the policy, so every field we send and the size must be in it
+	conditions := map[string]bool{}
+	for _, c := range policy.Conditions {
+		conditions[string(c)] = true
+	}
+	if !conditions[`["content-length-range",2048,2048]`] || !conditions[`{"bucket":"images"}`] {
+		t.Errorf("the policy does not limit the bucket and size: %s", b)
+	}
+	for name, value := range upload.Fields {
+		if name == "policy" || name == "x-amz-signature" {
+			continue
+		}
+		c, _ := json.Marshal(map[string]string{name: value})
+		if !conditions[string(c)] {
+			t.Errorf("the policy has no condition on field %s: %s", name, b)
+		}
+	}
+
+	sig := hex.EncodeToString(hmacSHA256(signingKey("secret", "20210501", "ca-central-1"), upload.Fields["policy"]))
+	if upload.Fields["x-amz-signature"] != sig {
+		t.Errorf("the signature is %q, want %q", upload.Fields["x-amz-signature"], sig)
+	}
+
+	//the images of a public group are not tagged
+	p.private = false
+	if upload, _ := p.presign(creds, now); upload.Fields["tagging"] != "" {
+		t.Errorf("the upload of a public image has tagging %q", upload.Fields["tagging"])
+	}
+}
@@ -0,0 +1,273 @@
+package invitationsAccess
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It stores the invitations to join a group.
+
+We have two Adapters for it: InvitationDynamoDbRepository for AWS and InvitationMemoryRepository
+for unit tests and running our handlers locally
+*/
+type Repository interface {
+	CreateInvitation(i models.Invitation) (models.Invitation, error)
+	GetInvitation(groupId string, id string) (models.Invitation, error)
+	GetInvitations(groupId string) ([]models.Invitation, error)
+	UseInvitation(groupId string, id string, now time.Time) (models.Invitation, error)
+	DeleteInvitation(groupId string, id string) error
+	DeleteGroupInvitations(groupId string) error
+}
+
+var (
+	// ErrInvitationNotFound is returned when an invitation does not exist, eg because it was revoked
+	ErrInvitationNotFound = apperrors.NotFound("invitation not found")
+	// ErrInvitationUsedUp is returned by UseInvitation when an invitation expired or was redeemed MaxUses times
+	ErrInvitationUsedUp = apperrors.Gone("invitation expired or was used up")
+)
+
+// InvitationDynamoDbRepository is the Adapter that keeps the invitations in DynamoDB
+type InvitationDynamoDbRepository struct {
+	client *dynamodb.DynamoDB
+	table  *string
+}
+
+var (
+	tableName = aws.String(os.Getenv("INVITATIONS_TABLE"))
+	/*
+		Set INVITATIONS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("INVITATIONS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// BatchWriteItem accepts at most 25 requests per call
+const maxBatchWrite = 25
+
+// Creates a DynamoDb client
+func createDynamoDBClient() *dynamodb.DynamoDB {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(svc.Client)
+	return svc
+}
+
+// NewRepo creates the Repository our handlers should use based on the INVITATIONS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	return &InvitationDynamoDbRepository{createDynamoDBClient(), tableName}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client and table
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string) Repository {
+	return &InvitationDynamoDbRepository{c, aws.String(table)}
+}
+
+// CreateInvitation stores a new invitation
+func (r *InvitationDynamoDbRepository) CreateInvitation(i models.Invitation) (models.Invitation, error) {
+	item, err := dynamodbattribute.MarshalMap(i)
+	if err != nil {
+		return models.Invitation{}, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: r.table,
+	}
+
+	if _, err := r.client.PutItem(input); err != nil {
+		return models.Invitation{}, apperrors.FromAWS(err)
+	}
+
+	return i, nil
+}
+
+/*
+GetInvitation returns an invitation of a group. DynamoDB deletes expired invitations some time after
+they expire(up to 48 hours), so an invitation we return can be expired. Use Pending to check it
+*/
+func (r *InvitationDynamoDbRepository) GetInvitation(groupId string, id string) (models.Invitation, error) {
+	result, err := r.client.GetItem(&dynamodb.GetItemInput{
+		Key:       invitationKey(groupId, id),
+		TableName: r.table,
+	})
+	if err != nil {
+		return models.Invitation{}, apperrors.FromAWS(err)
+	}
+
+	if result.Item == nil {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+
+	i := models.Invitation{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &i); err != nil {
+		return models.Invitation{}, err
+	}
+
+	return i, nil
+}
+
+// GetInvitations returns all the invitations of a group, including the ones that expired or were used up
+func (r *InvitationDynamoDbRepository) GetInvitations(groupId string) ([]models.Invitation, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		KeyConditionExpression: aws.String("groupId = :groupId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":groupId": {
+				S: aws.String(groupId),
+			},
+		},
+	}
+
+	invitations := []models.Invitation{}
+
+	//We keep going until there is no LastEvaluatedKey, just like membershipsAccess.GetMembers
+	for {
+		result, err := r.client.Query(input)
+		if err != nil {
+			return nil, apperrors.FromAWS(err)
+		}
+
+		var page []models.Invitation
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return invitations, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+/*
+UseInvitation counts one more use of an invitation and returns the updated invitation.
+
+Many people can redeem the same invitation at the same time, so we can't read Uses, check it and
+write it back. Instead DynamoDB increments Uses only if the condition still holds when it writes
+*/
+func (r *InvitationDynamoDbRepository) UseInvitation(groupId string, id string, now time.Time) (models.Invitation, error) {
+	input := &dynamodb.UpdateItemInput{
+		Key:                 invitationKey(groupId, id),
+		TableName:           r.table,
+		UpdateExpression:    aws.String("SET uses = uses + :one"),
+		ConditionExpression: aws.String("attribute_exists(id) AND uses < maxUses AND expiresAt > :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one": {
+				N: aws.String("1"),
+			},
+			":now": {
+				N: aws.String(strconv.FormatInt(now.Unix(), 10)),
+			},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllNew),
+	}
+
+	result, err := r.client.UpdateItem(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			//the condition does not tell us which part failed, so we find out if the invitation is still there
+			if _, err := r.GetInvitation(groupId, id); err != nil {
+				return models.Invitation{}, err
+			}
+			return models.Invitation{}, ErrInvitationUsedUp
+		}
+		return models.Invitation{}, apperrors.FromAWS(err)
+	}
+
+	i := models.Invitation{}
+	if err := dynamodbattribute.UnmarshalMap(result.Attributes, &i); err != nil {
+		return models.Invitation{}, err
+	}
+
+	return i, nil
+}
+
+// DeleteInvitation deletes an invitation so that nobody can redeem it anymore
+func (r *InvitationDynamoDbRepository) DeleteInvitation(groupId string, id string) error {
+	input := &dynamodb.DeleteItemInput{
+		Key:                 invitationKey(groupId, id),
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_exists(id)"),
+	}
+
+	if _, err := r.client.DeleteItem(input); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return ErrInvitationNotFound
+		}
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// DeleteGroupInvitations deletes every invitation of a group. We call it when the group is deleted
+func (r *InvitationDynamoDbRepository) DeleteGroupInvitations(groupId string) error {
+	invitations, err := r.GetInvitations(groupId)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(invitations); start += maxBatchWrite {
+		end := start + maxBatchWrite
+		if end > len(invitations) {
+			end = len(invitations)
+		}
+
+		var requests []*dynamodb.WriteRequest
+		for _, i := range invitations[start:end] {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: invitationKey(i.GroupId, i.Id)},
+			})
+		}
+
+		items := map[string][]*dynamodb.WriteRequest{*r.table: requests}
+
+		//DynamoDB may not process all our requests in one go, so we send back whatever it returns as UnprocessedItems
+		for attempt := 0; len(items) > 0; attempt++ {
+			time.Sleep(time.Duration(attempt*attempt) * 50 * time.Millisecond) //back off a little more every time we retry
+
+			result, err := r.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+				RequestItems: items,
+			})
+			if err != nil {
+				return apperrors.FromAWS(err)
+			}
+			items = result.UnprocessedItems
+		}
+	}
+
+	return nil
+}
+
+func invitationKey(groupId string, id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"groupId": {
+			S: aws.String(groupId),
+		},
+		"id": {
+			S: aws.String(id),
+		},
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Package invitationsAccessTest is the contract every Adapter of the invitationsAccess.Repository Port must pass
+*/
+package invitationsAccessTest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() invitationsAccess.Repository) {
+	t.Run("CreateAndGetInvitation", func(t *testing.T) {
+		testCreateAndGetInvitation(t, newRepo())
+	})
+	t.Run("GetInvitations", func(t *testing.T) {
+		testGetInvitations(t, newRepo())
+	})
+	t.Run("UseInvitation", func(t *testing.T) {
+		testUseInvitation(t, newRepo())
+	})
+	t.Run("UseExpiredInvitation", func(t *testing.T) {
+		testUseExpiredInvitation(t, newRepo())
+	})
+	t.Run("DeleteInvitation", func(t *testing.T) {
+		testDeleteInvitation(t, newRepo())
+	})
+	t.Run("DeleteGroupInvitations", func(t *testing.T) {
+		testDeleteGroupInvitations(t, newRepo())
+	})
+}
+
+// now is a fixed time so the tests don't depend on the clock
+var now = time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func invitation(groupId, id string, maxUses int) models.Invitation {
+	return models.Invitation{
+		Id:        id,
+		GroupId:   groupId,
+		Role:      models.RoleViewer,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		CreatedBy: "owner",
+		Timestamp: "2021-05-01T12:00:00Z",
+	}
+}
+
+func create(t *testing.T, r invitationsAccess.Repository, invitations ...models.Invitation) {
+	t.Helper()
+
+	for _, i := range invitations {
+		if _, err := r.CreateInvitation(i); err != nil {
+			t.Fatalf("CreateInvitation(%+v) failed: %v", i, err)
+		}
+	}
+}
+
+func testCreateAndGetInvitation(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i1", 5))
+
+	got, err := r.GetInvitation("g1", "i1")
+	if err != nil {
+		t.Fatalf("GetInvitation failed: %v", err)
+	}
+	if got != invitation("g1", "i1", 5) {
+		t.Errorf("GetInvitation returned %+v", got)
+	}
+
+	if _, err := r.GetInvitation("g2", "i1"); err != invitationsAccess.ErrInvitationNotFound {
+		t.Errorf("GetInvitation in another group returned %v, want %v", err, invitationsAccess.ErrInvitationNotFound)
+	}
+}
+
+func testGetInvitations(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i2", 1), invitation("g1", "i1", 1), invitation("g2", "i3", 1))
+
+	invitations, err := r.GetInvitations("g1")
+	if err != nil {
+		t.Fatalf("GetInvitations failed: %v", err)
+	}
+	if len(invitations) != 2 || invitations[0].Id != "i1" || invitations[1].Id != "i2" {
+		t.Errorf("GetInvitations returned %+v, want i1 and i2 in that order", invitations)
+	}
+
+	invitations, err = r.GetInvitations("empty")
+	if err != nil {
+		t.Fatalf("GetInvitations failed: %v", err)
+	}
+	if invitations == nil || len(invitations) != 0 {
+		t.Errorf("GetInvitations of a group without invitations returned %#v, want an empty list", invitations)
+	}
+}
+
+func testUseInvitation(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i1", 2))
+
+	for uses := 1; uses <= 2; uses++ {
+		got, err := r.UseInvitation("g1", "i1", now)
+		if err != nil {
+			t.Fatalf("UseInvitation #%d failed: %v", uses, err)
+		}
+		if got.Uses != uses {
+			t.Errorf("UseInvitation #%d returned %d uses", uses, got.Uses)
+		}
+	}
+
+	if _, err := r.UseInvitation("g1", "i1", now); err != invitationsAccess.ErrInvitationUsedUp {
+		t.Errorf("UseInvitation past MaxUses returned %v, want %v", err, invitationsAccess.ErrInvitationUsedUp)
+	}
+	if got, _ := r.GetInvitation("g1", "i1"); got.Uses != 2 {
+		t.Errorf("UseInvitation past MaxUses changed uses to %d", got.Uses)
+	}
+
+	if _, err := r.UseInvitation("g1", "missing", now); err != invitationsAccess.ErrInvitationNotFound {
+		t.Errorf("UseInvitation of a missing invitation returned %v, want %v", err, invitationsAccess.ErrInvitationNotFound)
+	}
+}
+
+func testUseExpiredInvitation(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i1", 5))
+
+	if _, err := r.UseInvitation("g1", "i1", now.Add(2*time.Hour)); err != invitationsAccess.ErrInvitationUsedUp {
+		t.Errorf("UseInvitation after it expired returned %v, want %v", err, invitationsAccess.ErrInvitationUsedUp)
+	}
+}
+
+func testDeleteInvitation(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i1", 1), invitation("g1", "i2", 1))
+
+	if err := r.DeleteInvitation("g1", "i1"); err != nil {
+		t.Fatalf("DeleteInvitation failed: %v", err)
+	}
+	if _, err := r.GetInvitation("g1", "i1"); err != invitationsAccess.ErrInvitationNotFound {
+		t.Errorf("GetInvitation after DeleteInvitation returned %v, want %v", err, invitationsAccess.ErrInvitationNotFound)
+	}
+	if _, err := r.GetInvitation("g1", "i2"); err != nil {
+		t.Errorf("DeleteInvitation deleted another invitation: %v", err)
+	}
+
+	if err := r.DeleteInvitation("g1", "i1"); err != invitationsAccess.ErrInvitationNotFound {
+		t.Errorf("DeleteInvitation of a missing invitation returned %v, want %v", err, invitationsAccess.ErrInvitationNotFound)
+	}
+}
+
+func testDeleteGroupInvitations(t *testing.T, r invitationsAccess.Repository) {
+	create(t, r, invitation("g1", "i1", 1), invitation("g1", "i2", 1), invitation("g2", "i3", 1))
+
+	if err := r.DeleteGroupInvitations("g1"); err != nil {
+		t.Fatalf("DeleteGroupInvitations failed: %v", err)
+	}
+	if invitations, _ := r.GetInvitations("g1"); len(invitations) != 0 {
+		t.Errorf("GetInvitations after DeleteGroupInvitations returned %+v", invitations)
+	}
+	if _, err := r.GetInvitation("g2", "i3"); err != nil {
+		t.Errorf("DeleteGroupInvitations deleted an invitation of another group: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package invitationsAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess/invitationsAccessTest"
+)
+
+// Like the groupsAccess tests, this only runs when DYNAMODB_ENDPOINT points at a DynamoDB, eg DynamoDB Local
+func TestInvitationDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	invitationsAccessTest.TestRepository(t, func() invitationsAccess.Repository {
+		n++
+		table := fmt.Sprintf("Invitations-test-%d-%d", time.Now().UnixNano(), n)
+		createInvitationsTable(t, client, table)
+
+		return invitationsAccess.NewDynamoDbRepoWithClient(client, table)
+	})
+}
+
+// createInvitationsTable creates a table with the same key schema as InvitationsDynamoDBTable in serverless.yml
+func createInvitationsTable(t *testing.T, client *dynamodb.DynamoDB, table string) {
+	t.Helper()
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("groupId"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("id"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("groupId"), KeyType: aws.String("HASH")},
+			{AttributeName: aws.String("id"), KeyType: aws.String("RANGE")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
@@ -0,0 +1,102 @@
+package invitationsAccess
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// InvitationMemoryRepository is the in-memory Adapter of our Repository Port. The invitations of each group are keyed by id
+type InvitationMemoryRepository struct {
+	mu     sync.RWMutex
+	groups map[string]map[string]models.Invitation
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &InvitationMemoryRepository{groups: make(map[string]map[string]models.Invitation)}
+}
+
+// CreateInvitation stores a new invitation
+func (r *InvitationMemoryRepository) CreateInvitation(i models.Invitation) (models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.groups[i.GroupId] == nil {
+		r.groups[i.GroupId] = make(map[string]models.Invitation)
+	}
+	r.groups[i.GroupId][i.Id] = i
+
+	return i, nil
+}
+
+// GetInvitation returns an invitation of a group
+func (r *InvitationMemoryRepository) GetInvitation(groupId string, id string) (models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.groups[groupId][id]
+	if !ok {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+
+	return i, nil
+}
+
+// GetInvitations returns all the invitations of a group, sorted by id like the range key of our table
+func (r *InvitationMemoryRepository) GetInvitations(groupId string) ([]models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, i := range r.groups[groupId] {
+		invitations = append(invitations, i)
+	}
+	sort.Slice(invitations, func(a, b int) bool { return invitations[a].Id < invitations[b].Id })
+
+	return invitations, nil
+}
+
+// UseInvitation counts one more use of an invitation and returns the updated invitation
+func (r *InvitationMemoryRepository) UseInvitation(groupId string, id string, now time.Time) (models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.groups[groupId][id]
+	if !ok {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	if !i.Pending(now) {
+		return models.Invitation{}, ErrInvitationUsedUp
+	}
+
+	i.Uses++
+	r.groups[groupId][id] = i
+
+	return i, nil
+}
+
+// DeleteInvitation deletes an invitation so that nobody can redeem it anymore
+func (r *InvitationMemoryRepository) DeleteInvitation(groupId string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[groupId][id]; !ok {
+		return ErrInvitationNotFound
+	}
+	delete(r.groups[groupId], id)
+
+	return nil
+}
+
+// DeleteGroupInvitations deletes every invitation of a group
+func (r *InvitationMemoryRepository) DeleteGroupInvitations(groupId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.groups, groupId)
+
+	return nil
+}
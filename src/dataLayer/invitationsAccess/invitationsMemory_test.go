@@ -0,0 +1,12 @@
+package invitationsAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess/invitationsAccessTest"
+)
+
+func TestInvitationMemoryRepository(t *testing.T) {
+	invitationsAccessTest.TestRepository(t, invitationsAccess.NewMemoryRepo)
+}
@@ -0,0 +1,221 @@
+package membershipsAccess
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It stores who is a member of a group and with which role.
+
+We have two Adapters for it: MembershipDynamoDbRepository for AWS and MembershipMemoryRepository
+for unit tests and running our handlers locally
+*/
+type Repository interface {
+	AddMember(m models.Membership) (models.Membership, error)
+	GetMember(groupId string, userId string) (models.Membership, error)
+	GetMembers(groupId string) ([]models.Membership, error)
+	RemoveMember(groupId string, userId string) error
+	DeleteGroupMembers(groupId string) error
+}
+
+// ErrMemberNotFound is returned when a user is not a member of a group
+var ErrMemberNotFound = apperrors.NotFound("member not found")
+
+// MembershipDynamoDbRepository is the Adapter that keeps the memberships in DynamoDB
+type MembershipDynamoDbRepository struct {
+	client *dynamodb.DynamoDB
+	table  *string
+}
+
+var (
+	tableName = aws.String(os.Getenv("MEMBERSHIPS_TABLE"))
+	/*
+		Set MEMBERSHIPS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("MEMBERSHIPS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// BatchWriteItem accepts at most 25 requests per call
+const maxBatchWrite = 25
+
+// Creates a DynamoDb client
+func createDynamoDBClient() *dynamodb.DynamoDB {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(svc.Client)
+	return svc
+}
+
+// NewRepo creates the Repository our handlers should use based on the MEMBERSHIPS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	return &MembershipDynamoDbRepository{createDynamoDBClient(), tableName}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client and table
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, table string) Repository {
+	return &MembershipDynamoDbRepository{c, aws.String(table)}
+}
+
+// AddMember adds a user to a group. If the user is already a member, their role is replaced
+func (r *MembershipDynamoDbRepository) AddMember(m models.Membership) (models.Membership, error) {
+	item, err := dynamodbattribute.MarshalMap(m)
+	if err != nil {
+		return models.Membership{}, err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: r.table,
+	}
+
+	if _, err := r.client.PutItem(input); err != nil {
+		return models.Membership{}, apperrors.FromAWS(err)
+	}
+
+	return m, nil
+}
+
+// GetMember returns the membership of a user in a group
+func (r *MembershipDynamoDbRepository) GetMember(groupId string, userId string) (models.Membership, error) {
+	result, err := r.client.GetItem(&dynamodb.GetItemInput{
+		Key:       memberKey(groupId, userId),
+		TableName: r.table,
+	})
+	if err != nil {
+		return models.Membership{}, apperrors.FromAWS(err)
+	}
+
+	if result.Item == nil {
+		return models.Membership{}, ErrMemberNotFound
+	}
+
+	m := models.Membership{}
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &m); err != nil {
+		return models.Membership{}, err
+	}
+
+	return m, nil
+}
+
+// GetMembers returns all the members of a group
+func (r *MembershipDynamoDbRepository) GetMembers(groupId string) ([]models.Membership, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              r.table,
+		KeyConditionExpression: aws.String("groupId = :groupId"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":groupId": {
+				S: aws.String(groupId),
+			},
+		},
+	}
+
+	members := []models.Membership{}
+
+	//A group can have more members than a single Query page(1MB) can return, so we keep going until there is no LastEvaluatedKey
+	for {
+		result, err := r.client.Query(input)
+		if err != nil {
+			return nil, apperrors.FromAWS(err)
+		}
+
+		var page []models.Membership
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return members, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// RemoveMember removes a user from a group
+func (r *MembershipDynamoDbRepository) RemoveMember(groupId string, userId string) error {
+	input := &dynamodb.DeleteItemInput{
+		Key:                 memberKey(groupId, userId),
+		TableName:           r.table,
+		ConditionExpression: aws.String("attribute_exists(groupId)"),
+	}
+
+	if _, err := r.client.DeleteItem(input); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return ErrMemberNotFound
+		}
+		return apperrors.FromAWS(err)
+	}
+
+	return nil
+}
+
+// DeleteGroupMembers removes every member of a group. We call it when the group is deleted
+func (r *MembershipDynamoDbRepository) DeleteGroupMembers(groupId string) error {
+	members, err := r.GetMembers(groupId)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(members); start += maxBatchWrite {
+		end := start + maxBatchWrite
+		if end > len(members) {
+			end = len(members)
+		}
+
+		var requests []*dynamodb.WriteRequest
+		for _, m := range members[start:end] {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: memberKey(m.GroupId, m.UserId)},
+			})
+		}
+
+		items := map[string][]*dynamodb.WriteRequest{*r.table: requests}
+
+		//DynamoDB may not process all our requests in one go, so we send back whatever it returns as UnprocessedItems
+		for attempt := 0; len(items) > 0; attempt++ {
+			time.Sleep(time.Duration(attempt*attempt) * 50 * time.Millisecond) //back off a little more every time we retry
+
+			result, err := r.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+				RequestItems: items,
+			})
+			if err != nil {
+				return apperrors.FromAWS(err)
+			}
+			items = result.UnprocessedItems
+		}
+	}
+
+	return nil
+}
+
+func memberKey(groupId string, userId string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"groupId": {
+			S: aws.String(groupId),
+		},
+		"userId": {
+			S: aws.String(userId),
+		},
+	}
+}
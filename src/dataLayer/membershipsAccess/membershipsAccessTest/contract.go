@@ -0,0 +1,122 @@
+/*
+Package membershipsAccessTest is the contract every Adapter of the membershipsAccess.Repository Port must pass
+*/
+package membershipsAccessTest
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() membershipsAccess.Repository) {
+	t.Run("AddAndGetMember", func(t *testing.T) {
+		testAddAndGetMember(t, newRepo())
+	})
+	t.Run("GetMembers", func(t *testing.T) {
+		testGetMembers(t, newRepo())
+	})
+	t.Run("RemoveMember", func(t *testing.T) {
+		testRemoveMember(t, newRepo())
+	})
+	t.Run("DeleteGroupMembers", func(t *testing.T) {
+		testDeleteGroupMembers(t, newRepo())
+	})
+}
+
+func member(groupId, userId string, role models.Role) models.Membership {
+	return models.Membership{GroupId: groupId, UserId: userId, Role: role, Timestamp: "2021-05-01T12:00:00Z"}
+}
+
+func add(t *testing.T, r membershipsAccess.Repository, members ...models.Membership) {
+	t.Helper()
+
+	for _, m := range members {
+		if _, err := r.AddMember(m); err != nil {
+			t.Fatalf("AddMember(%+v) failed: %v", m, err)
+		}
+	}
+}
+
+func testAddAndGetMember(t *testing.T, r membershipsAccess.Repository) {
+	add(t, r, member("g1", "u1", models.RoleViewer))
+
+	got, err := r.GetMember("g1", "u1")
+	if err != nil {
+		t.Fatalf("GetMember failed: %v", err)
+	}
+	if got != member("g1", "u1", models.RoleViewer) {
+		t.Errorf("GetMember returned %+v", got)
+	}
+
+	//adding a member again replaces their role
+	add(t, r, member("g1", "u1", models.RoleEditor))
+	if got, _ := r.GetMember("g1", "u1"); got.Role != models.RoleEditor {
+		t.Errorf("GetMember after a second AddMember returned role %q, want %q", got.Role, models.RoleEditor)
+	}
+
+	if _, err := r.GetMember("g1", "u2"); err != membershipsAccess.ErrMemberNotFound {
+		t.Errorf("GetMember of a user that is not a member returned %v, want %v", err, membershipsAccess.ErrMemberNotFound)
+	}
+	if _, err := r.GetMember("g2", "u1"); err != membershipsAccess.ErrMemberNotFound {
+		t.Errorf("GetMember in another group returned %v, want %v", err, membershipsAccess.ErrMemberNotFound)
+	}
+}
+
+func testGetMembers(t *testing.T, r membershipsAccess.Repository) {
+	add(t, r,
+		member("g1", "u2", models.RoleViewer),
+		member("g1", "u1", models.RoleOwner),
+		member("g2", "u3", models.RoleEditor),
+	)
+
+	members, err := r.GetMembers("g1")
+	if err != nil {
+		t.Fatalf("GetMembers failed: %v", err)
+	}
+	if len(members) != 2 || members[0].UserId != "u1" || members[1].UserId != "u2" {
+		t.Errorf("GetMembers returned %+v, want u1 and u2 in that order", members)
+	}
+
+	members, err = r.GetMembers("empty")
+	if err != nil {
+		t.Fatalf("GetMembers failed: %v", err)
+	}
+	if members == nil || len(members) != 0 {
+		t.Errorf("GetMembers of a group without members returned %#v, want an empty list", members)
+	}
+}
+
+func testRemoveMember(t *testing.T, r membershipsAccess.Repository) {
+	add(t, r, member("g1", "u1", models.RoleOwner), member("g1", "u2", models.RoleViewer))
+
+	if err := r.RemoveMember("g1", "u2"); err != nil {
+		t.Fatalf("RemoveMember failed: %v", err)
+	}
+	if _, err := r.GetMember("g1", "u2"); err != membershipsAccess.ErrMemberNotFound {
+		t.Errorf("GetMember after RemoveMember returned %v, want %v", err, membershipsAccess.ErrMemberNotFound)
+	}
+	if _, err := r.GetMember("g1", "u1"); err != nil {
+		t.Errorf("RemoveMember removed another member: %v", err)
+	}
+
+	if err := r.RemoveMember("g1", "u2"); err != membershipsAccess.ErrMemberNotFound {
+		t.Errorf("RemoveMember of a user that is not a member returned %v, want %v", err, membershipsAccess.ErrMemberNotFound)
+	}
+}
+
+func testDeleteGroupMembers(t *testing.T, r membershipsAccess.Repository) {
+	add(t, r, member("g1", "u1", models.RoleOwner), member("g1", "u2", models.RoleViewer), member("g2", "u1", models.RoleOwner))
+
+	if err := r.DeleteGroupMembers("g1"); err != nil {
+		t.Fatalf("DeleteGroupMembers failed: %v", err)
+	}
+	if members, _ := r.GetMembers("g1"); len(members) != 0 {
+		t.Errorf("GetMembers after DeleteGroupMembers returned %+v", members)
+	}
+	if _, err := r.GetMember("g2", "u1"); err != nil {
+		t.Errorf("DeleteGroupMembers removed a member of another group: %v", err)
+	}
+}
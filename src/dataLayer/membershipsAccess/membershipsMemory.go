@@ -0,0 +1,82 @@
+package membershipsAccess
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// MembershipMemoryRepository is the in-memory Adapter of our Repository Port. The members of each group are keyed by userId
+type MembershipMemoryRepository struct {
+	mu     sync.RWMutex
+	groups map[string]map[string]models.Membership
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &MembershipMemoryRepository{groups: make(map[string]map[string]models.Membership)}
+}
+
+// AddMember adds a user to a group. If the user is already a member, their role is replaced
+func (r *MembershipMemoryRepository) AddMember(m models.Membership) (models.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.groups[m.GroupId] == nil {
+		r.groups[m.GroupId] = make(map[string]models.Membership)
+	}
+	r.groups[m.GroupId][m.UserId] = m
+
+	return m, nil
+}
+
+// GetMember returns the membership of a user in a group
+func (r *MembershipMemoryRepository) GetMember(groupId string, userId string) (models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.groups[groupId][userId]
+	if !ok {
+		return models.Membership{}, ErrMemberNotFound
+	}
+
+	return m, nil
+}
+
+// GetMembers returns all the members of a group, sorted by userId like the range key of our table
+func (r *MembershipMemoryRepository) GetMembers(groupId string) ([]models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []models.Membership{}
+	for _, m := range r.groups[groupId] {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserId < members[j].UserId })
+
+	return members, nil
+}
+
+// RemoveMember removes a user from a group
+func (r *MembershipMemoryRepository) RemoveMember(groupId string, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[groupId][userId]; !ok {
+		return ErrMemberNotFound
+	}
+	delete(r.groups[groupId], userId)
+
+	return nil
+}
+
+// DeleteGroupMembers removes every member of a group
+func (r *MembershipMemoryRepository) DeleteGroupMembers(groupId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.groups, groupId)
+
+	return nil
+}
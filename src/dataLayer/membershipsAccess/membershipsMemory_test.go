@@ -0,0 +1,12 @@
+package membershipsAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess/membershipsAccessTest"
+)
+
+func TestMembershipMemoryRepository(t *testing.T) {
+	membershipsAccessTest.TestRepository(t, membershipsAccess.NewMemoryRepo)
+}
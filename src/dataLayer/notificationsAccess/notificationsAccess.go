@@ -0,0 +1,138 @@
+package notificationsAccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It sends messages to the clients that are
+connected to our websocket API.
+
+We have two Adapters for it: NotificationsWebsocketRepository for AWS and NotificationsMemoryRepository
+for unit tests and running our handlers locally
+*/
+type Repository interface {
+	Broadcast(message interface{}) error
+}
+
+// NotificationsWebsocketRepository is the Adapter that sends messages to the clients of our websocket API
+type NotificationsWebsocketRepository struct {
+	ddb        *dynamodb.DynamoDB
+	apiGateway *apigatewaymanagementapi.ApiGatewayManagementApi
+	table      *string // the connections the connect handler stores
+}
+
+// A connection to our websocket API, as the connect handler stores it
+type userConn struct {
+	Id        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+}
+
+var (
+	connectionsTable = aws.String(os.Getenv("CONNECTIONS_TABLE"))
+	stage            = os.Getenv("STAGE")
+	apiId            = os.Getenv("API_ID")
+	region           = os.Getenv("AWS_REGION")
+	/*
+		Set NOTIFICATIONS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("NOTIFICATIONS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// NewRepo creates the Repository our handlers should use based on the NOTIFICATIONS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewWebsocketRepo()
+}
+
+// NewWebsocketRepo creates a Repository that sends to the connections of the websocket API with the id API_ID
+func NewWebsocketRepo() Repository {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	ddb := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(ddb.Client)
+
+	endpoint := fmt.Sprintf("%s.execute-api.%s.amazonaws.com/%s", apiId, region, stage)
+
+	return &NotificationsWebsocketRepository{
+		ddb:        ddb,
+		apiGateway: apigatewaymanagementapi.New(sess, aws.NewConfig().WithEndpoint(endpoint)),
+		table:      connectionsTable,
+	}
+}
+
+/*
+Broadcast sends message as JSON to every connection. Like sendNotifications, we forget the connections
+that were closed without telling us. If we fail to send to some connections we return an error, and a
+retry sends to every connection again
+*/
+func (r *NotificationsWebsocketRepository) Broadcast(message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName: r.table,
+	}
+
+	var failed error
+	for {
+		result, err := r.ddb.Scan(input)
+		if err != nil {
+			return apperrors.FromAWS(err)
+		}
+
+		var conns []userConn
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &conns); err != nil {
+			return err
+		}
+
+		for _, c := range conns {
+			if err := r.send(c.Id, body); err != nil && failed == nil {
+				failed = err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return failed
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func (r *NotificationsWebsocketRepository) send(connId string, body []byte) error {
+	_, err := r.apiGateway.PostToConnection(&apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(connId),
+		Data:         body,
+	})
+
+	//we still have the connectionId in our db but that connection was closed
+	if _, ok := err.(*apigatewaymanagementapi.GoneException); ok {
+		_, err := r.ddb.DeleteItem(&dynamodb.DeleteItemInput{
+			Key: map[string]*dynamodb.AttributeValue{
+				"id": {
+					S: aws.String(connId),
+				},
+			},
+			TableName: r.table,
+		})
+		return err
+	}
+
+	return err
+}
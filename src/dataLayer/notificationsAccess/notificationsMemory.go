@@ -0,0 +1,26 @@
+package notificationsAccess
+
+import "sync"
+
+/*
+NotificationsMemoryRepository is the in-memory Adapter of our Repository Port. Nobody is connected
+to it, it only remembers the messages so the tests can check
+*/
+type NotificationsMemoryRepository struct {
+	mu       sync.Mutex
+	Messages []interface{}
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &NotificationsMemoryRepository{}
+}
+
+// Broadcast remembers the message
+func (r *NotificationsMemoryRepository) Broadcast(message interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Messages = append(r.Messages, message)
+	return nil
+}
@@ -0,0 +1,146 @@
+package searchAccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It is the search index of the images,
+that elasticSearchSync fills from the Images stream.
+
+We have two Adapters for it: SearchElasticRepository for our Elasticsearch domain and
+SearchMemoryRepository for unit tests and running our handlers locally
+*/
+type Repository interface {
+	DeleteImage(imageId string) error
+}
+
+// SearchElasticRepository is the Adapter that talks to our Elasticsearch domain
+type SearchElasticRepository struct {
+	client   *http.Client
+	url      string // where the documents of the images are, like elasticSearchSync writes them
+	region   string
+	secretId string
+	sm       *secretsmanager.SecretsManager
+
+	// The requests are signed with the credentials of our app user, read from the secret the first time we need them
+	mu     sync.Mutex
+	signer *v4.Signer
+}
+
+// The fields of the secret that has the credentials of our app user
+type awsUser struct {
+	KeyID     string `json:"AWS_ACCESS_KEY_ID"`
+	SecretKey string `json:"AWS_SECRET_ACCESS_KEY"`
+}
+
+const (
+	index   = "images-index"
+	docType = "images" //the type, as required by ES as the path to your indicies
+	service = "es"
+)
+
+var (
+	esHost   = os.Getenv("ES_ENDPOINT")
+	region   = os.Getenv("AWS_REGION")
+	secretId = os.Getenv("AWS_APP_USER_SECRET_ID")
+	/*
+		Set SEARCH_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("SEARCH_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+)
+
+// NewRepo creates the Repository our handlers should use based on the SEARCH_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewElasticRepo()
+}
+
+// NewElasticRepo creates a Repository for the Elasticsearch domain at ES_ENDPOINT
+func NewElasticRepo() Repository {
+	sm := secretsmanager.New(session.Must(session.NewSession())) // Create SecretsManager client
+	xray.AWS(sm.Client)
+
+	return &SearchElasticRepository{
+		client:   xray.Client(&http.Client{Timeout: 10 * time.Second}),
+		url:      fmt.Sprintf("https://%s/%s/%s/", esHost, index, docType),
+		region:   region,
+		secretId: secretId,
+		sm:       sm,
+	}
+}
+
+// DeleteImage removes the document of an image. It is not an error if there is none, eg because elasticSearchSync never indexed it
+func (r *SearchElasticRepository) DeleteImage(imageId string) error {
+	signer, err := r.getSigner()
+	if err != nil {
+		return err
+	}
+
+	//https://www.elastic.co/guide/en/elasticsearch/reference/6.8/docs-delete.html
+	req, err := http.NewRequest(http.MethodDelete, r.url+imageId, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := signer.Sign(req, nil, service, r.region, time.Now()); err != nil {
+		return err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete the search document of image %s: %s", imageId, resp.Status)
+	}
+
+	return nil
+}
+
+/*
+getSigner reads the credentials of our app user from the secret, and keeps them. AWS Lambda may keep
+our function instance for some time, then we don't call secretManager over and over again
+*/
+func (r *SearchElasticRepository) getSigner() (*v4.Signer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.signer != nil {
+		return r.signer, nil
+	}
+
+	data, err := r.sm.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(r.secretId),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u := awsUser{}
+	if err := json.Unmarshal([]byte(aws.StringValue(data.SecretString)), &u); err != nil {
+		return nil, err
+	}
+
+	r.signer = v4.NewSigner(credentials.NewStaticCredentials(u.KeyID, u.SecretKey, ""))
+	return r.signer, nil
+}
@@ -0,0 +1,26 @@
+package searchAccess
+
+import "sync"
+
+/*
+SearchMemoryRepository is the in-memory Adapter of our Repository Port. There is no index behind it,
+it only remembers which documents were deleted so the tests can check
+*/
+type SearchMemoryRepository struct {
+	mu      sync.Mutex
+	Deleted []string
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &SearchMemoryRepository{}
+}
+
+// DeleteImage remembers that the document of the image was deleted
+func (r *SearchMemoryRepository) DeleteImage(imageId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Deleted = append(r.Deleted, imageId)
+	return nil
+}
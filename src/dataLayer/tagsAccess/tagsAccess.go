@@ -0,0 +1,268 @@
+package tagsAccess
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+/*
+This interface is a Port, just like groupsAccess.Repository. It is the inverted index of our tags:
+for every tag, the public groups that have it, and how many they are.
+
+Filtering a Scan of the Groups table by tag would read every group to return a few of them. Instead
+we keep one row per tag and group, so listing the groups of a tag is a Query that only reads those groups.
+
+We have two Adapters for it: TagDynamoDbRepository for AWS and TagMemoryRepository for unit tests
+and running our handlers locally
+*/
+type Repository interface {
+	TagGroup(group models.Group, tags []string) error
+	UntagGroup(group models.Group, tags []string) error
+	GetGroupIdsByTag(tag string, l int64, n string, order groupsAccess.SortOrder) ([]string, string, error)
+	GetTagCounts() ([]models.TagCount, error)
+}
+
+// TagDynamoDbRepository is the Adapter that keeps the tags of the groups in DynamoDB
+type TagDynamoDbRepository struct {
+	client    *dynamodb.DynamoDB
+	groupTags *string // one row per tag and group. tag is the partition key and sortKey the sort key
+	tagCounts *string // one row per tag with the number of groups that have it
+}
+
+/*
+The groups of a tag are sorted by sortKey, which is the timestamp of the group followed by its id.
+The timestamps sort in the order the groups were created(see models.TimestampFormat), and the id
+keeps two groups created at the same time apart
+*/
+func sortKey(group models.Group) string {
+	return group.Timestamp + "#" + group.Id
+}
+
+/*
+The nextKey of GetGroupIdsByTag is a signed cursor, like the ones of groupsAccess. Every tag and
+order has its own scope, so a cursor of one tag can't be used to page through another one
+*/
+func tagScope(tag string, order groupsAccess.SortOrder) string {
+	return "tags:" + tag + ":" + string(order)
+}
+
+var (
+	groupTagsTableName = aws.String(os.Getenv("GROUP_TAGS_TABLE"))
+	tagCountsTableName = aws.String(os.Getenv("TAG_COUNTS_TABLE"))
+	/*
+		Set TAGS_REPOSITORY=memory to run the handlers locally without AWS. The memory Adapter
+		is shared by every handler running in the same process
+	*/
+	repoKind   = os.Getenv("TAGS_REPOSITORY")
+	memoryRepo = NewMemoryRepo()
+	cursors    = cursor.FromEnv()
+)
+
+// Creates a DynamoDb client
+func createDynamoDBClient() *dynamodb.DynamoDB {
+	sess := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
+	svc := dynamodb.New(sess)                  // Create DynamoDB client
+	xray.AWS(svc.Client)
+	return svc
+}
+
+// NewRepo creates the Repository our handlers should use based on the TAGS_REPOSITORY environment variable
+func NewRepo() Repository {
+	if repoKind == "memory" {
+		return memoryRepo
+	}
+
+	return NewDynamoDbRepo()
+}
+
+// NewDynamoDbRepo creates a new DynamoDb Repository
+func NewDynamoDbRepo() Repository {
+	return &TagDynamoDbRepository{createDynamoDBClient(), groupTagsTableName, tagCountsTableName}
+}
+
+// NewDynamoDbRepoWithClient creates a DynamoDb Repository on top of an existing client and tables
+func NewDynamoDbRepoWithClient(c *dynamodb.DynamoDB, groupTagsTable string, tagCountsTable string) Repository {
+	return &TagDynamoDbRepository{c, aws.String(groupTagsTable), aws.String(tagCountsTable)}
+}
+
+/*
+TagGroup adds the group to the groups of every tag and counts it once more in each of them.
+
+The row and the count of a tag are written in one transaction, so the count never drifts from the
+rows. The row is only put if it is not there yet: tagging a group twice with the same tag does nothing,
+so it is safe to call TagGroup again after a failure
+*/
+func (r *TagDynamoDbRepository) TagGroup(group models.Group, tags []string) error {
+	for _, tag := range tags {
+		_, err := r.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{
+					Put: &dynamodb.Put{
+						TableName: r.groupTags,
+						Item: map[string]*dynamodb.AttributeValue{
+							"tag":     {S: aws.String(tag)},
+							"sortKey": {S: aws.String(sortKey(group))},
+							"groupId": {S: aws.String(group.Id)},
+						},
+						ConditionExpression: aws.String("attribute_not_exists(tag)"),
+					},
+				},
+				r.addToCount(tag, 1),
+			},
+		})
+		if err != nil && !isConditionalCheckFailed(err) {
+			return apperrors.FromAWS(err)
+		}
+	}
+
+	return nil
+}
+
+// UntagGroup removes the group from the groups of every tag. Like TagGroup, it does nothing for the tags the group does not have
+func (r *TagDynamoDbRepository) UntagGroup(group models.Group, tags []string) error {
+	for _, tag := range tags {
+		_, err := r.client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: []*dynamodb.TransactWriteItem{
+				{
+					Delete: &dynamodb.Delete{
+						TableName: r.groupTags,
+						Key: map[string]*dynamodb.AttributeValue{
+							"tag":     {S: aws.String(tag)},
+							"sortKey": {S: aws.String(sortKey(group))},
+						},
+						ConditionExpression: aws.String("attribute_exists(tag)"),
+					},
+				},
+				r.addToCount(tag, -1),
+			},
+		})
+		if err != nil && !isConditionalCheckFailed(err) {
+			return apperrors.FromAWS(err)
+		}
+	}
+
+	return nil
+}
+
+// addToCount is the part of a transaction that changes the count of a tag. ADD starts from 0 when the tag has no count yet
+func (r *TagDynamoDbRepository) addToCount(tag string, n int64) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName: r.tagCounts,
+			Key: map[string]*dynamodb.AttributeValue{
+				"tag": {S: aws.String(tag)},
+			},
+			UpdateExpression:         aws.String("ADD #c :n"),
+			ExpressionAttributeNames: map[string]*string{"#c": aws.String("count")}, //count is a reserved word in DynamoDB
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":n": {N: aws.String(strconv.FormatInt(n, 10))},
+			},
+		},
+	}
+}
+
+// GetGroupIdsByTag gets the ids of the groups that have a tag, in the order they were created
+func (r *TagDynamoDbRepository) GetGroupIdsByTag(tag string, limit int64, nextKey string, order groupsAccess.SortOrder) ([]string, string, error) {
+	scope := tagScope(tag, order)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              r.groupTags,
+		KeyConditionExpression: aws.String("tag = :tag"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":tag": {
+				S: aws.String(tag),
+			},
+		},
+		ScanIndexForward:  aws.Bool(order == groupsAccess.OldestFirst),
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	}
+
+	result, err := r.client.Query(input)
+	if err != nil {
+		return nil, "", apperrors.FromAWS(err)
+	}
+
+	var rows []struct {
+		GroupId string `json:"groupId"`
+	}
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &rows); err != nil {
+		return nil, "", err
+	}
+
+	var ids []string
+	for _, row := range rows {
+		ids = append(ids, row.GroupId)
+	}
+
+	nk, err := cursors.Encode(scope, result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ids, nk, nil
+}
+
+/*
+GetTagCounts returns the count of every tag that at least one group has, in no particular order.
+The counts of tags nobody uses anymore stay in the table at 0, and we leave them out
+*/
+func (r *TagDynamoDbRepository) GetTagCounts() ([]models.TagCount, error) {
+	input := &dynamodb.ScanInput{
+		TableName:                r.tagCounts,
+		FilterExpression:         aws.String("#c > :zero"),
+		ExpressionAttributeNames: map[string]*string{"#c": aws.String("count")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":zero": {N: aws.String("0")},
+		},
+	}
+
+	counts := []models.TagCount{}
+
+	//We keep going until there is no LastEvaluatedKey, just like invitationsAccess.GetInvitations
+	for {
+		result, err := r.client.Scan(input)
+		if err != nil {
+			return nil, apperrors.FromAWS(err)
+		}
+
+		var page []models.TagCount
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		counts = append(counts, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return counts, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+/*
+isConditionalCheckFailed tells if a transaction was cancelled because the condition of its first item
+failed, ie the row of the tag was already there(TagGroup) or already gone(UntagGroup). A transaction
+cancelled for any other reason, eg a conflict with another transaction, is an error
+*/
+func isConditionalCheckFailed(err error) bool {
+	tce, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok || len(tce.CancellationReasons) == 0 {
+		return false
+	}
+	return aws.StringValue(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed"
+}
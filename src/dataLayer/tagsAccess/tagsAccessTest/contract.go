@@ -0,0 +1,183 @@
+/*
+Package tagsAccessTest is the contract every Adapter of the tagsAccess.Repository Port must pass
+*/
+package tagsAccessTest
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TestRepository runs the contract against the Repository returned by newRepo. newRepo must return an empty Repository every time it is called
+func TestRepository(t *testing.T, newRepo func() tagsAccess.Repository) {
+	t.Run("TagAndUntagGroup", func(t *testing.T) {
+		testTagAndUntagGroup(t, newRepo())
+	})
+	t.Run("TaggingTwiceCountsOnce", func(t *testing.T) {
+		testTaggingTwiceCountsOnce(t, newRepo())
+	})
+	t.Run("SortOrderAndPagination", func(t *testing.T) {
+		testSortOrderAndPagination(t, newRepo())
+	})
+	t.Run("InvalidCursor", func(t *testing.T) {
+		testInvalidCursor(t, newRepo())
+	})
+}
+
+func group(i int) models.Group {
+	return models.Group{
+		Id:        fmt.Sprintf("group-%02d", i),
+		Timestamp: fmt.Sprintf("2021-05-01T12:00:%02dZ", i), //the groups are created one second apart
+	}
+}
+
+// counts returns the tag counts as a map, so we don't depend on their order
+func counts(t *testing.T, r tagsAccess.Repository) map[string]int64 {
+	t.Helper()
+
+	tc, err := r.GetTagCounts()
+	if err != nil {
+		t.Fatalf("GetTagCounts failed: %v", err)
+	}
+
+	got := make(map[string]int64)
+	for _, c := range tc {
+		got[c.Tag] = c.Count
+	}
+	return got
+}
+
+// allIds reads every page of the groups of a tag
+func allIds(t *testing.T, r tagsAccess.Repository, tag string, limit int64, order groupsAccess.SortOrder) []string {
+	t.Helper()
+
+	var ids []string
+	nk := ""
+	for pages := 0; pages == 0 || nk != ""; pages++ {
+		if pages > 20 {
+			t.Fatalf("GetGroupIdsByTag(%s) never stopped returning a nextKey", tag)
+		}
+
+		page, next, err := r.GetGroupIdsByTag(tag, limit, nk, order)
+		if err != nil {
+			t.Fatalf("GetGroupIdsByTag(%s) failed: %v", tag, err)
+		}
+		if int64(len(page)) > limit {
+			t.Fatalf("GetGroupIdsByTag returned %d ids, want at most %d", len(page), limit)
+		}
+		ids = append(ids, page...)
+		nk = next
+	}
+	return ids
+}
+
+func testTagAndUntagGroup(t *testing.T, r tagsAccess.Repository) {
+	if err := r.TagGroup(group(0), []string{"cats", "dogs"}); err != nil {
+		t.Fatalf("TagGroup failed: %v", err)
+	}
+	if err := r.TagGroup(group(1), []string{"cats"}); err != nil {
+		t.Fatalf("TagGroup failed: %v", err)
+	}
+
+	if got := fmt.Sprint(allIds(t, r, "cats", 10, groupsAccess.NewestFirst)); got != "[group-01 group-00]" {
+		t.Errorf("GetGroupIdsByTag(cats) returned %s", got)
+	}
+	if got := counts(t, r); len(got) != 2 || got["cats"] != 2 || got["dogs"] != 1 {
+		t.Errorf("GetTagCounts returned %v, want cats:2 dogs:1", got)
+	}
+
+	if err := r.UntagGroup(group(0), []string{"cats", "dogs"}); err != nil {
+		t.Fatalf("UntagGroup failed: %v", err)
+	}
+
+	if got := fmt.Sprint(allIds(t, r, "cats", 10, groupsAccess.NewestFirst)); got != "[group-01]" {
+		t.Errorf("GetGroupIdsByTag(cats) after UntagGroup returned %s", got)
+	}
+	if ids := allIds(t, r, "dogs", 10, groupsAccess.NewestFirst); len(ids) != 0 {
+		t.Errorf("GetGroupIdsByTag(dogs) after UntagGroup returned %v", ids)
+	}
+	//a tag nobody has anymore is not in the counts
+	if got := counts(t, r); len(got) != 1 || got["cats"] != 1 {
+		t.Errorf("GetTagCounts after UntagGroup returned %v, want cats:1", got)
+	}
+}
+
+func testTaggingTwiceCountsOnce(t *testing.T, r tagsAccess.Repository) {
+	for i := 0; i < 2; i++ {
+		if err := r.TagGroup(group(0), []string{"cats"}); err != nil {
+			t.Fatalf("TagGroup failed: %v", err)
+		}
+	}
+	if got := counts(t, r); got["cats"] != 1 {
+		t.Errorf("GetTagCounts after tagging twice returned %v, want cats:1", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.UntagGroup(group(0), []string{"cats"}); err != nil {
+			t.Fatalf("UntagGroup failed: %v", err)
+		}
+	}
+	//untagging a group that does not have the tag must not count it twice
+	if err := r.UntagGroup(group(1), []string{"cats"}); err != nil {
+		t.Fatalf("UntagGroup of an untagged group failed: %v", err)
+	}
+	if got := counts(t, r); len(got) != 0 {
+		t.Errorf("GetTagCounts after untagging returned %v, want nothing", got)
+	}
+}
+
+func testSortOrderAndPagination(t *testing.T, r tagsAccess.Repository) {
+	var want []string
+	for i := 0; i < 5; i++ {
+		if err := r.TagGroup(group(i), []string{"cats"}); err != nil {
+			t.Fatalf("TagGroup failed: %v", err)
+		}
+		want = append(want, group(i).Id)
+	}
+	//groups with another tag are never listed
+	if err := r.TagGroup(group(9), []string{"dogs"}); err != nil {
+		t.Fatalf("TagGroup failed: %v", err)
+	}
+
+	if got := allIds(t, r, "cats", 2, groupsAccess.OldestFirst); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GetGroupIdsByTag(oldest) returned %v, want %v", got, want)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(want)))
+	if got := allIds(t, r, "cats", 2, groupsAccess.NewestFirst); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GetGroupIdsByTag(newest) returned %v, want %v", got, want)
+	}
+}
+
+func testInvalidCursor(t *testing.T, r tagsAccess.Repository) {
+	for i := 0; i < 3; i++ {
+		if err := r.TagGroup(group(i), []string{"cats", "dogs"}); err != nil {
+			t.Fatalf("TagGroup failed: %v", err)
+		}
+	}
+
+	_, nk, err := r.GetGroupIdsByTag("cats", 1, "", groupsAccess.NewestFirst)
+	if err != nil {
+		t.Fatalf("GetGroupIdsByTag failed: %v", err)
+	}
+	if nk == "" {
+		t.Fatal("GetGroupIdsByTag returned no nextKey")
+	}
+
+	if _, _, err := r.GetGroupIdsByTag("cats", 1, "not-a-cursor", groupsAccess.NewestFirst); !cursor.IsInvalid(err) {
+		t.Errorf("GetGroupIdsByTag with a garbage cursor returned %v, want an invalid cursor error", err)
+	}
+	//a cursor of one tag cannot be used for another one
+	if _, _, err := r.GetGroupIdsByTag("dogs", 1, nk, groupsAccess.NewestFirst); !cursor.IsInvalid(err) {
+		t.Errorf("GetGroupIdsByTag(dogs) with a cursor of cats returned %v, want an invalid cursor error", err)
+	}
+	if _, _, err := r.GetGroupIdsByTag("cats", 1, nk, groupsAccess.OldestFirst); !cursor.IsInvalid(err) {
+		t.Errorf("GetGroupIdsByTag(oldest) with a cursor of the newest groups returned %v, want an invalid cursor error", err)
+	}
+}
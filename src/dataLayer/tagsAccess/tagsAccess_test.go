@@ -0,0 +1,65 @@
+package tagsAccess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess/tagsAccessTest"
+)
+
+// Like the groupsAccess tests, this only runs when DYNAMODB_ENDPOINT points at a DynamoDB, eg DynamoDB Local
+func TestTagDynamoDbRepository(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT is not set")
+	}
+
+	sess := session.Must(session.NewSession(aws.NewConfig().WithEndpoint(endpoint)))
+	client := dynamodb.New(sess)
+
+	n := 0
+	tagsAccessTest.TestRepository(t, func() tagsAccess.Repository {
+		n++
+		suffix := fmt.Sprintf("test-%d-%d", time.Now().UnixNano(), n)
+		groupTags := "GroupTags-" + suffix
+		tagCounts := "TagCounts-" + suffix
+		createTable(t, client, groupTags, "tag", "sortKey")
+		createTable(t, client, tagCounts, "tag", "")
+
+		return tagsAccess.NewDynamoDbRepoWithClient(client, groupTags, tagCounts)
+	})
+}
+
+// createTable creates a table with the same key schema as GroupTagsDynamoDBTable or TagCountsDynamoDBTable in serverless.yml
+func createTable(t *testing.T, client *dynamodb.DynamoDB, table string, hashKey string, rangeKey string) {
+	t.Helper()
+
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String(hashKey), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String(hashKey), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	}
+	if rangeKey != "" {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{AttributeName: aws.String(rangeKey), AttributeType: aws.String("S")})
+		input.KeySchema = append(input.KeySchema, &dynamodb.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: aws.String("RANGE")})
+	}
+
+	if _, err := client.CreateTable(input); err != nil {
+		t.Fatalf("failed to create table %s: %v", table, err)
+	}
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+	})
+}
@@ -0,0 +1,116 @@
+package tagsAccess
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/udacity/serverless-golang/src/cursor"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// TagMemoryRepository is the in-memory Adapter of our Repository Port. It keeps the sort keys of the groups of each tag
+type TagMemoryRepository struct {
+	mu   sync.RWMutex
+	tags map[string]map[string]string // tag -> sortKey -> group id
+}
+
+// NewMemoryRepo creates a new empty in-memory Repository
+func NewMemoryRepo() Repository {
+	return &TagMemoryRepository{tags: make(map[string]map[string]string)}
+}
+
+// TagGroup adds the group to the groups of every tag. Tagging a group twice with the same tag does nothing
+func (r *TagMemoryRepository) TagGroup(group models.Group, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range tags {
+		if r.tags[tag] == nil {
+			r.tags[tag] = make(map[string]string)
+		}
+		r.tags[tag][sortKey(group)] = group.Id
+	}
+
+	return nil
+}
+
+// UntagGroup removes the group from the groups of every tag
+func (r *TagMemoryRepository) UntagGroup(group models.Group, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range tags {
+		delete(r.tags[tag], sortKey(group))
+	}
+
+	return nil
+}
+
+// GetGroupIdsByTag gets the ids of the groups that have a tag, in the order they were created
+func (r *TagMemoryRepository) GetGroupIdsByTag(tag string, limit int64, nextKey string, order groupsAccess.SortOrder) ([]string, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope := tagScope(tag, order)
+
+	startKey, err := cursors.Decode(scope, nextKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys []string
+	for k := range r.tags[tag] {
+		keys = append(keys, k)
+	}
+	less := func(a, b string) bool { return a < b }
+	if order != groupsAccess.OldestFirst {
+		less = func(a, b string) bool { return a > b }
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+
+	start := 0
+	if sk, ok := startKey["sortKey"]; ok {
+		//just like ExclusiveStartKey, we start right after the key we were given
+		after := aws.StringValue(sk.S)
+		start = sort.Search(len(keys), func(i int) bool { return less(after, keys[i]) })
+	}
+
+	var ids []string
+	var last string
+	for _, k := range keys[start:] {
+		if int64(len(ids)) >= limit {
+			break
+		}
+		ids = append(ids, r.tags[tag][k])
+		last = k
+	}
+
+	//like DynamoDB, we return a LastEvaluatedKey whenever the Limit was reached
+	if len(ids) == 0 || int64(len(ids)) < limit {
+		return ids, "", nil
+	}
+
+	nk, err := cursors.Encode(scope, cursor.StringKey(map[string]string{"tag": tag, "sortKey": last}))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ids, nk, nil
+}
+
+// GetTagCounts returns the count of every tag that at least one group has, in no particular order
+func (r *TagMemoryRepository) GetTagCounts() ([]models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := []models.TagCount{}
+	for tag, groups := range r.tags {
+		if len(groups) > 0 {
+			counts = append(counts, models.TagCount{Tag: tag, Count: int64(len(groups))})
+		}
+	}
+
+	return counts, nil
+}
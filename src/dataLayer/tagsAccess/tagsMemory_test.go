@@ -0,0 +1,12 @@
+package tagsAccess_test
+
+import (
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess/tagsAccessTest"
+)
+
+func TestTagMemoryRepository(t *testing.T) {
+	tagsAccessTest.TestRepository(t, tagsAccess.NewMemoryRepo)
+}
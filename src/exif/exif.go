@@ -0,0 +1,234 @@
+/*
+Package exif reads the metadata cameras and phones write into the files of our images: the EXIF
+block of JPEGs and PNGs, and the XMP packet editors like Lightroom add. It is pure Go, so resizeImage
+needs no C library in its Lambda.
+
+We only read the few tags we store on an image, see Metadata. Everything else is skipped. Strip
+removes it all from a file, so the originals we make public don't tell where they were taken
+*/
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metadata is what we read from a file. A field the file does not have is empty
+type Metadata struct {
+	/*
+		When the photo was taken. Files that have the offset of the camera get it in UTC. Most cameras
+		don't write it, then this is the time on the clock of the camera, read as if it was UTC
+	*/
+	TakenAt     time.Time
+	Make        string
+	Model       string
+	Orientation int  // how to rotate and flip the pixels to show them upright, 1 to 8 like the EXIF tag
+	GPS         *GPS // where the photo was taken
+}
+
+// GPS is a position in decimal degrees, negative to the south and west
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// ErrMalformed is returned for an EXIF block or XMP packet we can't read. What was read before it is still returned
+var ErrMalformed = errors.New("malformed metadata")
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader    = []byte("Exif\x00\x00")
+	xmpHeader     = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+/*
+Read reads the metadata of a JPEG or PNG file. Other files, like gifs, have none. When a file has
+both, EXIF wins and XMP only fills in what EXIF does not have: the camera writes EXIF, XMP may come
+from any tool the photographer used since
+*/
+func Read(file []byte) (Metadata, error) {
+	var exifBlock, xmpPacket []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(file, jpegSignature):
+		exifBlock, xmpPacket, err = jpegSegments(file)
+	case bytes.HasPrefix(file, pngSignature):
+		exifBlock, xmpPacket, err = pngChunks(file)
+	default:
+		return Metadata{}, nil
+	}
+
+	m := Metadata{}
+	if exifBlock != nil {
+		if exifErr := readExif(exifBlock, &m); exifErr != nil && err == nil {
+			err = exifErr
+		}
+	}
+	if xmpPacket != nil {
+		if xmpErr := readXMP(xmpPacket, &m); xmpErr != nil && err == nil {
+			err = xmpErr
+		}
+	}
+
+	return m, err
+}
+
+/*
+jpegSegments finds the EXIF block and the XMP packet of a JPEG. They are both in APP1 segments, which
+come before the image data. More on the segments here https://en.wikipedia.org/wiki/JPEG#Syntax_and_structure
+*/
+func jpegSegments(file []byte) ([]byte, []byte, error) {
+	var exifBlock, xmpPacket []byte
+
+	for i := len(jpegSignature); i+4 <= len(file); {
+		if file[i] != 0xFF {
+			return exifBlock, xmpPacket, fmt.Errorf("%w: no JPEG marker at %d", ErrMalformed, i)
+		}
+		marker := file[i+1]
+		switch {
+		case marker == 0xFF: //a fill byte before the marker
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): //the markers that have no segment
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9: //the image data, or the end of the file. The metadata is before
+			return exifBlock, xmpPacket, nil
+		}
+
+		length := int(binary.BigEndian.Uint16(file[i+2:])) //includes the 2 bytes of the length
+		if length < 2 || i+2+length > len(file) {
+			return exifBlock, xmpPacket, fmt.Errorf("%w: JPEG segment at %d is too long", ErrMalformed, i)
+		}
+		segment := file[i+4 : i+2+length]
+
+		if marker == 0xE1 {
+			if bytes.HasPrefix(segment, exifHeader) && exifBlock == nil {
+				exifBlock = segment[len(exifHeader):]
+			} else if bytes.HasPrefix(segment, xmpHeader) && xmpPacket == nil {
+				xmpPacket = segment[len(xmpHeader):]
+			}
+		}
+		i += 2 + length
+	}
+
+	return exifBlock, xmpPacket, nil
+}
+
+/*
+pngChunks finds the EXIF block and the XMP packet of a PNG. The EXIF is in an eXIf chunk and the XMP in
+an iTXt chunk, which may come after the image data. More on the chunks here http://www.libpng.org/pub/png/spec/1.2/PNG-Chunks.html
+*/
+func pngChunks(file []byte) ([]byte, []byte, error) {
+	var exifBlock, xmpPacket []byte
+
+	for i := len(pngSignature); i+8 <= len(file); {
+		length := binary.BigEndian.Uint32(file[i:])
+		kind := string(file[i+4 : i+8])
+		if uint64(length)+12 > uint64(len(file)-i) { //the length, kind and CRC are 12 bytes
+			return exifBlock, xmpPacket, fmt.Errorf("%w: PNG chunk %s is too long", ErrMalformed, kind)
+		}
+		data := file[i+8 : i+8+int(length)]
+
+		switch kind {
+		case "eXIf":
+			//some tools write the header of the JPEG segment too
+			exifBlock = bytes.TrimPrefix(data, exifHeader)
+		case "iTXt":
+			if p, ok := pngXMP(data); ok {
+				xmpPacket = p
+			}
+		case "IEND":
+			return exifBlock, xmpPacket, nil
+		}
+		i += 12 + int(length)
+	}
+
+	return exifBlock, xmpPacket, nil
+}
+
+// readExif reads our tags from an EXIF block, which is a little TIFF file
+func readExif(block []byte, m *Metadata) error {
+	t, ifd0, err := newTIFF(block)
+	if err != nil {
+		return err
+	}
+
+	tags, err := t.ifd(ifd0)
+	if err != nil {
+		return err
+	}
+	m.Make = tags[tagMake].string()
+	m.Model = tags[tagModel].string()
+	if o := tags[tagOrientation].uint(t); o >= 1 && o <= 8 {
+		m.Orientation = int(o)
+	}
+
+	if e, ok := tags[tagExifIFD]; ok {
+		exifTags, err := t.ifd(e.uint(t))
+		if err != nil {
+			return err
+		}
+		//the time the photo was taken, or else the time it was scanned. DateTime in IFD0 is when the file was last changed
+		if at, ok := exifTime(exifTags[tagDateTimeOriginal].string(), exifTags[tagOffsetTimeOriginal].string()); ok {
+			m.TakenAt = at
+		} else if at, ok := exifTime(exifTags[tagDateTimeDigitized].string(), exifTags[tagOffsetTimeDigitized].string()); ok {
+			m.TakenAt = at
+		}
+	}
+
+	if g, ok := tags[tagGPSIFD]; ok {
+		gpsTags, err := t.ifd(g.uint(t))
+		if err != nil {
+			return err
+		}
+		lat, latOk := degrees(gpsTags[tagGPSLatitude].rationals(t), gpsTags[tagGPSLatitudeRef].string())
+		lon, lonOk := degrees(gpsTags[tagGPSLongitude].rationals(t), gpsTags[tagGPSLongitudeRef].string())
+		if latOk && lonOk {
+			m.GPS = newGPS(lat, lon)
+		}
+	}
+
+	return nil
+}
+
+// exifTime reads a time like "2021:05:01 12:00:00", with an offset like "+02:00" if the camera wrote one
+func exifTime(s string, offset string) (time.Time, bool) {
+	//cameras that don't know the time write blanks or zeros
+	if s == "" || strings.HasPrefix(s, "0000") || strings.Trim(s, ": ") == "" {
+		return time.Time{}, false
+	}
+
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", s+offset); err == nil {
+			return t.UTC(), true
+		}
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", s)
+	return t, err == nil
+}
+
+// degrees turns degrees, minutes and seconds into decimal degrees, negative to the south and west
+func degrees(dms []float64, ref string) (float64, bool) {
+	if len(dms) != 3 {
+		return 0, false
+	}
+	d := dms[0] + dms[1]/60 + dms[2]/3600
+	if ref == "S" || ref == "W" {
+		d = -d
+	}
+	return d, true
+}
+
+// newGPS returns the position, or nil if it is not on Earth
+func newGPS(lat float64, lon float64) *GPS {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil
+	}
+	return &GPS{Latitude: lat, Longitude: lon}
+}
@@ -0,0 +1,317 @@
+package exif
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+// a tag for buildExif, with its value already encoded
+type testTag struct {
+	id    uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+func asciiTag(id uint16, s string) testTag {
+	return testTag{id, typeASCII, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortTag(order binary.ByteOrder, id uint16, v uint16) testTag {
+	b := make([]byte, 2)
+	order.PutUint16(b, v)
+	return testTag{id, typeShort, 1, b}
+}
+
+func rationalsTag(order binary.ByteOrder, id uint16, values ...uint32) testTag {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		order.PutUint32(b[4*i:], v)
+	}
+	return testTag{id, typeRational, uint32(len(values) / 2), b}
+}
+
+// buildExif builds an EXIF block with those tags in IFD0, the Exif IFD and the GPS IFD
+func buildExif(order binary.ByteOrder, ifd0 []testTag, exifIFD []testTag, gpsIFD []testTag) []byte {
+	ifdSize := func(tags []testTag) int { return 2 + 12*len(tags) + 4 }
+	long := func(id uint16, v int) testTag {
+		b := make([]byte, 4)
+		order.PutUint32(b, uint32(v))
+		return testTag{id, typeLong, 1, b}
+	}
+
+	//the pointers to the other IFDs are tags of IFD0, so we count them before we know where the IFDs go
+	n0 := len(ifd0)
+	if exifIFD != nil {
+		n0++
+	}
+	if gpsIFD != nil {
+		n0++
+	}
+	at := 8 + 2 + 12*n0 + 4
+	if exifIFD != nil {
+		ifd0 = append(ifd0, long(tagExifIFD, at))
+		at += ifdSize(exifIFD)
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, long(tagGPSIFD, at))
+		at += ifdSize(gpsIFD)
+	}
+
+	var b, data bytes.Buffer
+	if order == binary.LittleEndian {
+		b.WriteString("II*\x00")
+	} else {
+		b.WriteString("MM\x00*")
+	}
+	binary.Write(&b, order, uint32(8))
+
+	for _, ifd := range [][]testTag{ifd0, exifIFD, gpsIFD} {
+		if ifd == nil {
+			continue
+		}
+		binary.Write(&b, order, uint16(len(ifd)))
+		for _, t := range ifd {
+			binary.Write(&b, order, t.id)
+			binary.Write(&b, order, t.kind)
+			binary.Write(&b, order, t.count)
+			if len(t.value) <= 4 {
+				b.Write(append(t.value, make([]byte, 4-len(t.value))...))
+			} else {
+				binary.Write(&b, order, uint32(at+data.Len()))
+				data.Write(t.value)
+			}
+		}
+		binary.Write(&b, order, uint32(0)) //there is no next IFD
+	}
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
+
+func testImage() image.Image {
+	return image.NewRGBA(image.Rect(0, 0, 8, 4))
+}
+
+// jpegWith encodes a small JPEG with those APP1 segments right after the start of the file, where cameras put them
+func jpegWith(t *testing.T, segments ...[]byte) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, testImage(), nil); err != nil {
+		t.Fatalf("jpeg.Encode failed: %v", err)
+	}
+
+	var app1 bytes.Buffer
+	for _, s := range segments {
+		app1.Write([]byte{0xFF, 0xE1})
+		binary.Write(&app1, binary.BigEndian, uint16(len(s)+2))
+		app1.Write(s)
+	}
+
+	file := b.Bytes()
+	return append(append(append([]byte{}, file[:2]...), app1.Bytes()...), file[2:]...)
+}
+
+// pngWith encodes a small PNG with those chunks right after its header
+func pngWith(t *testing.T, chunks map[string][]byte) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, testImage()); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+
+	var extra bytes.Buffer
+	for kind, data := range chunks {
+		binary.Write(&extra, binary.BigEndian, uint32(len(data)))
+		extra.WriteString(kind)
+		extra.Write(data)
+		binary.Write(&extra, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(kind), data...)))
+	}
+
+	file := b.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13 //the IHDR chunk comes first and has 13 bytes
+	return append(append(append([]byte{}, file[:ihdrEnd]...), extra.Bytes()...), file[ihdrEnd:]...)
+}
+
+func cameraExif(order binary.ByteOrder) []byte {
+	return buildExif(order,
+		[]testTag{asciiTag(tagMake, "Canon"), asciiTag(tagModel, "Canon EOS R5  "), shortTag(order, tagOrientation, 6)},
+		[]testTag{asciiTag(tagDateTimeOriginal, "2021:05:01 12:30:00"), asciiTag(tagOffsetTimeOriginal, "+02:00")},
+		//48°51'29.6"N 2°17'40.2"E, the Eiffel Tower
+		[]testTag{
+			asciiTag(tagGPSLatitudeRef, "N"), rationalsTag(order, tagGPSLatitude, 48, 1, 51, 1, 296, 10),
+			asciiTag(tagGPSLongitudeRef, "E"), rationalsTag(order, tagGPSLongitude, 2, 1, 17, 1, 402, 10),
+		},
+	)
+}
+
+func checkCamera(t *testing.T, m Metadata) {
+	t.Helper()
+
+	if m.Make != "Canon" || m.Model != "Canon EOS R5" || m.Orientation != 6 {
+		t.Errorf("Read returned the camera %q %q and orientation %d", m.Make, m.Model, m.Orientation)
+	}
+	if want := time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC); !m.TakenAt.Equal(want) {
+		t.Errorf("Read returned the time %v, want %v", m.TakenAt, want)
+	}
+	if m.GPS == nil || math.Abs(m.GPS.Latitude-48.858222) > 1e-6 || math.Abs(m.GPS.Longitude-2.294500) > 1e-6 {
+		t.Errorf("Read returned the position %+v", m.GPS)
+	}
+}
+
+func TestReadJPEG(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		file := jpegWith(t, append([]byte("Exif\x00\x00"), cameraExif(order)...))
+
+		m, err := Read(file)
+		if err != nil {
+			t.Fatalf("Read with %v failed: %v", order, err)
+		}
+		checkCamera(t, m)
+
+		//the file is still a JPEG we can decode
+		if _, _, err := image.Decode(bytes.NewReader(file)); err != nil {
+			t.Errorf("the test JPEG does not decode: %v", err)
+		}
+	}
+}
+
+func TestReadTimeWithoutOffset(t *testing.T) {
+	order := binary.BigEndian
+	//a scanner, and the blanks of a camera that does not know the time
+	exifBlock := buildExif(order, nil, []testTag{
+		asciiTag(tagDateTimeOriginal, "    :  :     :  :  "),
+		asciiTag(tagDateTimeDigitized, "1999:12:31 23:59:59"),
+	}, nil)
+
+	m, err := Read(jpegWith(t, append([]byte("Exif\x00\x00"), exifBlock...)))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if want := time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC); !m.TakenAt.Equal(want) {
+		t.Errorf("Read returned the time %v, want %v", m.TakenAt, want)
+	}
+	if m.GPS != nil || m.Make != "" || m.Orientation != 0 {
+		t.Errorf("Read returned %+v for a file that has only a time", m)
+	}
+}
+
+const testXMP = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:tiff="http://ns.adobe.com/tiff/1.0/"
+    xmlns:exif="http://ns.adobe.com/exif/1.0/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    tiff:Make="NIKON CORPORATION"
+    tiff:Orientation="3"
+    xmp:CreateDate="2020-01-01T00:00:00Z"
+    exif:GPSLatitude="33,51.5139S"
+    exif:GPSLongitude="151,12,54.6E">
+   <tiff:Model>NIKON Z 7</tiff:Model>
+   <exif:DateTimeOriginal>2021-05-01T12:30:00.50+02:00</exif:DateTimeOriginal>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+func TestReadXMP(t *testing.T) {
+	m, err := Read(jpegWith(t, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), testXMP...)))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if m.Make != "NIKON CORPORATION" || m.Model != "NIKON Z 7" || m.Orientation != 3 {
+		t.Errorf("Read returned the camera %q %q and orientation %d", m.Make, m.Model, m.Orientation)
+	}
+	//the time it was taken, not the time the file was created
+	if want := time.Date(2021, 5, 1, 10, 30, 0, 500000000, time.UTC); !m.TakenAt.Equal(want) {
+		t.Errorf("Read returned the time %v, want %v", m.TakenAt, want)
+	}
+	//the Sydney Opera House
+	if m.GPS == nil || math.Abs(m.GPS.Latitude+33.858565) > 1e-6 || math.Abs(m.GPS.Longitude-151.215167) > 1e-6 {
+		t.Errorf("Read returned the position %+v", m.GPS)
+	}
+}
+
+func TestExifWinsOverXMP(t *testing.T) {
+	file := jpegWith(t,
+		append([]byte("http://ns.adobe.com/xap/1.0/\x00"), testXMP...),
+		append([]byte("Exif\x00\x00"), cameraExif(binary.LittleEndian)...),
+	)
+
+	m, err := Read(file)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	checkCamera(t, m)
+}
+
+func TestReadPNG(t *testing.T) {
+	var xmp bytes.Buffer
+	w := zlib.NewWriter(&xmp)
+	w.Write([]byte(testXMP))
+	w.Close()
+
+	//a compressed XMP packet: the keyword, the compression flag and method, and no language or translated keyword
+	itxt := append([]byte("XML:com.adobe.xmp\x00\x01\x00\x00\x00"), xmp.Bytes()...)
+	m, err := Read(pngWith(t, map[string][]byte{"iTXt": itxt}))
+	if err != nil {
+		t.Fatalf("Read of the XMP of a PNG failed: %v", err)
+	}
+	if m.Model != "NIKON Z 7" {
+		t.Errorf("Read of the XMP of a PNG returned %+v", m)
+	}
+
+	m, err = Read(pngWith(t, map[string][]byte{"eXIf": cameraExif(binary.BigEndian)}))
+	if err != nil {
+		t.Fatalf("Read of the EXIF of a PNG failed: %v", err)
+	}
+	checkCamera(t, m)
+}
+
+func TestReadWithoutMetadata(t *testing.T) {
+	var b bytes.Buffer
+	if err := gif.Encode(&b, testImage(), nil); err != nil {
+		t.Fatalf("gif.Encode failed: %v", err)
+	}
+
+	for name, file := range map[string][]byte{"gif": b.Bytes(), "jpeg": jpegWith(t), "png": pngWith(t, nil), "empty": nil} {
+		if m, err := Read(file); err != nil || m != (Metadata{}) {
+			t.Errorf("Read of a %s without metadata returned %+v, %v", name, m, err)
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	//cut in the middle of the GPS IFD, which is after IFD0 and the Exif IFD. The values that don't fit in a tag are after it
+	exifBlock := cameraExif(binary.LittleEndian)[:120]
+	m, err := Read(jpegWith(t, append([]byte("Exif\x00\x00"), exifBlock...)))
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("Read of a cut off EXIF block returned %v, want %v", err, ErrMalformed)
+	}
+	if m.Orientation != 6 {
+		t.Errorf("Read of a cut off EXIF block returned %+v, want what is before the cut", m)
+	}
+
+	//no file we read can make us panic, wherever it is cut
+	file := jpegWith(t,
+		append([]byte("http://ns.adobe.com/xap/1.0/\x00"), testXMP...),
+		append([]byte("Exif\x00\x00"), cameraExif(binary.BigEndian)...),
+	)
+	for i := range file {
+		Read(file[:i])
+	}
+}
@@ -0,0 +1,285 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// ErrUnknownFormat is returned by Strip for a file that is not a JPEG, PNG or GIF
+var ErrUnknownFormat = errors.New("not a JPEG, PNG or GIF file")
+
+var gifSignatures = [][]byte{[]byte("GIF87a"), []byte("GIF89a")}
+
+/*
+Strip returns the file without its metadata: the EXIF block with the position and serial numbers, the
+XMP packet, the comments and anything after the end of the image. The pixels are not decoded, so
+nothing is lost. We only keep what it takes to show the image like before: the color profile and the
+Orientation, which we write in a new EXIF block of its own.
+
+Stripping a file Strip returned gives the same bytes, so a caller can tell whether there was anything to strip
+*/
+func Strip(file []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(file, jpegSignature):
+		return stripJPEG(file)
+	case bytes.HasPrefix(file, pngSignature):
+		return stripPNG(file)
+	case bytes.HasPrefix(file, gifSignatures[0]) || bytes.HasPrefix(file, gifSignatures[1]):
+		return stripGIF(file)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// orientation reads the Orientation of an EXIF block. Browsers ignore the one in XMP, so we do too
+func orientation(exifBlock []byte) int {
+	if exifBlock == nil {
+		return 0
+	}
+	m := Metadata{}
+	readExif(exifBlock, &m) //what is before a malformed tag is still read
+	return m.Orientation
+}
+
+// orientationExif is an EXIF block with only the Orientation in it, or nil when the image is already upright
+func orientationExif(o int) []byte {
+	if o <= 1 {
+		return nil
+	}
+
+	b := make([]byte, 8+2+12+4)
+	copy(b, "MM\x00*")
+	binary.BigEndian.PutUint32(b[4:], 8) //IFD0 comes right after the header
+	binary.BigEndian.PutUint16(b[8:], 1)
+	binary.BigEndian.PutUint16(b[10:], tagOrientation)
+	binary.BigEndian.PutUint16(b[12:], typeShort)
+	binary.BigEndian.PutUint32(b[14:], 1)
+	binary.BigEndian.PutUint16(b[18:], uint16(o))
+	//the offset of the next IFD stays 0, there is none
+	return b
+}
+
+// keepJPEGSegment tells if we keep an APPn or COM segment. The other segments are the image itself
+func keepJPEGSegment(marker byte, segment []byte) bool {
+	switch {
+	case marker == 0xE0: //JFIF says how big the pixels are
+		return bytes.HasPrefix(segment, []byte("JFIF\x00")) || bytes.HasPrefix(segment, []byte("JFXX\x00"))
+	case marker == 0xE2: //the color profile
+		return bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE: //Adobe says how to convert the colors
+		return bytes.HasPrefix(segment, []byte("Adobe"))
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false //EXIF, XMP, IPTC, the tags of the camera makers, comments...
+	}
+	return true
+}
+
+/*
+stripJPEG copies the segments of a JPEG we keep, then the image data up to the end of the image. Phones
+add more images after it, like previews and depth maps, each with its own EXIF, so we drop them too
+*/
+func stripJPEG(file []byte) ([]byte, error) {
+	exifBlock, _, err := jpegSegments(file)
+	if err != nil {
+		return nil, err
+	}
+	newExif := orientationExif(orientation(exifBlock))
+
+	out := bytes.NewBuffer(make([]byte, 0, len(file)))
+	out.Write(jpegSignature)
+	writeExif := func() {
+		if newExif == nil {
+			return
+		}
+		out.Write([]byte{0xFF, 0xE1})
+		binary.Write(out, binary.BigEndian, uint16(2+len(exifHeader)+len(newExif)))
+		out.Write(exifHeader)
+		out.Write(newExif)
+		newExif = nil
+	}
+
+	for i := len(jpegSignature); i+2 <= len(file); {
+		if file[i] != 0xFF {
+			return nil, fmt.Errorf("%w: no JPEG marker at %d", ErrMalformed, i)
+		}
+		marker := file[i+1]
+		switch {
+		case marker == 0xFF: //a fill byte before the marker
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			out.Write(file[i : i+2])
+			i += 2
+			continue
+		case marker == 0xD9:
+			writeExif()
+			out.Write(file[i : i+2])
+			return out.Bytes(), nil
+		}
+
+		if i+4 > len(file) {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(file[i+2:]))
+		if length < 2 || i+2+length > len(file) {
+			return nil, fmt.Errorf("%w: JPEG segment at %d is too long", ErrMalformed, i)
+		}
+		end := i + 2 + length
+
+		//EXIF wants to be the first segment, but JFIF wants that too. Most files put JFIF first
+		if marker != 0xE0 {
+			writeExif()
+		}
+		if keepJPEGSegment(marker, file[i+4:end]) {
+			out.Write(file[i:end])
+		}
+
+		if marker == 0xDA {
+			//the compressed data of the scan. A 0xFF in it is followed by 0x00 or a restart marker, anything else is the next marker
+			for end < len(file) {
+				if file[end] == 0xFF && end+1 < len(file) {
+					if next := file[end+1]; next != 0x00 && (next < 0xD0 || next > 0xD7) {
+						break
+					}
+					end++
+				}
+				end++
+			}
+			out.Write(file[i+2+length : end])
+		}
+		i = end
+	}
+
+	//the file ends before the end of the image. The decoder took it, so we keep what there is
+	writeExif()
+	return out.Bytes(), nil
+}
+
+// stripPNG copies the chunks of a PNG, except the ones with metadata. Its EXIF, if any, goes before the image data like the spec wants
+func stripPNG(file []byte) ([]byte, error) {
+	exifBlock, _, err := pngChunks(file)
+	if err != nil {
+		return nil, err
+	}
+	newExif := orientationExif(orientation(exifBlock))
+
+	out := bytes.NewBuffer(make([]byte, 0, len(file)))
+	out.Write(pngSignature)
+	for i := len(pngSignature); i+8 <= len(file); {
+		length := binary.BigEndian.Uint32(file[i:])
+		kind := string(file[i+4 : i+8])
+		end := i + 12 + int(length) //pngChunks already checked the lengths
+
+		switch kind {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+			//the metadata, the text(XMP is text too) and when the file was last changed
+		case "IDAT", "IEND":
+			if newExif != nil {
+				writePNGChunk(out, "eXIf", newExif)
+				newExif = nil
+			}
+			out.Write(file[i:end])
+		default:
+			out.Write(file[i:end])
+		}
+		if kind == "IEND" {
+			break
+		}
+		i = end
+	}
+
+	return out.Bytes(), nil
+}
+
+func writePNGChunk(out *bytes.Buffer, kind string, data []byte) {
+	binary.Write(out, binary.BigEndian, uint32(len(data)))
+	out.WriteString(kind)
+	out.Write(data)
+	binary.Write(out, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(kind), data...)))
+}
+
+// The application extensions of a GIF we keep: the ones that make animations loop, and the color profile
+var gifApplications = [][]byte{[]byte("NETSCAPE2.0"), []byte("ANIMEXTS1.0"), []byte("ICCRGBG1012")}
+
+/*
+stripGIF copies the blocks of a GIF, except the comments and the application extensions we don't
+know, like XMP. GIFs have no EXIF. More on the blocks here https://www.w3.org/Graphics/GIF/spec-gif89a.txt
+*/
+func stripGIF(file []byte) ([]byte, error) {
+	//the header, the screen descriptor, and the global color table if there is one
+	i := 13
+	if len(file) < i {
+		return nil, fmt.Errorf("%w: the GIF is too short", ErrMalformed)
+	}
+	if flags := file[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+	if i > len(file) {
+		return nil, fmt.Errorf("%w: the GIF color table is too long", ErrMalformed)
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(file)))
+	out.Write(file[:i])
+	for i < len(file) {
+		start := i
+		keep := true
+		switch file[i] {
+		case 0x3B: //the end of the file
+			out.WriteByte(0x3B)
+			return out.Bytes(), nil
+		case 0x21:
+			if i+2 > len(file) {
+				return nil, fmt.Errorf("%w: GIF extension at %d is cut off", ErrMalformed, i)
+			}
+			switch label := file[i+1]; label {
+			case 0xFE:
+				keep = false
+			case 0xFF:
+				keep = i+3+11 <= len(file) && file[i+2] == 11 && isGIFApplication(file[i+3:i+3+11])
+			}
+			i += 2
+		case 0x2C: //an image: its descriptor, its color table and the size of the LZW codes
+			if i+10 > len(file) {
+				return nil, fmt.Errorf("%w: GIF image at %d is cut off", ErrMalformed, i)
+			}
+			if flags := file[i+9]; flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i += 11
+		default:
+			return nil, fmt.Errorf("%w: unknown GIF block at %d", ErrMalformed, i)
+		}
+
+		//the data of the block, in sub-blocks that start with their size. An empty one ends it
+		for {
+			if i >= len(file) {
+				return nil, fmt.Errorf("%w: GIF block at %d is cut off", ErrMalformed, start)
+			}
+			n := int(file[i])
+			i += 1 + n
+			if n == 0 {
+				break
+			}
+		}
+		if i > len(file) {
+			return nil, fmt.Errorf("%w: GIF block at %d is cut off", ErrMalformed, start)
+		}
+		if keep {
+			out.Write(file[start:i])
+		}
+	}
+
+	//no end. The decoder took it, so we keep what there is
+	return out.Bytes(), nil
+}
+
+func isGIFApplication(id []byte) bool {
+	for _, a := range gifApplications {
+		if bytes.Equal(id, a) {
+			return true
+		}
+	}
+	return false
+}
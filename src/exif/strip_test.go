@@ -0,0 +1,109 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"testing"
+)
+
+// checkStripped checks that a stripped file still decodes, has only the orientation left, and that stripping it again changes nothing
+func checkStripped(t *testing.T, name string, stripped []byte, orientation int) {
+	t.Helper()
+
+	if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("the stripped %s does not decode: %v", name, err)
+	}
+	m, err := Read(stripped)
+	if err != nil || m != (Metadata{Orientation: orientation}) {
+		t.Errorf("the stripped %s has the metadata %+v, %v, want only the orientation %d", name, m, err, orientation)
+	}
+	again, err := Strip(stripped)
+	if err != nil || !bytes.Equal(again, stripped) {
+		t.Errorf("stripping the stripped %s again changed it, %v", name, err)
+	}
+}
+
+func TestStripJPEG(t *testing.T) {
+	file := jpegWith(t,
+		append([]byte("Exif\x00\x00"), cameraExif(binary.LittleEndian)...),
+		append([]byte("http://ns.adobe.com/xap/1.0/\x00"), testXMP...),
+	)
+	file = append(file, jpegWith(t, append([]byte("Exif\x00\x00"), cameraExif(binary.BigEndian)...))...) //a preview after the end of the image
+
+	stripped, err := Strip(file)
+	if err != nil {
+		t.Fatalf("Strip of a JPEG failed: %v", err)
+	}
+	checkStripped(t, "JPEG", stripped, 6)
+	if bytes.Contains(stripped, []byte("Canon")) {
+		t.Errorf("the stripped JPEG still has the camera, or the preview")
+	}
+
+	//the pixels are copied as they are
+	clean := jpegWith(t)
+	if stripped, err := Strip(clean); err != nil || !bytes.Equal(stripped, clean) {
+		t.Errorf("Strip of a JPEG without metadata changed it, %v", err)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	file := pngWith(t, map[string][]byte{
+		"eXIf": cameraExif(binary.BigEndian),
+		"tEXt": []byte("Comment\x00at home"),
+	})
+
+	stripped, err := Strip(file)
+	if err != nil {
+		t.Fatalf("Strip of a PNG failed: %v", err)
+	}
+	checkStripped(t, "PNG", stripped, 6)
+	if bytes.Contains(stripped, []byte("at home")) {
+		t.Errorf("the stripped PNG still has its text")
+	}
+}
+
+func TestStripGIF(t *testing.T) {
+	var b bytes.Buffer
+	if err := gif.Encode(&b, testImage(), nil); err != nil {
+		t.Fatalf("gif.Encode failed: %v", err)
+	}
+	clean := b.Bytes()
+
+	//a comment and an XMP packet right before the image, after the global color table gif.Encode writes
+	at := 13 + 3<<(clean[10]&0x07+1)
+	comment := append([]byte{0x21, 0xFE, 7}, "at home\x00"...)
+	xmp := append(append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...), 4, 'x', 'm', 'p', '!', 0)
+	file := append(append(append(append([]byte{}, clean[:at]...), comment...), xmp...), clean[at:]...)
+
+	stripped, err := Strip(file)
+	if err != nil {
+		t.Fatalf("Strip of a GIF failed: %v", err)
+	}
+	if !bytes.Equal(stripped, clean) {
+		t.Errorf("Strip of a GIF kept %d bytes, want the %d of the GIF without metadata", len(stripped), len(clean))
+	}
+	checkStripped(t, "GIF", stripped, 0)
+}
+
+func TestStripUnknownOrMalformed(t *testing.T) {
+	if _, err := Strip([]byte("BM a bitmap")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Strip of a bitmap returned %v, want %v", err, ErrUnknownFormat)
+	}
+
+	//no file we strip can make us panic, wherever it is cut
+	files := [][]byte{
+		jpegWith(t, append([]byte("Exif\x00\x00"), cameraExif(binary.BigEndian)...)),
+		pngWith(t, map[string][]byte{"eXIf": cameraExif(binary.LittleEndian)}),
+	}
+	var b bytes.Buffer
+	gif.Encode(&b, testImage(), nil)
+	files = append(files, b.Bytes())
+	for _, file := range files {
+		for i := range file {
+			Strip(file[:i])
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// The tags we read. More on them here https://exiftool.org/TagNames/EXIF.html and https://exiftool.org/TagNames/GPS.html
+const (
+	tagMake        = 0x010F
+	tagModel       = 0x0110
+	tagOrientation = 0x0112
+	tagExifIFD     = 0x8769 // where the Exif IFD is
+	tagGPSIFD      = 0x8825 // where the GPS IFD is
+
+	tagDateTimeOriginal    = 0x9003
+	tagDateTimeDigitized   = 0x9004
+	tagOffsetTimeOriginal  = 0x9011
+	tagOffsetTimeDigitized = 0x9012
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+)
+
+// The types of the values of the tags, and how many bytes one value takes
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+var typeSizes = map[uint16]uint64{
+	typeByte:      1,
+	typeASCII:     1,
+	typeShort:     2,
+	typeLong:      4,
+	typeRational:  8,
+	typeUndefined: 1,
+	typeSLong:     4,
+	typeSRational: 8,
+}
+
+// An IFD has a few dozen tags. More than this is a broken or hostile file
+const maxTags = 1000
+
+/*
+tiff is the TIFF file in an EXIF block. It is a list of IFDs, each a list of tags, that point at
+each other and at their values with offsets from the start of the block. Any offset may point
+outside of it, so we check them all
+*/
+type tiff struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+// a tag of an IFD, with its value
+type entry struct {
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// newTIFF reads the header of a TIFF file and returns the offset of its first IFD
+func newTIFF(b []byte) (*tiff, uint32, error) {
+	if len(b) < 8 {
+		return nil, 0, fmt.Errorf("%w: the EXIF block is too short", ErrMalformed)
+	}
+
+	t := &tiff{b: b}
+	switch string(b[:4]) {
+	case "II*\x00":
+		t.order = binary.LittleEndian
+	case "MM\x00*":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("%w: the EXIF block is not a TIFF file", ErrMalformed)
+	}
+
+	return t, t.order.Uint32(b[4:]), nil
+}
+
+// ifd reads the tags of the IFD at that offset, by tag
+func (t *tiff) ifd(offset uint32) (map[uint16]entry, error) {
+	if uint64(offset)+2 > uint64(len(t.b)) {
+		return nil, fmt.Errorf("%w: IFD at %d is outside of the EXIF block", ErrMalformed, offset)
+	}
+	n := int(t.order.Uint16(t.b[offset:]))
+	if n > maxTags || uint64(offset)+2+uint64(n)*12 > uint64(len(t.b)) {
+		return nil, fmt.Errorf("%w: IFD at %d is too long", ErrMalformed, offset)
+	}
+
+	tags := make(map[uint16]entry, n)
+	for i := 0; i < n; i++ {
+		//each tag is 12 bytes: the tag, the type, the count and the value, or its offset when it does not fit in 4 bytes
+		e := t.b[int(offset)+2+i*12:]
+		kind := t.order.Uint16(e[2:])
+		count := t.order.Uint32(e[4:])
+
+		size, ok := typeSizes[kind]
+		if !ok {
+			continue //a type we never read
+		}
+		size *= uint64(count)
+
+		var value []byte
+		if size <= 4 {
+			value = e[8 : 8+size]
+		} else {
+			at := uint64(t.order.Uint32(e[8:]))
+			if at+size > uint64(len(t.b)) {
+				continue //we can still read the other tags
+			}
+			value = t.b[at : at+size]
+		}
+		tags[t.order.Uint16(e)] = entry{kind, count, value}
+	}
+
+	return tags, nil
+}
+
+// string returns the value of an ASCII tag, without the NUL at the end and the padding some cameras add
+func (e entry) string() string {
+	if e.kind != typeASCII {
+		return ""
+	}
+	s := string(e.value)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// uint returns the first value of a SHORT or LONG tag, or 0
+func (e entry) uint(t *tiff) uint32 {
+	switch {
+	case e.kind == typeShort && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value))
+	case e.kind == typeLong && len(e.value) >= 4:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// rationals returns the values of a RATIONAL tag, or nil if one of them divides by zero
+func (e entry) rationals(t *tiff) []float64 {
+	if e.kind != typeRational {
+		return nil
+	}
+
+	values := make([]float64, 0, e.count)
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num, den := t.order.Uint32(e.value[i:]), t.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values
+}
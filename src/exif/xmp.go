@@ -0,0 +1,194 @@
+package exif
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The namespaces of the XMP properties we read. More on them here https://exiftool.org/TagNames/XMP.html
+const (
+	nsTIFF      = "http://ns.adobe.com/tiff/1.0/"
+	nsExif      = "http://ns.adobe.com/exif/1.0/"
+	nsXMP       = "http://ns.adobe.com/xap/1.0/"
+	nsPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+)
+
+// The most we inflate of a compressed XMP packet in a PNG. They are a few KB
+const maxXMPSize = 1 << 20
+
+// The XMP properties we read
+var (
+	xmpMake         = xml.Name{Space: nsTIFF, Local: "Make"}
+	xmpModel        = xml.Name{Space: nsTIFF, Local: "Model"}
+	xmpOrientation  = xml.Name{Space: nsTIFF, Local: "Orientation"}
+	xmpGPSLatitude  = xml.Name{Space: nsExif, Local: "GPSLatitude"}
+	xmpGPSLongitude = xml.Name{Space: nsExif, Local: "GPSLongitude"}
+	// the first one a tool wrote wins: the time the photo was taken comes before the time the file was created
+	xmpTakenAt = []xml.Name{
+		{Space: nsExif, Local: "DateTimeOriginal"},
+		{Space: nsPhotoshop, Local: "DateCreated"},
+		{Space: nsXMP, Local: "CreateDate"},
+	}
+)
+
+/*
+readXMP fills in what the EXIF block did not have from an XMP packet. XMP is RDF, where a property
+can be an attribute of an rdf:Description or an element in it, so we look for both and don't care
+about the rest of the structure
+*/
+func readXMP(packet []byte, m *Metadata) error {
+	values, err := xmpValues(packet)
+
+	if m.Make == "" {
+		m.Make = values[xmpMake]
+	}
+	if m.Model == "" {
+		m.Model = values[xmpModel]
+	}
+	if o, _ := strconv.Atoi(values[xmpOrientation]); m.Orientation == 0 && o >= 1 && o <= 8 {
+		m.Orientation = o
+	}
+	if m.TakenAt.IsZero() {
+		for _, p := range xmpTakenAt {
+			if at, ok := xmpTime(values[p]); ok {
+				m.TakenAt = at
+				break
+			}
+		}
+	}
+	if m.GPS == nil {
+		lat, latOk := xmpDegrees(values[xmpGPSLatitude])
+		lon, lonOk := xmpDegrees(values[xmpGPSLongitude])
+		if latOk && lonOk {
+			m.GPS = newGPS(lat, lon)
+		}
+	}
+
+	return err
+}
+
+// xmpValues returns the values of the properties we read in a packet, by name
+func xmpValues(packet []byte) (map[xml.Name]string, error) {
+	wanted := map[xml.Name]bool{xmpMake: true, xmpModel: true, xmpOrientation: true, xmpGPSLatitude: true, xmpGPSLongitude: true}
+	for _, p := range xmpTakenAt {
+		wanted[p] = true
+	}
+
+	values := map[xml.Name]string{}
+	d := xml.NewDecoder(bytes.NewReader(packet))
+	var current xml.Name //the property whose value may be the text we read next
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return values, fmt.Errorf("%w: %s", ErrMalformed, err.Error())
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			for _, a := range tok.Attr {
+				if wanted[a.Name] {
+					values[a.Name] = strings.TrimSpace(a.Value)
+				}
+			}
+			current = tok.Name
+		case xml.CharData:
+			if s := strings.TrimSpace(string(tok)); s != "" && wanted[current] {
+				values[current] = s
+			}
+		case xml.EndElement:
+			current = xml.Name{}
+		}
+	}
+}
+
+// The formats of the dates of XMP, which is ISO 8601 with as much precision as the tool had
+var xmpTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04-07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// xmpTime reads a date of XMP. Like exifTime, a date without an offset is read as if it was UTC
+func xmpTime(s string) (time.Time, bool) {
+	for _, f := range xmpTimeFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// xmpDegrees reads a GPS coordinate of XMP, like "37,46.494N" or "37,46,29.64N"
+func xmpDegrees(s string) (float64, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	ref := s[len(s)-1:]
+	if !strings.Contains("NSEW", ref) {
+		return 0, false
+	}
+
+	dms := []float64{0, 0, 0}
+	parts := strings.Split(s[:len(s)-1], ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		dms[i] = v
+	}
+
+	return degrees(dms, ref)
+}
+
+/*
+pngXMP returns the XMP packet in an iTXt chunk, if that is what the chunk has. The chunk is the
+keyword, a NUL, the compression flag and method, the language, a NUL, the translated keyword, a NUL
+and the text, deflated when the flag is 1
+*/
+func pngXMP(data []byte) ([]byte, bool) {
+	const keyword = "XML:com.adobe.xmp\x00"
+	if !bytes.HasPrefix(data, []byte(keyword)) || len(data) < len(keyword)+2 {
+		return nil, false
+	}
+	compressed := data[len(keyword)] == 1
+	rest := data[len(keyword)+2:]
+
+	//skip the language and the translated keyword
+	for i := 0; i < 2; i++ {
+		n := bytes.IndexByte(rest, 0)
+		if n < 0 {
+			return nil, false
+		}
+		rest = rest[n+1:]
+	}
+
+	if !compressed {
+		return rest, true
+	}
+	r, err := zlib.NewReader(bytes.NewReader(rest))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+	packet, err := ioutil.ReadAll(io.LimitReader(r, maxXMPSize))
+	if err != nil {
+		return nil, false
+	}
+	return packet, true
+}
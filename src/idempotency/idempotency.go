@@ -0,0 +1,134 @@
+/*
+Package idempotency lets a client retry a request that creates something without creating it twice.
+
+The client sends an Idempotency-Key header with a value it makes up, eg a uuid, and sends the same one
+again when it retries. The first request with a key is handled as usual, and we store its response.
+A retry gets the stored response back instead of being handled again.
+
+A key belongs to the user that sent it, and to one request: the same key with another method, path
+or body gets a 422, since it is most likely a bug in the client
+*/
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+const (
+	HeaderName     = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed" // set on the responses we send again for a retry
+	MaxKeyLength   = 255
+)
+
+var (
+	// TTL is how long we remember a key. A client should not retry after that
+	TTL = 24 * time.Hour
+	// LockTimeout is how long a request can hold its key without answering. It is longer than the timeout of our Lambdas
+	LockTimeout = 30 * time.Second
+)
+
+/*
+Handle calls handle once per Idempotency-Key and user, and returns its response. A retry gets the
+same response without calling handle. Requests without the header are always handled.
+
+We only store the responses that would be the same if we handled the request again. After a 429 or
+a 5xx we forget the key, so that a retry is handled again. So handle must not fail once it stored
+something, or the retry stores it a second time: CreateGroup and CreateImage do their last fallible
+step before they write, or only log the ones after
+*/
+func Handle(repo idempotencyAccess.Repository, req events.APIGatewayProxyRequest, userId string, handle func() events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	key := requests.Header(req.Headers, HeaderName)
+	if key == "" {
+		return handle()
+	}
+	if !validKey(key) {
+		return apperrors.Response(apperrors.Invalid("Idempotency-Key must be 1 to 255 printable characters"))
+	}
+
+	now := time.Now()
+	rec := models.IdempotencyRecord{
+		Key:         userId + ":" + key,
+		RequestHash: requestHash(req),
+		LockedUntil: now.Add(LockTimeout).Unix(),
+		ExpiresAt:   now.Add(TTL).Unix(),
+	}
+
+	existing, claimed, err := repo.Claim(rec, now)
+	if err != nil {
+		log.Printf("Failed to claim the Idempotency-Key: Error message was %s", err.Error())
+		return apperrors.Response(err)
+	}
+	if !claimed {
+		return replay(existing, rec.RequestHash)
+	}
+
+	resp := handle()
+
+	if resp.StatusCode == 429 || resp.StatusCode >= 500 {
+		if err := repo.Release(rec); err != nil {
+			//the key is freed anyway when its lock runs out
+			log.Printf("Failed to release the Idempotency-Key: Error message was %s", err.Error())
+		}
+		return resp
+	}
+
+	rec.StatusCode = resp.StatusCode
+	rec.Headers = resp.Headers
+	rec.Body = resp.Body
+	if err := repo.Complete(rec); err != nil {
+		//we still answer, the client just can't replay this response
+		log.Printf("Failed to store the response of the Idempotency-Key: Error message was %s", err.Error())
+	}
+
+	return resp
+}
+
+// replay answers a request whose key is already taken
+func replay(existing models.IdempotencyRecord, hash string) events.APIGatewayProxyResponse {
+	if existing.RequestHash != hash {
+		return apperrors.ResponseWithStatus(422, apperrors.Conflict("Idempotency-Key was already used for another request"))
+	}
+	if !existing.Completed() {
+		return apperrors.Response(apperrors.Conflict("a request with this Idempotency-Key is still being handled, try again later"))
+	}
+
+	headers := map[string]string{ReplayedHeader: "true"}
+	for k, v := range existing.Headers {
+		headers[k] = v
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: existing.StatusCode,
+		Headers:    headers,
+		Body:       existing.Body,
+	}
+}
+
+// requestHash tells two requests apart. A retry sends exactly the same method, path and body
+func requestHash(req events.APIGatewayProxyRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.HTTPMethod + " " + req.Path + "\n"))
+	h.Write([]byte(req.Body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validKey(key string) bool {
+	if len(key) > MaxKeyLength {
+		return false
+	}
+	for _, c := range key {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+func request(key, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/groups",
+		Headers:    map[string]string{"idempotency-key": key}, //http/2 clients send lower case headers
+		Body:       body,
+	}
+}
+
+// counter is a handler that counts how many times it was called, and answers with status
+func counter(calls *int, status int) func() events.APIGatewayProxyResponse {
+	return func() events.APIGatewayProxyResponse {
+		*calls++
+		return events.APIGatewayProxyResponse{StatusCode: status, Body: `{"n":` + strconv.Itoa(*calls) + `}`}
+	}
+}
+
+func TestRetryReplaysTheResponse(t *testing.T) {
+	repo := idempotencyAccess.NewMemoryRepo()
+	calls := 0
+
+	first := Handle(repo, request("k1", `{"name":"Cats"}`), "user-1", counter(&calls, 201))
+	retry := Handle(repo, request("k1", `{"name":"Cats"}`), "user-1", counter(&calls, 201))
+
+	if calls != 1 {
+		t.Errorf("the handler was called %d times, want 1", calls)
+	}
+	if retry.StatusCode != 201 || retry.Body != first.Body {
+		t.Errorf("the retry got %d %s, want %d %s", retry.StatusCode, retry.Body, first.StatusCode, first.Body)
+	}
+	if retry.Headers[ReplayedHeader] != "true" {
+		t.Errorf("the retry does not have the %s header", ReplayedHeader)
+	}
+
+	//keys belong to a user
+	Handle(repo, request("k1", `{"name":"Cats"}`), "user-2", counter(&calls, 201))
+	if calls != 2 {
+		t.Errorf("the same key of another user was not handled")
+	}
+}
+
+func TestSameKeyWithAnotherBody(t *testing.T) {
+	repo := idempotencyAccess.NewMemoryRepo()
+	calls := 0
+
+	Handle(repo, request("k1", `{"name":"Cats"}`), "user-1", counter(&calls, 201))
+	resp := Handle(repo, request("k1", `{"name":"Dogs"}`), "user-1", counter(&calls, 201))
+
+	if resp.StatusCode != 422 {
+		t.Errorf("the same key with another body got %d, want 422", resp.StatusCode)
+	}
+	if calls != 1 {
+		t.Errorf("the handler was called %d times, want 1", calls)
+	}
+}
+
+func TestServerErrorsAreNotReplayed(t *testing.T) {
+	repo := idempotencyAccess.NewMemoryRepo()
+	calls := 0
+
+	if resp := Handle(repo, request("k1", "{}"), "user-1", counter(&calls, 503)); resp.StatusCode != 503 {
+		t.Fatalf("got %d, want 503", resp.StatusCode)
+	}
+	if resp := Handle(repo, request("k1", "{}"), "user-1", counter(&calls, 201)); resp.StatusCode != 201 {
+		t.Errorf("the retry after a 503 got %d, want 201", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Errorf("the handler was called %d times, want 2", calls)
+	}
+}
+
+func TestRequestInProgress(t *testing.T) {
+	repo := idempotencyAccess.NewMemoryRepo()
+	calls := 0
+
+	//another Lambda is handling the same request right now
+	now := time.Now()
+	repo.Claim(models.IdempotencyRecord{
+		Key:         "user-1:k1",
+		RequestHash: requestHash(request("k1", "{}")),
+		LockedUntil: now.Add(time.Minute).Unix(),
+		ExpiresAt:   now.Add(time.Hour).Unix(),
+	}, now)
+
+	if resp := Handle(repo, request("k1", "{}"), "user-1", counter(&calls, 201)); resp.StatusCode != 409 {
+		t.Errorf("a retry while the request is in progress got %d, want 409", resp.StatusCode)
+	}
+	if calls != 0 {
+		t.Errorf("the handler was called %d times, want 0", calls)
+	}
+}
+
+func TestWithoutAKey(t *testing.T) {
+	repo := idempotencyAccess.NewMemoryRepo()
+	calls := 0
+
+	req := request("", "{}")
+	Handle(repo, req, "user-1", counter(&calls, 201))
+	Handle(repo, req, "user-1", counter(&calls, 201))
+	if calls != 2 {
+		t.Errorf("the handler was called %d times without a key, want 2", calls)
+	}
+
+	for _, key := range []string{strings.Repeat("k", MaxKeyLength+1), "with space", "é"} {
+		if resp := Handle(repo, request(key, "{}"), "user-1", counter(&calls, 201)); resp.StatusCode != 400 {
+			t.Errorf("key %q got %d, want 400", key, resp.StatusCode)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/auth"
 )
 
 type Event events.APIGatewayCustomAuthorizerRequest
@@ -79,14 +80,15 @@ func auth0AuthorizerHandler(event Event) (Response, error) {
 		https://stackoverflow.com/questions/51834234/i-have-a-public-key-and-a-jwt-how-do-i-check-if-its-valid-in-go
 		https://brunoscheufler.com/blog/2020-04-11-verifying-asymmetrically-signed-jwts-in-go
 	*/
-	if bearerToken != "123" {
-		log.Println("User was not authorized: Invalid token")
+	userId, err := auth.UserIdFromToken(bearerToken) //see src/auth/utils.go
+	if err != nil {
+		log.Printf("User was not authorized: %s", err.Error())
 		return Response{}, errors.New("Unauthorized") // Return a 401 Unauthorized response
 		//other ways to use new Error to have your function return an error in go https://www.geeksforgeeks.org/errors-new-function-in-golang-with-examples/
 	}
 
 	//At this point, there are no exceptions and the request has been authorized
-	return generatePolicy("user", "Allow", event.MethodArn), nil
+	return generatePolicy(userId, "Allow", event.MethodArn), nil
 }
 
 func generatePolicy(principalID, effect, resource string) Response {
@@ -104,6 +106,15 @@ func generatePolicy(principalID, effect, resource string) Response {
 			},
 		}
 	}
-	//authResponse.Context = context //optional. I did not want to use it. More on this here https://github.com/aws/aws-lambda-go/blob/master/events/README_ApiGatewayCustomAuthorizer.md
+	/*
+		Whatever we put in the context is passed to the functions behind this authorizer in
+		requestContext.authorizer. We use it to tell them who the user is. More on this here
+		https://github.com/aws/aws-lambda-go/blob/master/events/README_ApiGatewayCustomAuthorizer.md
+	*/
+	if effect == "Allow" {
+		authResponse.Context = map[string]interface{}{
+			auth.UserIdContextKey: principalID,
+		}
+	}
 	return authResponse
 }
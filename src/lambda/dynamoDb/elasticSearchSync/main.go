@@ -17,16 +17,31 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/searchAccess"
 )
 
 type DynamoDBStreamEvent events.DynamoDBEvent
 
 type Body struct {
-	ImageId   string `json:"imageId"`
-	GroupId   string `json:"groupId"`
-	Title     string `json:"title"`
-	Timestamp string `json:"timestamp"`
-	ImageUrl  string `json:"imageUrl"`
+	ImageId     string   `json:"imageId"`
+	GroupId     string   `json:"groupId"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	AltText     string   `json:"altText,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Timestamp   string   `json:"timestamp"`
+	ImageUrl    string   `json:"imageUrl"`
+	/*
+		What resizeImage read from the file, see models.ImageMetadata. Not the location: anybody can
+		search the index, and only the members of a group may know where its photos were taken
+	*/
+	Width       int64  `json:"width,omitempty"`
+	Height      int64  `json:"height,omitempty"`
+	TakenAt     string `json:"takenAt,omitempty"`
+	CameraMake  string `json:"cameraMake,omitempty"`
+	CameraModel string `json:"cameraModel,omitempty"`
+	Orientation int64  `json:"orientation,omitempty"`
 }
 
 type AWSUser struct {
@@ -52,6 +67,9 @@ var (
 		and we wil save some money by not calling secretManager
 	*/
 	cachedSecretObj *AWSUser
+
+	imagesRepo = imagesAccess.NewRepo()
+	searchRepo = searchAccess.NewRepo() // deletes the documents, see the REMOVE records
 )
 
 func init() {
@@ -135,31 +153,65 @@ func elasticSearchSyncHandler(e DynamoDBStreamEvent) error {
 		fmt.Printf("Processing request data for event ID %s, type %s.\n", record.EventID, record.EventName)
 
 		/*
-			If the eventName is not 'INSERT', we will skip this record.
+			An INSERT adds the document of an image and a MODIFY (eg PATCH /images/{imageId}) replaces it,
+			so we PUT the whole item in both cases. A REMOVE deletes it.
 
-			However, in a complete solution, we will remove record from ES if they are removed(REMOVE) from dynamodb
-			or update ES record if they are updated(MODIFY) in dynamoDb. Eg like in the link
+			The cleanup of deleteImage deletes the document too(see images.ImageDeleter), but it runs alongside
+			us: a MODIFY we handle after it would write the document back. The records of an item come in
+			order, so the REMOVE after that MODIFY deletes it for good
 			https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_streams_Record.html
 		*/
-		if record.EventName != "INSERT" {
+		if record.EventName == "REMOVE" {
+			id := stringAttr(record.Change.OldImage, "imageId")
+			if id == "" {
+				continue //removed before the stream had the old items
+			}
+			if err := searchRepo.DeleteImage(id); err != nil {
+				log.Printf("Failed to delete the search document of image %s: Error message was %s", id, err.Error())
+			}
+			continue
+		}
+		if record.EventName != "INSERT" && record.EventName != "MODIFY" {
 			continue
 		}
 
-		//Get the item that was added to dynaoDb
+		//Get the item as it is now in dynaoDb
 		newItem := record.Change.NewImage
 
-		id := newItem["imageId"].String()
+		//an image whose file was not uploaded yet would be a broken search result. It is indexed by the MODIFY that makes it uploaded
+		if stringAttr(newItem, "status") == "pending" {
+			continue
+		}
+
+		id := stringAttr(newItem, "imageId")
+
+		//the image was deleted since, and its REMOVE may already be handled. We must not index it again
+		if _, err := imagesRepo.GetImage(id); err == imagesAccess.ErrImageNotFound {
+			continue
+		} else if err != nil {
+			log.Printf("Failed to check that image %s still exists, indexing it anyway: Error message was %s", id, err.Error())
+		}
 
 		/*
 			We create the document that we want to store in ElasticSearch.
-			We basically copied all fields from our dynamoDb item to our struct
+			We basically copied all fields from our dynamoDb item to our struct.
+			The fields added with PATCH /images/{imageId} are not there for older images, so we don't assume them
 		*/
 		b := Body{
-			ImageId:   newItem["imageId"].String(),
-			Timestamp: newItem["timestamp"].String(),
-			GroupId:   newItem["groupId"].String(),
-			ImageUrl:  newItem["imageUrl"].String(),
-			Title:     newItem["title"].String(),
+			ImageId:     id,
+			Timestamp:   stringAttr(newItem, "timestamp"),
+			GroupId:     stringAttr(newItem, "groupId"),
+			ImageUrl:    stringAttr(newItem, "imageUrl"),
+			Title:       stringAttr(newItem, "title"),
+			Description: stringAttr(newItem, "description"),
+			AltText:     stringAttr(newItem, "altText"),
+			Tags:        stringsAttr(newItem, "tags"),
+			Width:       intAttr(newItem, "width"),
+			Height:      intAttr(newItem, "height"),
+			TakenAt:     stringAttr(newItem, "takenAt"),
+			CameraMake:  stringAttr(newItem, "cameraMake"),
+			CameraModel: stringAttr(newItem, "cameraModel"),
+			Orientation: intAttr(newItem, "orientation"),
 		}
 
 		// JSON document to be included as the request body
@@ -192,6 +244,41 @@ func elasticSearchSyncHandler(e DynamoDBStreamEvent) error {
 	return nil
 }
 
+// stringAttr returns a string attribute of a stream image, or "" when it is not there
+func stringAttr(image map[string]events.DynamoDBAttributeValue, name string) string {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeString {
+		return ""
+	}
+	return v.String()
+}
+
+// stringsAttr returns a list of strings of a stream image, or nil when it is not there
+func stringsAttr(image map[string]events.DynamoDBAttributeValue, name string) []string {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeList {
+		return nil
+	}
+
+	var s []string
+	for _, item := range v.List() {
+		if item.DataType() == events.DataTypeString {
+			s = append(s, item.String())
+		}
+	}
+	return s
+}
+
+// intAttr returns a number attribute of a stream image, or 0 when it is not there
+func intAttr(image map[string]events.DynamoDBAttributeValue, name string) int64 {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeNumber {
+		return 0
+	}
+	n, _ := v.Integer()
+	return n
+}
+
 func main() {
 	lambda.Start(elasticSearchSyncHandler)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+type DynamoDBStreamEvent events.DynamoDBEvent
+
+/*
+groupStatsHandler keeps the imageCount, lastImageAt and coverImageId of the groups up to date with
+the Images table. Like elasticSearchSync, it reads the stream of the table.
+
+Only the images whose file was uploaded are counted, the pending ones may never get one and expire.
+So an image is added by the INSERT of an image that is not pending, eg an imported one, or by the MODIFY
+that makes it uploaded. It is removed by a REMOVE, unless it was still pending.
+
+When we fail, Lambda retries the whole batch, so the records before the one that failed are delivered
+again. The businessLogic only counts a record once, so that is safe
+*/
+func groupStatsHandler(e DynamoDBStreamEvent) error {
+	gs := groups.NewGroupStats(groupsAccess.NewRepo(), imagesAccess.NewRepo())
+
+	for _, record := range e.Records {
+		groupId := stringAttr(record.Change.Keys, "groupId")
+		timestamp := stringAttr(record.Change.Keys, "timestamp")
+
+		oldItem, newItem := record.Change.OldImage, record.Change.NewImage
+
+		var err error
+		switch {
+		case record.EventName == "INSERT" && counted(newItem), record.EventName == "MODIFY" && !counted(oldItem) && counted(newItem):
+			err = gs.RecordImageAdded(record.EventID, groupId, stringAttr(newItem, "imageId"), timestamp)
+		case record.EventName == "REMOVE" && counted(oldItem):
+			err = gs.RecordImageRemoved(record.EventID, groupId, timestamp)
+		default:
+			continue //the other changes don't change how many uploaded images a group has, or which one is the newest
+		}
+
+		if err != nil {
+			log.Printf("Failed to process %s of image %s in group %s: Error message was %s", record.EventName, timestamp, groupId, err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+counted tells if an item of the stream is an image we count, because its file was uploaded. The images
+created before we had statuses have none, and they were all uploaded
+*/
+func counted(image map[string]events.DynamoDBAttributeValue) bool {
+	return stringAttr(image, "status") != string(models.ImageStatusPending)
+}
+
+// stringAttr returns a string attribute of a stream image, or "" when it is not there
+func stringAttr(image map[string]events.DynamoDBAttributeValue, name string) string {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeString {
+		return ""
+	}
+	return v.String()
+}
+
+func main() {
+	lambda.Start(groupStatsHandler)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+func abortMultipartUploadHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	// Parse imageId and uploadId variables from request url
+	imageId := req.PathParameters["imageId"]
+	uploadId := req.PathParameters["uploadId"]
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
+
+	//This also deletes the parts that were uploaded
+	if err := ia.AbortMultipartUpload(auth.GetUserId(req.RequestContext), imageId, uploadId); err != nil {
+		log.Printf("Failed to abort upload: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	return Response{
+		StatusCode: 204,
+		Body:       "",
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(abortMultipartUploadHandler)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type AddMemberResponse struct {
+	Member models.Membership `json:"newItem"`
+}
+
+// addMemberHandler adds a user to a group, or changes their role if they are already a member. Only owners can do this
+func addMemberHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	// Parse and validate request body
+	addReq := &requests.AddMemberRequest{}
+	if err := requests.Decode(req.Body, addReq); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	member, err := ga.AddMember(auth.GetUserId(req.RequestContext), gId, addReq)
+	if err != nil {
+		log.Printf("Failed to add member: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&AddMemberResponse{
+		member,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 201,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(addMemberHandler)
+}
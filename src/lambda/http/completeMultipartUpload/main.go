@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type CompleteMultipartUploadResponse struct {
+	Image models.Image `json:"item"`
+}
+
+func completeMultipartUploadHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse imageId and uploadId variables from request url
+	imageId := req.PathParameters["imageId"]
+	uploadId := req.PathParameters["uploadId"]
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
+
+	//S3 puts the parts together and tells the images topic, the image is pending until resizeImage gets it
+	item, err := ia.CompleteMultipartUpload(auth.GetUserId(req.RequestContext), imageId, uploadId)
+	if err != nil {
+		log.Printf("Failed to complete upload: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&CompleteMultipartUploadResponse{
+		item,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(completeMultipartUploadHandler)
+}
@@ -8,8 +8,15 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
 	"github.com/udacity/serverless-golang/src/businessLogic/groups"
 	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/idempotency"
 	"github.com/udacity/serverless-golang/src/models"
 	"github.com/udacity/serverless-golang/src/requests"
 )
@@ -25,31 +32,38 @@ func createGroupHandler(ctx context.Context, req Request) (Response, error) {
 	e, _ := json.MarshalIndent(req, "", " ")
 	log.Printf("Processing Event: %s", e)
 
+	// The user that sent the request will own the group
+	userId := auth.GetUserId(req.RequestContext)
+
+	//a retry with the same Idempotency-Key gets the response of the first request instead of a second group
+	resp := idempotency.Handle(idempotencyAccess.NewRepo(), events.APIGatewayProxyRequest(req), userId, func() events.APIGatewayProxyResponse {
+		return events.APIGatewayProxyResponse(createGroup(req, userId))
+	})
+
+	return Response(resp), nil
+}
+
+func createGroup(req Request, userId string) Response {
 	var buf bytes.Buffer
 
 	// Initialize CreateGroupRequest
 	group := &requests.CreateGroupRequest{}
 
-	groupsRepo := groupsAccess.NewDynamoDbRepo()
-	ga := groups.NewGroupAccess(groupsRepo)
+	// Parse and validate request body
+	if err := requests.Decode(req.Body, group); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err))
+	}
 
-	// Parse request body
-	json.Unmarshal([]byte(req.Body), group)
+	groupsRepo := groupsAccess.NewRepo()
+	ga := groups.NewGroupAccess(groupsRepo, membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
 
-	newItem, err := ga.CreateGroup(group)
+	newItem, err := ga.CreateGroup(userId, group)
 	if err != nil {
 
-		log.Fatalf("Failed to create new item: Error message was %s", err.Error())
+		log.Printf("Failed to create new item: Error message was %s", err.Error())
 		// Error HTTP response
-		resp := Response{
-			StatusCode: 500,
-			Body:       err.Error(),
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-
-		return resp, nil
+		return Response(apperrors.Response(err))
 	} else {
 		//body, _ := json.Marshal(group)
 		body, _ := json.Marshal(&CreateGroupResponse{
@@ -65,7 +79,7 @@ func createGroupHandler(ctx context.Context, req Request) (Response, error) {
 				"Access-Control-Allow-Origin": "*",
 			},
 		}
-		return resp, nil
+		return resp
 	}
 }
 
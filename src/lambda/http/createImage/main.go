@@ -4,97 +4,76 @@ import (
 	"bytes"
 	"encoding/json"
 	"log"
-	"os"
-	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-xray-sdk-go/xray"
-	uuid "github.com/satori/go.uuid"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/idempotencyAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/idempotency"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
 )
 
 type Response events.APIGatewayProxyResponse
 type Request events.APIGatewayProxyRequest
 
-type Image struct {
-	ImageId   string `json:"imageId"`
-	GroupId   string `json:"groupId"`
-	Title     string `json:"title"`
-	Timestamp string `json:"timestamp"`
-	ImageUrl  string `json:"imageUrl"`
-}
-
 type createImageResponse struct {
-	Image     Image  `json:"newItem"`
-	UploadUrl string `json:"uploadUrl"`
-}
-
-var (
-	ddb *dynamodb.DynamoDB
-	s3s *s3.S3
-	gTb = aws.String(os.Getenv("GROUPS_TABLE"))
-	iTb = aws.String(os.Getenv("IMAGES_TABLE"))
-	bn  = os.Getenv("IMAGES_S3_BUCKET")
-)
-
-func init() {
-	svc := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
-	ddb = dynamodb.New(svc)                   // Create DynamoDB client
-	s3s = s3.New(svc)                         // Create S3 service client
-
-	xray.AWS(ddb.Client)
-	xray.AWS(s3s.Client)
+	Image models.Image `json:"newItem"`
+	models.ImageUpload
 }
 
 func createImageHandler(req Request) (Response, error) {
-	var buf bytes.Buffer
-
 	r, _ := json.MarshalIndent(req, "", " ")
 	log.Printf("Caller Request: %s", r)
 
 	// Parse groupId variable from request url
 	gId := req.PathParameters["groupId"]
 
-	c := make(chan bool)
-	nIc := make(chan Image)
-
-	go groupExists(gId, c)
-
-	validGroupId := <-c
+	// Parse and validate request body
+	imgReq := &requests.CreateImageRequest{}
+	if err := requests.Decode(req.Body, imgReq); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
 
-	if !validGroupId {
+	userId := auth.GetUserId(req.RequestContext)
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
 
-		body, _ := json.Marshal(map[string]interface{}{
-			"error": "Group does not exist",
-		})
-		json.HTMLEscape(&buf, body)
+	//a retry with the same Idempotency-Key gets the response of the first request instead of a second image
+	resp := idempotency.Handle(idempotencyAccess.NewRepo(), events.APIGatewayProxyRequest(req), userId, func() events.APIGatewayProxyResponse {
+		return events.APIGatewayProxyResponse(createImage(ia, userId, gId, imgReq))
+	})
 
-		return Response{
-			StatusCode: 404,
-			Body:       buf.String(),
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}, nil
+	//the upload url we stored may have expired since, the client needs a new one to upload the image
+	if resp.StatusCode == 200 && resp.Headers[idempotency.ReplayedHeader] == "true" {
+		resp = withNewUpload(ia, userId, resp)
 	}
 
-	/* We are sure that our group does exist at this point */
-	imageId := uuid.Must(uuid.NewV4(), nil).String() //create a new id
-
-	go createImage(gId, imageId, req, nIc)
+	return Response(resp), nil
+}
 
-	nIt := <-nIc
+// Only the editors and owners of a group can add images to it, see images.ImageAccess
+func createImage(ia images.ImageAccess, userId string, gId string, imgReq *requests.CreateImageRequest) Response {
+	var buf bytes.Buffer
 
-	url, _ := getUploadUrl(imageId)
+	image, upload, err := ia.CreateImage(userId, gId, imgReq)
+	if err != nil {
+		log.Printf("Failed to create new item: Error message was %s", err.Error())
+		return Response(apperrors.Response(err))
+	}
 
 	body, _ := json.Marshal(&createImageResponse{
-		nIt,
-		url,
+		image,
+		upload,
 	})
 	json.HTMLEscape(&buf, body)
 
@@ -105,71 +84,31 @@ func createImageHandler(req Request) (Response, error) {
 			"Content-Type":                "application/json",
 			"Access-Control-Allow-Origin": "*",
 		},
-	}, nil
-
-}
-
-func groupExists(gId string, c chan bool) {
-	// Build the query input parameters
-	params := &dynamodb.GetItemInput{
-		Key: map[string]*dynamodb.AttributeValue{
-			"id": {
-				S: aws.String(gId),
-			},
-		},
-		TableName: gTb,
-	}
-
-	// Make the DynamoDB Query API call
-	//https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.GetItem
-	rslt, _ := ddb.GetItem(params)
-
-	if rslt.Item != nil {
-		c <- true // we know group exist
-	} else {
-		c <- false // if not item is returned, we know the group does not exist
 	}
 }
 
-func createImage(groupId string, imageId string, event Request, c chan Image) {
-	// Initialize group
-	newItem := &Image{
-		ImageId:   imageId,
-		Timestamp: time.Now().String(),
-		GroupId:   groupId,
-		ImageUrl:  "https://" + bn + ".s3.amazonaws.com/" + imageId,
-	}
-
-	// Parse request body
-	json.Unmarshal([]byte(event.Body), newItem)
-
-	e, _ := json.MarshalIndent(newItem, "", " ")
-	log.Printf("Storing new item: %s", e)
+// withNewUpload signs a new upload for the image of a response we replay. The upload urls expire after 5 minutes
+func withNewUpload(ia images.ImageAccess, userId string, resp events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	var buf bytes.Buffer
 
-	// Write the new item to DynamoDB database
-	item, _ := dynamodbattribute.MarshalMap(newItem)
-	p := &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: iTb,
+	var body createImageResponse
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		log.Printf("Failed to read the replayed response: Error message was %s", err.Error())
+		return resp
 	}
 
-	if _, err := ddb.PutItem(p); err != nil {
-		log.Fatalf("Failed to create new item: Error message was %s", err.Error())
+	upload, err := ia.NewUpload(userId, body.Image.ImageId)
+	if err != nil {
+		log.Printf("Failed to sign the upload url: Error message was %s", err.Error())
+		return resp
 	}
+	body.ImageUpload = upload
 
-	c <- *newItem
-}
-
-func getUploadUrl(imageId string) (string, error) {
-	//More on PutObject here https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.PutObjectRequest
-	req, _ := s3s.PutObjectRequest(&s3.PutObjectInput{
-		Bucket: aws.String(bn),
-		Key:    aws.String(imageId),
-	})
-
-	urlStr, err := req.Presign(5 * time.Minute)
+	b, _ := json.Marshal(&body)
+	json.HTMLEscape(&buf, b)
+	resp.Body = buf.String()
 
-	return urlStr, err
+	return resp
 }
 
 func main() {
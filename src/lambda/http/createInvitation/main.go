@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type CreateInvitationResponse struct {
+	Invitation models.Invitation `json:"newItem"`
+	Token      string            `json:"token"` // what the owner shares with the people they invite. We can't show it again later
+}
+
+// createInvitationHandler creates an invitation to join a group. Only owners can do this
+func createInvitationHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	// Parse and validate request body
+	createReq := &requests.CreateInvitationRequest{}
+	if err := requests.Decode(req.Body, createReq); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	invitation, token, err := ga.CreateInvitation(auth.GetUserId(req.RequestContext), gId, createReq)
+	if err != nil {
+		log.Printf("Failed to create invitation: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&CreateInvitationResponse{
+		invitation,
+		token,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 201,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(createInvitationHandler)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+func deleteGroupHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	// Like updateGroup, the client can send the ETag it got so it does not delete changes it has not seen
+	version, ok := groups.VersionFromIfMatch(requests.Header(req.Headers, "If-Match"))
+	if !ok {
+		return Response(apperrors.ResponseWithStatus(412, apperrors.Conflict("If-Match does not match the current version of the group"))), nil
+	}
+
+	gd := groups.NewGroupDeleter(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo(), imagesAccess.NewRepo(), cleanupsAccess.NewRepo())
+
+	//This also removes its members, invitations and tags, and starts the cleanup of each of its images
+	err := gd.DeleteGroup(auth.GetUserId(req.RequestContext), gId, version)
+	if groupsAccess.IsConflict(err) && version != groups.AnyVersion {
+		//somebody else changed the group since the client got the ETag it sent us. Without If-Match this is a 409
+		return Response(apperrors.ResponseWithStatus(412, err)), nil
+	}
+	if err != nil {
+		log.Printf("Failed to delete item: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	return Response{
+		StatusCode: 204,
+		Body:       "",
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(deleteGroupHandler)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/notificationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/searchAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+func deleteImageHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	// Parse imageId variable from request url
+	imageId := req.PathParameters["imageId"]
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	id := images.NewImageDeleter(imagesAccess.NewRepo(), cleanupsAccess.NewRepo(), searchAccess.NewRepo(), notificationsAccess.NewRepo(), ga)
+
+	//This also removes its files from both S3 buckets and its search document. What fails is retried by retryImageCleanups
+	if err := id.DeleteImage(auth.GetUserId(req.RequestContext), imageId); err != nil {
+		log.Printf("Failed to delete image: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	return Response{
+		StatusCode: 204,
+		Body:       "",
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(deleteImageHandler)
+}
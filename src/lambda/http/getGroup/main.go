@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type GetGroupResponse struct {
+	Group models.Group `json:"item"`
+}
+
+/*
+getGroupHandler returns a group with its version in the ETag header. Clients send it back in the
+If-Match header of updateGroup and deleteGroup so they don't overwrite changes they have not seen
+*/
+func getGroupHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	// Like getImages, anybody can get a public or unlisted group but only members can get a private one
+	group, err := ga.GetVisibleGroup(auth.GetOptionalUserId(events.APIGatewayProxyRequest(req)), gId)
+	if err != nil {
+		log.Printf("Failed to get group: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	etag := groups.ETag(group)
+
+	//the client already has this version, so there is no need to send it again
+	if requests.Header(req.Headers, "If-None-Match") == etag {
+		return Response{
+			StatusCode: 304,
+			Headers: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Expose-Headers": "ETag",
+				"ETag":                          etag,
+			},
+		}, nil
+	}
+
+	body, _ := json.Marshal(&GetGroupResponse{
+		group,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                  "application/json",
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "ETag", //browsers only let the client read the headers we expose
+			"ETag":                          etag,
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(getGroupHandler)
+}
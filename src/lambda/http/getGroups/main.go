@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
-	"net/url"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
 	"github.com/udacity/serverless-golang/src/businessLogic/groups"
 	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
 )
 
 type Request events.APIGatewayProxyRequest
@@ -22,12 +25,7 @@ func GetGroupsHandler(ctx context.Context, req Request) (Response, error) {
 	log.Println("GetGroups")
 	var buf bytes.Buffer
 
-	queryParams := req.QueryStringParameters
-	if len(queryParams) == 0 {
-		log.Println("Undefined queryString parameters")
-		//the client gets 502(Bad Gateway) error returned but the error returned will be logged to cloudwatch
-		return Response{StatusCode: 400}, errors.New("bad request")
-	}
+	queryParams := req.QueryStringParameters //nil when there is no query string, then we use the defaults
 
 	var nextKey string // Next key to continue scan operation if necessary
 	nk, ok := queryParams["nextKey"]
@@ -47,21 +45,45 @@ func GetGroupsHandler(ctx context.Context, req Request) (Response, error) {
 
 	if limit <= 0 {
 		log.Println("Limit parameter should be positive")
-		return Response{StatusCode: 400}, errors.New("bad request")
+		return Response(apperrors.Response(apperrors.Invalid("limit should be positive"))), nil
 	}
 
-	groupsRepo := groupsAccess.NewDynamoDbRepo()
-	ga := groups.NewGroupAccess(groupsRepo)
+	// newest (the default) or oldest groups first
+	order, err := groupsAccess.ParseSortOrder(queryParams["sort"])
+	if err != nil {
+		log.Printf("Invalid sort: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
 
-	groups, nk := ga.GetAllGroups(limit, nextKey)
+	// ?tag=x only lists the public groups with that tag
+	tag, byTag := queryParams["tag"]
+	if byTag && !models.ValidTag(models.NormalizeTag(tag)) {
+		log.Printf("Invalid tag: %q", tag)
+		return Response(apperrors.Response(apperrors.Invalid("tag is not a valid tag"))), nil
+	}
+
+	groupsRepo := groupsAccess.NewRepo()
+	ga := groups.NewGroupAccess(groupsRepo, membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	var groups []models.Group
+	if byTag {
+		groups, nk, err = ga.GetGroupsByTag(tag, limit, nextKey, order)
+	} else {
+		groups, nk, err = ga.GetAllGroups(limit, nextKey, order)
+	}
+	if err != nil {
+		//an invalid nextKey is a 400 like any other validation error
+		log.Printf("Failed to get groups: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
 
 	// Success HTTP response
 	body, err := json.Marshal(map[string]interface{}{
 		"items":   groups,
-		"nextKey": url.QueryEscape(nk),
+		"nextKey": nextKeyValue(nk),
 	})
 	if err != nil {
-		return Response{StatusCode: 404}, err
+		return Response(apperrors.Response(err)), nil
 	}
 
 	json.HTMLEscape(&buf, body)
@@ -77,6 +99,14 @@ func GetGroupsHandler(ctx context.Context, req Request) (Response, error) {
 	return resp, nil
 }
 
+// nextKeyValue is the nextKey we send to the client. It is null when there are no more items to return
+func nextKeyValue(nk string) *string {
+	if nk == "" {
+		return nil
+	}
+	return &nk
+}
+
 func main() {
 	lambda.Start(GetGroupsHandler)
 }
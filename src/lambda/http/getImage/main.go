@@ -4,91 +4,53 @@ import (
 	"bytes"
 	"encoding/json"
 	"log"
-	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
 )
 
 type Response events.APIGatewayProxyResponse
 type Request events.APIGatewayProxyRequest
 
-type Image struct {
-	ImageId   string `json:"imageId"`
-	GroupId   string `json:"groupId"`
-	Title     string `json:"title"`
-	Timestamp string `json:"timestamp"`
-}
-
-var (
-	ddb          *dynamodb.DynamoDB
-	imageIdTable = aws.String(os.Getenv("IMAGE_ID_INDEX"))
-	imageTable   = aws.String(os.Getenv("IMAGES_TABLE"))
-)
-
-func init() {
-	svc := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
-	ddb = dynamodb.New(svc)                   // Create DynamoDB client
-	xray.AWS(ddb.Client)
-}
-
 func getImageHandler(req Request) (Response, error) {
 	var buf bytes.Buffer
 
 	r, _ := json.MarshalIndent(req, "", " ")
 	log.Printf("Caller Request: %s", r)
 
-	// Parse groupId variable from request url
+	// Parse imageId variable from request url
 	mId := req.PathParameters["imageId"]
 
-	c := make(chan []map[string]*dynamodb.AttributeValue)
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
 
-	go func() {
-		p := &dynamodb.QueryInput{
-			TableName:              imageTable,
-			IndexName:              imageIdTable,
-			KeyConditionExpression: aws.String("imageId = :imageId"),
-			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-				":imageId": {
-					S: aws.String(mId),
-				},
-			},
-		}
-
-		// Make the DynamoDB Query API call
-		rslt, _ := ddb.Query(p)
-		c <- rslt.Items
-	}() //imageId string
-
-	results := <-c
-
-	if len(results) > 0 {
-		item := Image{}
-
-		dynamodbattribute.UnmarshalMap(results[0], &item)
-		body, _ := json.Marshal(item)
-		json.HTMLEscape(&buf, body)
-
-		return Response{
-			StatusCode: 200,
-			Body:       buf.String(),
-			Headers: map[string]string{
-				"Content-Type":                "application/json",
-				"Access-Control-Allow-Origin": "*",
-			},
-		}, nil
+	//the image of a private group is only visible to its members. For everybody else it does not exist
+	item, err := ia.GetImage(auth.GetOptionalUserId(events.APIGatewayProxyRequest(req)), mId)
+	if err != nil {
+		log.Printf("Failed to get the image: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
 	}
 
+	body, _ := json.Marshal(item)
+	json.HTMLEscape(&buf, body)
+
 	return Response{
-		StatusCode: 404,
-		Body:       "",
+		StatusCode: 200,
+		Body:       buf.String(),
 		Headers: map[string]string{
-			"Access-Control-Allow-Origin": "*",
+			"Content-Type":                  "application/json",
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "ETag",            //browsers only let the client read the headers we expose
+			"ETag":                          images.ETag(item), //sent back in If-Match by PATCH /images/{imageId}
 		},
 	}, nil
 }
@@ -3,44 +3,27 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
-	"os"
+	"strconv"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/requests"
 )
 
 type Response events.APIGatewayProxyResponse
 type Request events.APIGatewayProxyRequest
 
-type Image struct {
-	ImageId   string `json:"imageId"`
-	GroupId   string `json:"groupId"`
-	Title     string `json:"title"`
-	Timestamp string `json:"timestamp"`
-}
-
-type getImagesResponse struct {
-	Images []Image `json:"items"`
-}
-
-var (
-	ddb *dynamodb.DynamoDB
-	gTb = aws.String(os.Getenv("GROUPS_TABLE"))
-	iTb = aws.String(os.Getenv("IMAGES_TABLE"))
-)
-
-func init() {
-	svc := session.Must(session.NewSession()) // Use aws sdk to connect to dynamoDB
-	ddb = dynamodb.New(svc)                   // Create DynamoDB client
-	xray.AWS(ddb.Client)
-}
-
 func getImagesHandler(req Request) (Response, error) {
 	var buf bytes.Buffer
 
@@ -50,39 +33,73 @@ func getImagesHandler(req Request) (Response, error) {
 	// Parse groupId variable from request url
 	gId := req.PathParameters["groupId"]
 
-	c := make(chan bool)
-	ic := make(chan []Image)
+	queryParams := req.QueryStringParameters //nil when there is no query string, then we use the defaults
+
+	nextKey := queryParams["nextKey"] // Next key to continue the query if necessary
 
-	go groupExists(gId, c)
+	var limit int64 // Maximum number of images to return
+	if ql, ok := queryParams["limit"]; !ok {
+		limit = 20 //default limit is 20 if not lmit is given
+	} else {
+		//convert string to int64
+		fmt.Sscan(ql, &limit)
+	}
 
-	validGroupId := <-c
+	if limit <= 0 {
+		log.Println("Limit parameter should be positive")
+		return Response(apperrors.Response(apperrors.Invalid("limit should be positive"))), nil
+	}
 
-	if !validGroupId {
+	// newest (the default) or oldest images first
+	order, err := groupsAccess.ParseSortOrder(queryParams["order"])
+	if err != nil {
+		log.Printf("Invalid order: %s", err.Error())
+		return Response(apperrors.Response(apperrors.Invalid("order must be newest or oldest"))), nil
+	}
 
-		body, _ := json.Marshal(map[string]interface{}{
-			"error": "Group does not exist",
-		})
-		json.HTMLEscape(&buf, body)
+	// createdAt (the default) or takenAt, the time the photos were taken. The order and time range apply to it
+	by, err := imagesAccess.ParseSortBy(queryParams["sort"])
+	if err != nil {
+		log.Printf("Invalid sort: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
 
-		return Response{
-			StatusCode: 404,
-			Body:       buf.String(),
-			Headers: map[string]string{
-				"Content-Type":                "application/json",
-				"Access-Control-Allow-Origin": "*",
-			},
-		}, nil
+	// ?from=2021-05-01&to=2021-05-31 only lists the images created in May, or taken in May with ?sort=takenAt
+	from, to, err := requests.ParseTimeRange(queryParams["from"], queryParams["to"])
+	if err != nil {
+		log.Printf("Invalid time range: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
 	}
 
-	/* We are sure that our group does exist at this point */
-	go getImagesPerGroup(gId, ic)
+	// ?pending=true also lists the images whose file was not uploaded yet. Only the editors of the group can ask for them
+	includePending := false
+	if qp, ok := queryParams["pending"]; ok {
+		if includePending, err = strconv.ParseBool(qp); err != nil {
+			log.Printf("Invalid pending: %s", err.Error())
+			return Response(apperrors.Response(apperrors.Invalid("pending must be true or false"))), nil
+		}
+	}
 
-	imgs := <-ic
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
+
+	// Anybody can see the images of public and unlisted groups, but only members can see the images of a private group
+	imgs, nk, err := ia.GetImages(auth.GetOptionalUserId(events.APIGatewayProxyRequest(req)), gId, imagesAccess.TimeRange{From: from, To: to}, limit, nextKey, order, by, includePending)
+	if err != nil {
+		//an invalid nextKey is a 400 like any other validation error
+		log.Printf("Failed to get the images: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
 
 	// Success HTTP response
-	body, _ := json.Marshal(&getImagesResponse{
-		Images: imgs,
+	body, err := json.Marshal(map[string]interface{}{
+		"items":   imgs,
+		"nextKey": nextKeyValue(nk),
 	})
+	if err != nil {
+		return Response(apperrors.Response(err)), nil
+	}
+
 	json.HTMLEscape(&buf, body)
 
 	resp := Response{
@@ -97,57 +114,12 @@ func getImagesHandler(req Request) (Response, error) {
 	return resp, nil
 }
 
-func groupExists(gId string, c chan bool) {
-	// Build the query input parameters
-	params := &dynamodb.GetItemInput{
-		Key: map[string]*dynamodb.AttributeValue{
-			"id": {
-				S: aws.String(gId),
-			},
-		},
-		TableName: gTb,
+// nextKeyValue is the nextKey we send to the client. It is null when there are no more images to return
+func nextKeyValue(nk string) *string {
+	if nk == "" {
+		return nil
 	}
-
-	// Make the DynamoDB Query API call
-	//https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.GetItem
-	rslt, _ := ddb.GetItem(params)
-
-	if rslt.Item != nil {
-		c <- true // we know group exist
-	} else {
-		c <- false // if not item is returned, we know the group does not exist
-	}
-}
-
-func getImagesPerGroup(gId string, c chan []Image) {
-
-	p := &dynamodb.QueryInput{
-		TableName:              iTb,
-		KeyConditionExpression: aws.String("groupId = :groupId"), //we specify that we want all the images that has the the groupId we want
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":groupId": {
-				S: aws.String(gId),
-			},
-			//more on how to use other atttibute types here https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue#Unmarshal
-		},
-		ScanIndexForward: aws.Bool(false), //it reverses the order of the list. The latest images will be first
-	}
-
-	// Make the DynamoDB Query API call
-	rslt, _ := ddb.Query(p)
-
-	// Construct todos from response
-	var imgs []Image
-	for _, i := range rslt.Items {
-		img := Image{}
-		if err := dynamodbattribute.UnmarshalMap(i, &img); err != nil {
-			log.Println("Failed to unmarshal")
-			log.Println(err)
-		}
-		imgs = append(imgs, img)
-	}
-
-	c <- imgs
+	return &nk
 }
 
 func main() {
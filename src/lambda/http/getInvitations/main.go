@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type GetInvitationsResponse struct {
+	Invitations []models.Invitation `json:"items"`
+}
+
+// getInvitationsHandler lists the invitations of a group that can still be redeemed. Only owners can see them
+func getInvitationsHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	invitations, err := ga.GetInvitations(auth.GetUserId(req.RequestContext), gId)
+	if err != nil {
+		log.Printf("Failed to get invitations: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&GetInvitationsResponse{
+		invitations,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(getInvitationsHandler)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type GetTagsResponse struct {
+	Tags []models.TagCount `json:"items"`
+}
+
+// getTagsHandler returns the tag cloud: how many public groups have each tag, the most used tags first
+func getTagsHandler(ctx context.Context, req Request) (Response, error) {
+	log.Println("GetTags")
+	var buf bytes.Buffer
+
+	limit := 50 //default limit is 50 if no limit is given
+	if ql, ok := req.QueryStringParameters["limit"]; ok {
+		fmt.Sscan(ql, &limit)
+	}
+
+	if limit <= 0 {
+		log.Println("Limit parameter should be positive")
+		return Response(apperrors.Response(apperrors.Invalid("limit should be positive"))), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	tags, err := ga.GetTagCloud(limit)
+	if err != nil {
+		log.Printf("Failed to get tags: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&GetTagsResponse{
+		tags,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(getTagsHandler)
+}
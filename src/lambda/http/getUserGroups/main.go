@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// GetUserGroupsHandler returns the groups created by the user that sent the request
+func GetUserGroupsHandler(ctx context.Context, req Request) (Response, error) {
+	log.Println("GetUserGroups")
+	var buf bytes.Buffer
+
+	userId := auth.GetUserId(req.RequestContext)
+
+	queryParams := req.QueryStringParameters
+
+	nextKey := queryParams["nextKey"] // Next key to continue the query if necessary
+
+	var limit int64 = 20 // Maximum number of elements to return. Default limit is 20 if no limit is given
+	if ql, ok := queryParams["limit"]; ok {
+		//convert string to int64
+		fmt.Sscan(ql, &limit)
+	}
+
+	if limit <= 0 {
+		log.Println("Limit parameter should be positive")
+		return Response(apperrors.Response(apperrors.Invalid("limit should be positive"))), nil
+	}
+
+	groupsRepo := groupsAccess.NewRepo()
+	ga := groups.NewGroupAccess(groupsRepo, membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	groups, nk, err := ga.GetUserGroups(userId, limit, nextKey)
+	if err != nil {
+		//an invalid nextKey is a 400 like any other validation error
+		log.Printf("Failed to get user groups: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	// Success HTTP response
+	body, _ := json.Marshal(map[string]interface{}{
+		"items":   groups,
+		"nextKey": nextKeyValue(nk),
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+// nextKeyValue is the nextKey we send to the client. It is null when there are no more items to return
+func nextKeyValue(nk string) *string {
+	if nk == "" {
+		return nil
+	}
+	return &nk
+}
+
+func main() {
+	lambda.Start(GetUserGroupsHandler)
+}
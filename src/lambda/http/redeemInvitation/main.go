@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type RedeemInvitationResponse struct {
+	Member models.Membership `json:"item"`
+}
+
+/*
+redeemInvitationHandler makes the caller a member of the group of an invitation. The token is in
+the body and not in the url so that it does not end up in our access logs
+*/
+func redeemInvitationHandler(ctx context.Context, req Request) (Response, error) {
+	logged := req
+	logged.Body = "<redacted>" //anybody that reads the token can join the group, so we keep it out of our logs too
+	e, _ := json.MarshalIndent(logged, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse and validate request body
+	redeemReq := &requests.RedeemInvitationRequest{}
+	if err := requests.Decode(req.Body, redeemReq); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	member, err := ga.RedeemInvitation(auth.GetUserId(req.RequestContext), redeemReq.Token)
+	if err != nil {
+		log.Printf("Failed to redeem invitation: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&RedeemInvitationResponse{
+		member,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(redeemInvitationHandler)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type UpdateGroupResponse struct {
+	Group models.Group `json:"item"`
+}
+
+func updateGroupHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse groupId variable from request url
+	gId := req.PathParameters["groupId"]
+
+	// Initialize UpdateGroupRequest
+	update := &requests.UpdateGroupRequest{}
+
+	// Parse and validate request body
+	if err := requests.Decode(req.Body, update); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	// The client sends the ETag it got from GET /groups/{groupId} so it does not overwrite changes it has not seen
+	version, ok := groups.VersionFromIfMatch(requests.Header(req.Headers, "If-Match"))
+	if !ok {
+		return Response(apperrors.ResponseWithStatus(412, apperrors.Conflict("If-Match does not match the current version of the group"))), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+
+	item, err := ga.UpdateGroup(auth.GetUserId(req.RequestContext), gId, version, update)
+	if groupsAccess.IsConflict(err) && version != groups.AnyVersion {
+		//somebody else changed the group since the client got the ETag it sent us. Without If-Match this is a 409
+		return Response(apperrors.ResponseWithStatus(412, err)), nil
+	}
+	if err != nil {
+		log.Printf("Failed to update item: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	/*
+		A new visibility is only given to the group once the files of its images have it. Tagging them all
+		can take longer than we have for a big group, so retryImageCleanups does it and we answer with
+		the visibility still pending
+	*/
+	status := 200
+	if item.PendingVisibility != "" {
+		status = 202
+	}
+
+	body, _ := json.Marshal(&UpdateGroupResponse{
+		item,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: status,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                  "application/json",
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "ETag",            //browsers only let the client read the headers we expose
+			"ETag":                          groups.ETag(item), //so the client can make another change without getting the group again
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(updateGroupHandler)
+}
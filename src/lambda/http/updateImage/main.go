@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/auth"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+	"github.com/udacity/serverless-golang/src/models"
+	"github.com/udacity/serverless-golang/src/requests"
+)
+
+type Response events.APIGatewayProxyResponse
+type Request events.APIGatewayProxyRequest
+
+type UpdateImageResponse struct {
+	Image models.Image `json:"item"`
+}
+
+func updateImageHandler(ctx context.Context, req Request) (Response, error) {
+	e, _ := json.MarshalIndent(req, "", " ")
+	log.Printf("Processing Event: %s", e)
+
+	var buf bytes.Buffer
+
+	// Parse imageId variable from request url
+	mId := req.PathParameters["imageId"]
+
+	// Initialize UpdateImageRequest
+	update := &requests.UpdateImageRequest{}
+
+	// Parse and validate request body
+	if err := requests.Decode(req.Body, update); err != nil {
+		log.Printf("Invalid request: %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	// The client sends the ETag it got from GET /images/{imageId} so it does not overwrite changes it has not seen
+	version, ok := groups.VersionFromIfMatch(requests.Header(req.Headers, "If-Match"))
+	if !ok {
+		return Response(apperrors.ResponseWithStatus(412, apperrors.Conflict("If-Match does not match the current version of the image"))), nil
+	}
+
+	ga := groups.NewGroupAccess(groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo())
+	ia := images.NewImageAccess(imagesAccess.NewRepo(), ga)
+
+	item, err := ia.UpdateImage(auth.GetUserId(req.RequestContext), mId, version, update)
+	if errors.Is(err, imagesAccess.ErrImageChanged) && version != groups.AnyVersion {
+		//somebody else changed the image since the client got the ETag it sent us. Without If-Match this is a 409
+		return Response(apperrors.ResponseWithStatus(412, err)), nil
+	}
+	if err != nil {
+		log.Printf("Failed to update item: Error message was %s", err.Error())
+		return Response(apperrors.Response(err)), nil
+	}
+
+	body, _ := json.Marshal(&UpdateImageResponse{
+		item,
+	})
+	json.HTMLEscape(&buf, body)
+
+	return Response{
+		StatusCode: 200,
+		Body:       buf.String(),
+		Headers: map[string]string{
+			"Content-Type":                  "application/json",
+			"Access-Control-Allow-Origin":   "*",
+			"Access-Control-Expose-Headers": "ETag",            //browsers only let the client read the headers we expose
+			"ETag":                          images.ETag(item), //so the client can make another change without getting the image again
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(updateImageHandler)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"image"
+	"image/gif" // createImage lets clients upload gifs and pngs too, see images.UploadLimits
 	"image/jpeg"
+	"image/png"
 	"io/ioutil"
 	"log"
 	"os"
@@ -16,6 +18,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/nfnt/resize"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/exif"
+	"github.com/udacity/serverless-golang/src/models"
 )
 
 type s3Event events.S3Event
@@ -25,11 +32,17 @@ var (
 	thumbnailBucketName = os.Getenv("THUMBNAILS_S3_BUCKET")
 	imagesBucketName    = os.Getenv("IMAGES_S3_BUCKET")
 	s3Client            *s3.S3
+	imagesRepo          imagesAccess.Repository
+	statuses            images.ImageStatuses // we move the images from uploaded to processed or failed, see models.ImageStatus
+	originals           images.Originals     // we strip the metadata of the originals, see models.OriginalsPolicy
 )
 
 func init() {
 	svc := session.Must(session.NewSession())
 	s3Client = s3.New(svc)
+	imagesRepo = imagesAccess.NewRepo()
+	statuses = images.NewImageStatuses(imagesRepo)
+	originals = images.NewOriginals(imagesRepo, groupsAccess.NewRepo(), images.OriginalsPolicyFromEnv())
 }
 
 func main() {
@@ -67,9 +80,32 @@ func processImage(e events.S3EventRecord, c chan string) {
 		Bucket: aws.String(imagesBucketName),
 		Key:    aws.String(key),
 	})
+	sendErr := req.Send()
+	if sendErr == nil && imagesAccess.IsStripped(resp.Metadata) {
+		//we wrote this file ourselves when we stripped the one the client uploaded, which we already processed
+		resp.Body.Close()
+		c <- key
+		return
+	}
 
-	if err := req.Send(); err != nil {
-		fmt.Print(err)
+	//the file is here, so the image is not pending anymore
+	_, err := statuses.SetStatus(key, models.ImageStatusUploaded)
+	if err == imagesAccess.ErrImageNotFound {
+		//the image was deleted or expired before its file came. Nobody can see this file so we delete it
+		log.Printf("Image %s does not exist, deleting its file", key)
+		if err := imagesRepo.DeleteOriginal(key); err != nil {
+			log.Printf("Failed to delete the file of image %s: Error message was %s", key, err.Error())
+		}
+		c <- key
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to set image %s uploaded: Error message was %s", key, err.Error())
+	}
+
+	if sendErr != nil {
+		fmt.Print(sendErr)
+		setStatus(key, models.ImageStatusFailed)
 		c <- key
 		return
 	}
@@ -77,23 +113,54 @@ func processImage(e events.S3EventRecord, c chan string) {
 	s3ObjectBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Print(err)
+		setStatus(key, models.ImageStatusFailed)
 		c <- key
 		return
 	}
 
 	fmt.Print("Resizing image")
 	//reset format of data []byte to image.Image
-	img, _, err := image.Decode(bytes.NewReader(s3ObjectBytes))
+	img, format, err := image.Decode(bytes.NewReader(s3ObjectBytes))
 	if err != nil {
 		fmt.Print(err)
+		setStatus(key, models.ImageStatusFailed) //most likely not an image at all
+		c <- key
+		return
+	}
+
+	//the decoder drops the metadata, so we read it from the file ourselves
+	meta, err := exif.Read(s3ObjectBytes)
+	if err != nil {
+		log.Printf("Failed to read the metadata of image %s, keeping what was read: Error message was %s", key, err.Error())
+	}
+	//the position is only kept when the group wants it. When we can't tell, it is not
+	keepLocation, err := originals.KeepsLocation(key)
+	if err != nil {
+		log.Printf("Failed to read the originals policy of image %s, leaving its location out: Error message was %s", key, err.Error())
+	}
+	bounds := img.Bounds()
+	if _, err := statuses.SetMetadata(key, images.NewImageMetadata(bounds.Dx(), bounds.Dy(), meta, keepLocation)); err != nil {
+		log.Printf("Failed to save the metadata of image %s: Error message was %s", key, err.Error())
+	}
+
+	//anybody can download the original, so it must not tell where the photo was taken
+	if err := stripOriginal(key, s3ObjectBytes, img, format); err != nil {
+		log.Printf("Failed to strip the metadata of image %s: Error message was %s", key, err.Error())
+		//its original still has the position, so nobody must download it
+		if err := originals.Hide(key); err != nil {
+			log.Printf("Failed to hide the original of image %s: Error message was %s", key, err.Error())
+		}
+		setStatus(key, models.ImageStatusFailed)
 		c <- key
 		return
 	}
+
 	newImage := resize.Resize(150, 0, img, resize.Lanczos2)
 	buf := new(bytes.Buffer)
 	//convert our image.Image into a buffer
 	if err := jpeg.Encode(buf, newImage, nil); err != nil {
 		fmt.Print(err)
+		setStatus(key, models.ImageStatusFailed)
 		c <- key
 		return
 	}
@@ -107,11 +174,55 @@ func processImage(e events.S3EventRecord, c chan string) {
 	})
 	if err != nil {
 		fmt.Printf("failed to write image back to bucket. Error: %s", err)
+		setStatus(key, models.ImageStatusFailed)
 		c <- key
 		return
 	}
 
 	fmt.Print(res.String() + "\n")
+	setStatus(key, models.ImageStatusProcessed)
 	c <- key
 
 }
+
+/*
+stripOriginal writes the original of an image again without its metadata. The parts of the file we
+keep are copied as they are. When exif.Strip can't read the file we encode the pixels again instead,
+which loses the orientation, and a little quality for a JPEG
+*/
+func stripOriginal(imageId string, file []byte, img image.Image, format string) error {
+	stripped, err := exif.Strip(file)
+	if err != nil {
+		log.Printf("Failed to strip image %s, encoding it again: Error message was %s", imageId, err.Error())
+
+		buf := new(bytes.Buffer)
+		switch format {
+		case "png":
+			err = png.Encode(buf, img)
+		case "gif":
+			err = gif.Encode(buf, img, nil) //only the first frame of an animation
+		default:
+			err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 95})
+		}
+		if err != nil {
+			return err
+		}
+		stripped = buf.Bytes()
+	}
+
+	replaced, err := originals.Replace(imageId, file, stripped)
+	if err != nil {
+		return err
+	}
+	if replaced {
+		fmt.Printf("Stripped the metadata of image %s\n", imageId)
+	}
+	return nil
+}
+
+// setStatus gives an image its status once we are done with its file. The file is already there, so we only log the errors
+func setStatus(imageId string, status models.ImageStatus) {
+	if _, err := statuses.SetStatus(imageId, status); err != nil {
+		log.Printf("Failed to set image %s %s: Error message was %s", imageId, status, err.Error())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+)
+
+type ScheduledEvent events.CloudWatchEvent
+
+// maxExpired is how many images we expire per run, so a run always ends before the function times out
+const maxExpired = 100
+
+// defaultTtl is how long an image can stay pending when PENDING_IMAGE_TTL is not set
+const defaultTtl = 24 * time.Hour
+
+/*
+The PENDING_IMAGE_TTL(eg "24h") of the stage. It must be longer than the upload urls of createImage
+last, otherwise we could expire an image while its file is being uploaded
+*/
+var ttl = func() time.Duration {
+	s := os.Getenv("PENDING_IMAGE_TTL")
+	if s == "" {
+		return defaultTtl
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid PENDING_IMAGE_TTL %q. Using %s", s, defaultTtl)
+		return defaultTtl
+	}
+	return d
+}()
+
+/*
+expirePendingImagesHandler runs every hour. It deletes the images whose file never came, eg because
+the client gave up after createImage, so nobody sees a broken image forever
+*/
+func expirePendingImagesHandler(e ScheduledEvent) error {
+	is := images.NewImageStatuses(imagesAccess.NewRepo())
+
+	expired, err := is.ExpirePending(ttl, maxExpired)
+	if err != nil {
+		log.Printf("Failed to get the pending images: Error message was %s", err.Error())
+		return err
+	}
+
+	log.Printf("Expired %d images that were pending for more than %s", expired, ttl)
+	return nil
+}
+
+func main() {
+	lambda.Start(expirePendingImagesHandler)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/udacity/serverless-golang/src/businessLogic/groups"
+	"github.com/udacity/serverless-golang/src/businessLogic/images"
+	"github.com/udacity/serverless-golang/src/dataLayer/cleanupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/groupsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/imagesAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/invitationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/membershipsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/notificationsAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/searchAccess"
+	"github.com/udacity/serverless-golang/src/dataLayer/tagsAccess"
+)
+
+type ScheduledEvent events.CloudWatchEvent
+
+// maxCleanups is how many cleanups we retry per run, so a run always ends before the function times out
+const maxCleanups = 100
+
+/*
+retryImageCleanupsHandler runs every few minutes. It goes on with the deletes of groups and images that
+failed half way, eg because S3 or the search index was down when deleteImage ran. The groups go first:
+what is left of a group includes storing the cleanups of its images, which we then delete right away.
+
+It also gives the groups the visibility their owner asked for with updateGroup, once the files of their
+images are tagged for it. That can take a while for a big group, so updateGroup leaves it to us
+*/
+func retryImageCleanupsHandler(e ScheduledEvent) error {
+	groupsRepo, membersRepo, invitesRepo, tagsRepo := groupsAccess.NewRepo(), membershipsAccess.NewRepo(), invitationsAccess.NewRepo(), tagsAccess.NewRepo()
+	imagesRepo, cleanupsRepo := imagesAccess.NewRepo(), cleanupsAccess.NewRepo()
+
+	gd := groups.NewGroupDeleter(groupsRepo, membersRepo, invitesRepo, tagsRepo, imagesRepo, cleanupsRepo)
+	groupsDone, err := gd.RetryCleanups(maxCleanups)
+	if err != nil {
+		log.Printf("Failed to get the group cleanups to retry: Error message was %s", err.Error())
+		return err
+	}
+	log.Printf("Finished %d group cleanups", groupsDone)
+
+	ga := groups.NewGroupAccess(groupsRepo, membersRepo, invitesRepo, tagsRepo)
+	id := images.NewImageDeleter(imagesRepo, cleanupsRepo, searchAccess.NewRepo(), notificationsAccess.NewRepo(), ga)
+
+	done, err := id.RetryCleanups(maxCleanups)
+	if err != nil {
+		log.Printf("Failed to get the cleanups to retry: Error message was %s", err.Error())
+		return err
+	}
+
+	log.Printf("Finished %d image cleanups", done)
+
+	changed, err := groups.NewVisibilityChanges(groupsRepo, tagsRepo, imagesRepo).RetryPending(maxCleanups)
+	if err != nil {
+		log.Printf("Failed to get the pending visibility changes: Error message was %s", err.Error())
+		return err
+	}
+
+	log.Printf("Finished %d visibility changes", changed)
+	return nil
+}
+
+func main() {
+	lambda.Start(retryImageCleanupsHandler)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"log"
 	"os"
-	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -13,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/udacity/serverless-golang/src/models"
 )
 
 type Response events.APIGatewayProxyResponse
@@ -42,7 +42,7 @@ func connectHandler(req Request) (Response, error) {
 
 	// Parse connectionID from websocketrequest url
 	cId := req.RequestContext.ConnectionID
-	timestamp := time.Now().String() //or req.RequestContext.RequestTime
+	timestamp := models.NewTimestamp() //or req.RequestContext.RequestTime
 
 	conn := UserConn{
 		cId,
@@ -1,8 +1,102 @@
 package models
 
+// Who can see a group and its images
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"   // anybody can find it in the list of groups and see its images
+	VisibilityUnlisted Visibility = "unlisted" // anybody with the link can see it, but it is not in the list of groups
+	VisibilityPrivate  Visibility = "private"  // only its members can see it
+)
+
+// Valid tells if v is one of our visibilities
+func (v Visibility) Valid() bool {
+	return v == VisibilityPublic || v == VisibilityUnlisted || v == VisibilityPrivate
+}
+
+/*
+What resizeImage does with the originals of a group. Either way they lose their location and the other
+metadata that can tell who took them, see exif.Strip. The stage sets the policy of the groups that have none
+*/
+type OriginalsPolicy string
+
+const (
+	OriginalsStrip           OriginalsPolicy = "strip"           // only the stripped original is kept
+	OriginalsKeepPrivateCopy OriginalsPolicy = "keepPrivateCopy" // the file as it was uploaded is kept too, in a bucket nobody can read
+)
+
+// Valid tells if p is one of our policies
+func (p OriginalsPolicy) Valid() bool {
+	return p == OriginalsStrip || p == OriginalsKeepPrivateCopy
+}
+
+/*
+KeepsLocation tells if we may store where the photos were taken, see ImageMetadata. Only a group that
+keeps the files as they were uploaded opted in to keeping the position, the others want it gone
+*/
+func (p OriginalsPolicy) KeepsLocation() bool {
+	return p == OriginalsKeepPrivateCopy
+}
+
 type Group struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Timestamp   string `json:"timestamp"`
+	Id          string     `json:"id"`
+	UserId      string     `json:"userId"` // the user that created the group
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+	Version     int64      `json:"version"`        // goes up by one on every write. The groups created before we had versions are at 0
+	Tags        []string   `json:"tags,omitempty"` // normalized, sorted and without duplicates. See NormalizeTags
+	Timestamp   string     `json:"timestamp"`
+
+	// Empty for the groups that follow the policy of the stage, see GetOriginalsPolicy
+	OriginalsPolicy OriginalsPolicy `json:"originalsPolicy,omitempty"`
+
+	/*
+		The visibility the owner asked for, while the files of the images are tagged for it. The group
+		only gets it once they all are, so a group is never said to be private while its files are not
+	*/
+	PendingVisibility Visibility `json:"pendingVisibility,omitempty"`
+
+	/*
+		What is in the group: only the images whose file was uploaded. These are kept up to date from the
+		Images stream(see the groupStats Lambda), so they can be a little behind right after an image is
+		uploaded or removed. They are not part of the version: members never write them
+	*/
+	ImageCount   int64  `json:"imageCount"`
+	LastImageAt  string `json:"lastImageAt,omitempty"`  // the timestamp of the newest image
+	CoverImageId string `json:"coverImageId,omitempty"` // the newest image
+}
+
+// GetVisibility returns the visibility of the group. The groups created before we had visibilities are public
+func (g Group) GetVisibility() Visibility {
+	if g.Visibility == "" {
+		return VisibilityPublic
+	}
+	return g.Visibility
+}
+
+// GetOriginalsPolicy returns the policy of the group, or the one of the stage when the group has none
+func (g Group) GetOriginalsPolicy(stage OriginalsPolicy) OriginalsPolicy {
+	if g.OriginalsPolicy == "" {
+		return stage
+	}
+	return g.OriginalsPolicy
+}
+
+// Listed tells if the group shows up in the list of all groups
+func (g Group) Listed() bool {
+	return g.GetVisibility() == VisibilityPublic
+}
+
+// Private tells if only the members of the group can see it and its images
+func (g Group) Private() bool {
+	return g.GetVisibility() == VisibilityPrivate
+}
+
+/*
+ImagesPrivate tells if the new files of the group must be tagged visibility=private. While the group
+is becoming private they must, or a file uploaded after its images were tagged would stay public
+*/
+func (g Group) ImagesPrivate() bool {
+	return g.Private() || g.PendingVisibility == VisibilityPrivate
 }
@@ -0,0 +1,37 @@
+package models
+
+/*
+A GroupCleanup is a group we are deleting. Besides the group itself, that is its members, its
+invitations, its tags and every one of its images. Like an ImageCleanup, we store it before we
+start so that whatever is left can be retried later, and nothing about the group is orphaned
+*/
+type GroupCleanup struct {
+	GroupId string `json:"groupId"`
+	Group   Group  `json:"group"` // as it was when the owner deleted it. We need its version and its tags
+	/*
+		What is left to do, in order. Like the steps of an ImageCleanup, every one of them can be
+		done again without harm
+	*/
+	Steps     []GroupCleanupStep `json:"steps"`
+	Attempts  int                `json:"attempts"`
+	LastError string             `json:"lastError,omitempty"`
+	RetryAt   string             `json:"retryAt"` // a timestamp. The cleanup is not retried before it
+	CreatedAt string             `json:"createdAt"`
+}
+
+// A GroupCleanupStep is one of the things we do to delete a group
+type GroupCleanupStep string
+
+const (
+	GroupCleanupRow         GroupCleanupStep = "row"         // delete the group from the Groups table, if it is still at the version we read
+	GroupCleanupMembers     GroupCleanupStep = "members"     // delete its memberships
+	GroupCleanupInvitations GroupCleanupStep = "invitations" // delete its invitations
+	GroupCleanupTags        GroupCleanupStep = "tags"        // take it out of the tag index
+	GroupCleanupImages      GroupCleanupStep = "images"      // store an ImageCleanup for every one of its images
+)
+
+/*
+GroupCleanupSteps are all the steps of a group cleanup. The row goes first: it is the only step that
+can be refused, when somebody changed the group since we read it, and then we must not do the others
+*/
+var GroupCleanupSteps = []GroupCleanupStep{GroupCleanupRow, GroupCleanupMembers, GroupCleanupInvitations, GroupCleanupTags, GroupCleanupImages}
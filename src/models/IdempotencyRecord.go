@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+/*
+An IdempotencyRecord remembers a request that was sent with an Idempotency-Key header, and the response
+we sent for it. When the client retries with the same key, we send the same response again instead
+of creating another group or image
+*/
+type IdempotencyRecord struct {
+	Key         string            `json:"key"`                  // the user that sent the request and their Idempotency-Key
+	RequestHash string            `json:"requestHash"`          // a hash of the method, path and body of the request
+	StatusCode  int               `json:"statusCode,omitempty"` // 0 while the request is still being handled
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	LockedUntil int64             `json:"lockedUntil"` // unix time in seconds. Until then, nobody else can handle a request with this key
+	ExpiresAt   int64             `json:"expiresAt"`   // unix time in seconds. It is also the TTL attribute of our Idempotency table
+}
+
+// Completed tells if we already have the response of the request
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Expired tells if the record should be treated as if it was not there anymore. DynamoDB deletes the expired records, but can take up to 48 hours
+func (r IdempotencyRecord) Expired(now time.Time) bool {
+	return now.Unix() >= r.ExpiresAt
+}
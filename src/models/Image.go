@@ -0,0 +1,96 @@
+package models
+
+/*
+Where the file of an image is. createImage writes the row before anything is uploaded, so an image
+starts pending. The S3 -> SNS pipeline moves it on when the file arrives, see the resizeImage Lambda
+*/
+type ImageStatus string
+
+const (
+	ImageStatusPending   ImageStatus = "pending"   // created, but the client has not uploaded the file yet
+	ImageStatusUploaded  ImageStatus = "uploaded"  // the file is in the images bucket, the thumbnail is on its way
+	ImageStatusProcessed ImageStatus = "processed" // the thumbnail is ready too
+	ImageStatusFailed    ImageStatus = "failed"    // the file is there but we could not make a thumbnail, eg it is not an image
+)
+
+// An Image is a picture in a group. Its file is stored in the images bucket under its ImageId
+type Image struct {
+	ImageId   string `json:"imageId"`
+	GroupId   string `json:"groupId"`
+	Title     string `json:"title"`
+	Timestamp string `json:"timestamp"`
+	/*
+		Where anybody can download the image. The bucket does not let anybody read the images of a
+		private group, so the images service replaces it with a link that expires for their members
+	*/
+	ImageUrl string `json:"imageUrl"`
+
+	/*
+		What the client said it would upload when it created the image. The upload is signed for this
+		type and size only. Images created before createImage asked for them don't have them
+	*/
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+
+	/*
+		The images created before we had statuses have none, see GetStatus. An image became pending at
+		its Timestamp, the other times are when it last got that status
+	*/
+	Status      ImageStatus `json:"status,omitempty"`
+	UploadedAt  string      `json:"uploadedAt,omitempty"`
+	ProcessedAt string      `json:"processedAt,omitempty"`
+	FailedAt    string      `json:"failedAt,omitempty"`
+
+	// The multipart upload of the file that is in progress, if any. See MultipartUpload
+	Upload *MultipartUpload `json:"multipartUpload,omitempty"`
+
+	// What resizeImage read from the file. Its fields are at the top level of the image, like the others
+	ImageMetadata
+
+	// What PATCH /images/{imageId} can change, with the Title. They are empty until somebody sets them
+	Description string   `json:"description,omitempty"`
+	AltText     string   `json:"altText,omitempty"` // describes the image for screen readers
+	Tags        []string `json:"tags,omitempty"`    // normalized like the tags of a group, see NormalizeTag
+	Version     int64    `json:"version"`           // goes up by one on every update. An image that was never updated is at 0
+}
+
+/*
+ImageMetadata is what resizeImage reads from the file of an image once it is uploaded, see the exif
+package. The fields the file does not have are empty, and they are all empty until it is processed
+*/
+type ImageMetadata struct {
+	Width       int       `json:"width,omitempty"`   // in pixels, before the Orientation is applied
+	Height      int       `json:"height,omitempty"`  // in pixels
+	TakenAt     string    `json:"takenAt,omitempty"` // when the photo was taken, as a timestamp
+	CameraMake  string    `json:"cameraMake,omitempty"`
+	CameraModel string    `json:"cameraModel,omitempty"`
+	Orientation int       `json:"orientation,omitempty"` // how to rotate and flip the image to show it upright, 1 to 8 like the EXIF tag
+	Location    *GeoPoint `json:"location,omitempty"`    // where the photo was taken, when the group keeps it(see OriginalsPolicy.KeepsLocation). Only its members see it
+}
+
+// A GeoPoint is a position in decimal degrees
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// GetStatus returns the status of the image. The images created before we had statuses were all uploaded and processed
+func (i Image) GetStatus() ImageStatus {
+	if i.Status == "" {
+		return ImageStatusProcessed
+	}
+	return i.Status
+}
+
+/*
+An ImageUpload is how the client uploads the file of an image, once its Image was created: a
+multipart/form-data POST to the Url with all the Fields, and then the file in a field named "file"
+*/
+type ImageUpload struct {
+	Url string `json:"uploadUrl"`
+	/*
+		The Fields include a policy we signed, so S3 rejects the upload if the client changes any of
+		them, or if the file is not of the content type and size it declared
+	*/
+	Fields map[string]string `json:"uploadFields"`
+}
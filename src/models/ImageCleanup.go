@@ -0,0 +1,35 @@
+package models
+
+/*
+An ImageCleanup is an image we are deleting. Deleting an image touches the Images table, both buckets,
+the search index and the websocket clients, and any of them can fail. We store the cleanup before we
+start so that whatever is left can be retried later, and no object is orphaned
+*/
+type ImageCleanup struct {
+	ImageId   string `json:"imageId"`
+	GroupId   string `json:"groupId"`
+	Timestamp string `json:"timestamp"` // with the GroupId, the key of the image in the Images table
+	/*
+		What is left to do, in order. Every step can be done again without harm, so after a failure we
+		simply start again from the first step that is left
+	*/
+	Steps     []CleanupStep `json:"steps"`
+	Attempts  int           `json:"attempts"`
+	LastError string        `json:"lastError,omitempty"`
+	RetryAt   string        `json:"retryAt"` // a timestamp. The cleanup is not retried before it
+	CreatedAt string        `json:"createdAt"`
+}
+
+// A CleanupStep is one of the things we do to delete an image
+type CleanupStep string
+
+const (
+	CleanupRow       CleanupStep = "row"       // delete the image from the Images table
+	CleanupOriginal  CleanupStep = "original"  // delete the file of the image from the images bucket
+	CleanupThumbnail CleanupStep = "thumbnail" // delete the thumbnail resizeImage made from the thumbnails bucket
+	CleanupSearch    CleanupStep = "search"    // delete the document of the image from the search index
+	CleanupNotify    CleanupStep = "notify"    // tell the websocket clients the image is gone
+)
+
+// CleanupSteps are all the steps of a cleanup. The row goes first so the image disappears from our API right away
+var CleanupSteps = []CleanupStep{CleanupRow, CleanupOriginal, CleanupThumbnail, CleanupSearch, CleanupNotify}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+/*
+An Invitation lets people join a group with a role, even if they don't have an account yet. The owner
+shares a signed token made from the GroupId and Id, and whoever redeems it becomes a member
+*/
+type Invitation struct {
+	Id        string `json:"id"`
+	GroupId   string `json:"groupId"`
+	Role      Role   `json:"role"`      // the role the people who redeem it get
+	MaxUses   int    `json:"maxUses"`   // how many people can redeem it
+	Uses      int    `json:"uses"`      // how many people redeemed it so far
+	ExpiresAt int64  `json:"expiresAt"` // unix time in seconds. It is also the TTL attribute of our Invitations table
+	CreatedBy string `json:"createdBy"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Pending tells if the invitation can still be redeemed at the time now
+func (i Invitation) Pending(now time.Time) bool {
+	return i.Uses < i.MaxUses && now.Unix() < i.ExpiresAt
+}
@@ -0,0 +1,33 @@
+package models
+
+// The role of a user in a group. Each role can do everything the roles below it can do
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // can see the group and its images
+	RoleEditor Role = "editor" // can also add images and change the name and description of the group
+	RoleOwner  Role = "owner"  // can also manage the members and delete the group
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Valid tells if r is one of our roles
+func (r Role) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Includes tells if a user with role r can do what a user with role o can do
+func (r Role) Includes(o Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[o]
+}
+
+type Membership struct {
+	GroupId   string `json:"groupId"`
+	UserId    string `json:"userId"`
+	Role      Role   `json:"role"`
+	Timestamp string `json:"timestamp"`
+}
@@ -0,0 +1,28 @@
+package models
+
+/*
+A MultipartUpload is an upload of the file of an image in parts, for the files that are too big to
+upload in one request on a poor connection. An image has at most one at a time, see Image.Upload.
+
+The parts are all PartSize bytes, except for the last one. S3 keeps the parts that were uploaded,
+so a client that lost its connection asks which parts are there and only uploads the others
+*/
+type MultipartUpload struct {
+	UploadId  string `json:"uploadId"`
+	PartSize  int64  `json:"partSize"`
+	PartCount int64  `json:"partCount"`
+	StartedAt string `json:"startedAt"`
+}
+
+// An UploadedPart is a part of a MultipartUpload that is in S3 already
+type UploadedPart struct {
+	PartNumber int64  `json:"partNumber"` // from 1 to the PartCount of the upload
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"` // S3 needs it to put the parts together
+}
+
+// A PartUpload is how the client uploads one part: a PUT of its bytes to the Url
+type PartUpload struct {
+	PartNumber int64  `json:"partNumber"`
+	Url        string `json:"uploadUrl"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits of the tags of a group. The same as in models/create-group-request.json
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// TagCount is how many public groups have a tag. The tag cloud is a list of them
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+/*
+NormalizeTag gives the form we store a tag in: lowercase, without the # people like to type,
+and with its words joined by dashes. "  #Summer Trip " and "summer-trip" are the same tag
+*/
+func NormalizeTag(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// ValidTag tells if a normalized tag is not empty, not too long, and only has letters, digits and dashes
+func ValidTag(t string) bool {
+	if t == "" || utf8.RuneCountInString(t) > MaxTagLength {
+		return false
+	}
+	for _, r := range t {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTags normalizes every tag, and returns them sorted and without duplicates. It returns nil when there is no tag
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t != "" && !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := map[string]string{
+		"cats":           "cats",
+		"  Cute   Cats ": "cute-cats",
+		"#Kittens":       "kittens",
+		"Éléphants":      "éléphants",
+		"already-a-slug": "already-a-slug",
+		"   ":            "",
+	}
+
+	for in, want := range tests {
+		if got := NormalizeTag(in); got != want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestValidTag(t *testing.T) {
+	valid := []string{"cats", "cute-cats", "2021", "éléphants", strings.Repeat("é", MaxTagLength)}
+	invalid := []string{"", "c@ts", "cats!", "a_b", strings.Repeat("a", MaxTagLength+1)}
+
+	for _, tag := range valid {
+		if !ValidTag(tag) {
+			t.Errorf("ValidTag(%q) = false, want true", tag)
+		}
+	}
+	for _, tag := range invalid {
+		if ValidTag(tag) {
+			t.Errorf("ValidTag(%q) = true, want false", tag)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{"Dogs", "cats", "#cats", " ", "cute cats"})
+	want := []string{"cats", "cute-cats", "dogs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTags = %v, want %v", got, want)
+	}
+
+	if got := NormalizeTags(nil); got != nil {
+		t.Errorf("NormalizeTags(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+TimestampFormat is the format of every timestamp we store, like 2021-05-01T12:00:00.123000000Z.
+
+It is RFC3339 with nanoseconds, so any language can parse it, always in UTC. Unlike time.RFC3339Nano
+we keep the trailing zeros of the nanoseconds: every timestamp has the same length, so they sort as
+strings in the order they happened. We need that because timestamp is the sort key of the Images
+table and of the CreatedAtIndex of the Groups table
+*/
+const TimestampFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// legacyTimestampFormat is what time.Time.String() gives, which is what we used to store
+const legacyTimestampFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// FormatTimestamp formats t in our TimestampFormat
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampFormat)
+}
+
+// NewTimestamp is the timestamp of now
+func NewTimestamp() string {
+	return FormatTimestamp(time.Now())
+}
+
+/*
+ParseTimestamp reads a timestamp in our TimestampFormat, or in any other RFC3339 format. It also reads
+the timestamps we stored with time.Time.String() before, like "2021-05-01 12:00:00.123 +0000 UTC m=+0.01"
+*/
+func ParseTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	//the monotonic clock reading is only meaningful in the process that wrote it
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+
+	return time.Parse(legacyTimestampFormat, s)
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC), "2021-05-01T12:00:00.000000000Z"},
+		{time.Date(2021, 5, 1, 12, 0, 0, 123000000, time.UTC), "2021-05-01T12:00:00.123000000Z"},
+		{time.Date(2021, 5, 1, 14, 0, 0, 5, time.FixedZone("CEST", 2*60*60)), "2021-05-01T12:00:00.000000005Z"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTimestamp(tt.in); got != tt.want {
+			t.Errorf("FormatTimestamp(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTimestampsSortInTheOrderTheyHappened(t *testing.T) {
+	base := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	//time.RFC3339Nano would format these as ...:00Z, ...:00.1Z and ...:00.05Z, which do not sort
+	times := []time.Time{base, base.Add(100 * time.Millisecond), base.Add(50 * time.Millisecond), base.Add(time.Second)}
+
+	var got []string
+	for _, tm := range times {
+		got = append(got, FormatTimestamp(tm))
+	}
+	sort.Strings(got)
+
+	want := []string{FormatTimestamp(times[0]), FormatTimestamp(times[2]), FormatTimestamp(times[1]), FormatTimestamp(times[3])}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sorted timestamps = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2021, 5, 1, 12, 0, 0, 123000000, time.UTC)
+
+	tests := []string{
+		"2021-05-01T12:00:00.123000000Z",
+		"2021-05-01T12:00:00.123Z",
+		"2021-05-01T14:00:00.123+02:00",
+		"2021-05-01 12:00:00.123 +0000 UTC",
+		"2021-05-01 12:00:00.123 +0000 UTC m=+0.010000001",
+		"2021-05-01 05:00:00.123 -0700 PDT m=+3600.5",
+	}
+
+	for _, s := range tests {
+		got, err := ParseTimestamp(s)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q) failed: %v", s, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", s, got, want)
+		}
+	}
+
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Error("ParseTimestamp(yesterday) did not fail")
+	}
+}
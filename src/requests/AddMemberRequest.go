@@ -0,0 +1,21 @@
+package requests
+
+import "github.com/udacity/serverless-golang/src/models"
+
+// The same limit as in models/add-member-request.json
+const MaxUserIdLength = 128
+
+type AddMemberRequest struct {
+	UserId string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+// Validate checks the request against the rules of models/add-member-request.json
+func (a *AddMemberRequest) Validate() error {
+	verr := &ValidationError{}
+
+	checkString(verr, "userId", a.UserId, 1, MaxUserIdLength)
+	checkEnum(verr, "role", a.Role, string(models.RoleViewer), string(models.RoleEditor), string(models.RoleOwner))
+
+	return verr.orNil()
+}
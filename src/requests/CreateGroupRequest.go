@@ -1,6 +1,63 @@
 package requests
 
+import (
+	"fmt"
+
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// The same limits as in models/create-group-request.json
+const (
+	MaxGroupNameLength        = 100
+	MaxGroupDescriptionLength = 1000
+)
+
 type CreateGroupRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Visibility  string   `json:"visibility"` // optional. Groups are public when it is not sent
+	Tags        []string `json:"tags"`       // optional. They are normalized, see models.NormalizeTag
+	// optional. Groups follow the policy of the stage when it is not sent
+	OriginalsPolicy string `json:"originalsPolicy"`
+}
+
+// Validate checks the request against the rules of models/create-group-request.json
+func (c *CreateGroupRequest) Validate() error {
+	verr := &ValidationError{}
+
+	checkString(verr, "name", c.Name, 1, MaxGroupNameLength)
+	checkString(verr, "description", c.Description, 1, MaxGroupDescriptionLength)
+	if c.Visibility != "" {
+		checkVisibility(verr, c.Visibility)
+	}
+	checkTags(verr, c.Tags)
+	if c.OriginalsPolicy != "" {
+		checkOriginalsPolicy(verr, c.OriginalsPolicy)
+	}
+
+	return verr.orNil()
+}
+
+// checkVisibility applies the enum rule of the visibility field of our group schemas
+func checkVisibility(verr *ValidationError, value string) {
+	checkEnum(verr, "visibility", value, string(models.VisibilityPublic), string(models.VisibilityUnlisted), string(models.VisibilityPrivate))
+}
+
+// checkOriginalsPolicy applies the enum rule of the originalsPolicy field of our group schemas
+func checkOriginalsPolicy(verr *ValidationError, value string) {
+	checkEnum(verr, "originalsPolicy", value, string(models.OriginalsStrip), string(models.OriginalsKeepPrivateCopy))
+}
+
+// checkTags applies the rules of the tags field of our group schemas. The rules are for the normalized tags
+func checkTags(verr *ValidationError, tags []string) {
+	if len(tags) > models.MaxTags {
+		verr.add("tags", CodeTooLong, fmt.Sprintf("tags must have at most %d tags", models.MaxTags))
+	}
+
+	for i, t := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		if !models.ValidTag(models.NormalizeTag(t)) {
+			verr.add(field, CodeInvalidValue, fmt.Sprintf("%s must have 1 to %d letters, digits, spaces or dashes", field, models.MaxTagLength))
+		}
+	}
 }
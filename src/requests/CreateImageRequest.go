@@ -0,0 +1,34 @@
+package requests
+
+import "fmt"
+
+// The same limits as in models/create-image-request.json
+const (
+	MaxImageTitleLength       = 200
+	MaxImageContentTypeLength = 100
+	/*
+		The most S3 takes in one upload. Which content types are allowed, and how big an image can
+		really be, is set per stage, see images.UploadLimits
+	*/
+	MaxImageSize int64 = 5 << 30
+)
+
+type CreateImageRequest struct {
+	Title       string `json:"title"`
+	ContentType string `json:"contentType"` // the MIME type of the file the client will upload, eg image/jpeg
+	Size        int64  `json:"size"`        // the size of that file in bytes. S3 rejects a file of another size
+}
+
+// Validate checks the request against the rules of models/create-image-request.json
+func (c *CreateImageRequest) Validate() error {
+	verr := &ValidationError{}
+
+	checkString(verr, "title", c.Title, 1, MaxImageTitleLength)
+	checkString(verr, "contentType", c.ContentType, 1, MaxImageContentTypeLength)
+	//checkInt takes an int, which is too small for the size of a file on 32 bit platforms
+	if c.Size < 1 || c.Size > MaxImageSize {
+		verr.add("size", CodeOutOfRange, fmt.Sprintf("size must be between %d and %d", 1, MaxImageSize))
+	}
+
+	return verr.orNil()
+}
@@ -0,0 +1,26 @@
+package requests
+
+import "github.com/udacity/serverless-golang/src/models"
+
+// The same limits as in models/create-invitation-request.json
+const (
+	MaxInvitationUses  = 1000
+	MaxInvitationHours = 30 * 24
+)
+
+type CreateInvitationRequest struct {
+	Role           string `json:"role"`
+	MaxUses        int    `json:"maxUses"`
+	ExpiresInHours int    `json:"expiresInHours"`
+}
+
+// Validate checks the request against the rules of models/create-invitation-request.json
+func (c *CreateInvitationRequest) Validate() error {
+	verr := &ValidationError{}
+
+	checkEnum(verr, "role", c.Role, string(models.RoleViewer), string(models.RoleEditor), string(models.RoleOwner))
+	checkInt(verr, "maxUses", c.MaxUses, 1, MaxInvitationUses)
+	checkInt(verr, "expiresInHours", c.ExpiresInHours, 1, MaxInvitationHours)
+
+	return verr.orNil()
+}
@@ -0,0 +1,17 @@
+package requests
+
+// The same limit as in models/redeem-invitation-request.json
+const MaxInvitationTokenLength = 1024
+
+type RedeemInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// Validate checks the request against the rules of models/redeem-invitation-request.json
+func (r *RedeemInvitationRequest) Validate() error {
+	verr := &ValidationError{}
+
+	checkString(verr, "token", r.Token, 1, MaxInvitationTokenLength)
+
+	return verr.orNil()
+}
@@ -0,0 +1,27 @@
+package requests
+
+import "fmt"
+
+// The same limits as in models/sign-parts-request.json
+const (
+	MaxSignedParts = 100   // how many parts a client can ask urls for at once
+	MaxPartNumber  = 10000 // the most parts S3 takes in a multipart upload
+)
+
+type SignPartsRequest struct {
+	PartNumbers []int `json:"partNumbers"`
+}
+
+// Validate checks the request against the rules of models/sign-parts-request.json
+func (s *SignPartsRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if len(s.PartNumbers) == 0 || len(s.PartNumbers) > MaxSignedParts {
+		verr.add("partNumbers", CodeOutOfRange, fmt.Sprintf("partNumbers must have between 1 and %d items", MaxSignedParts))
+	}
+	for i, n := range s.PartNumbers {
+		checkInt(verr, fmt.Sprintf("partNumbers[%d]", i), n, 1, MaxPartNumber)
+	}
+
+	return verr.orNil()
+}
@@ -0,0 +1,40 @@
+package requests
+
+/*
+UpdateGroupRequest is the body of a PATCH request. All the fields are optional so we use pointers
+to tell a field that was not sent apart from a field that was sent empty
+*/
+type UpdateGroupRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Visibility  *string   `json:"visibility"`
+	Tags        *[]string `json:"tags"` // replaces all the tags of the group. An empty list removes them
+	// Only applies to the images uploaded from now on. The originals that were already stripped stay stripped
+	OriginalsPolicy *string `json:"originalsPolicy"`
+}
+
+// Validate checks the request against the rules of models/update-group-request.json
+func (u *UpdateGroupRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if u.Name == nil && u.Description == nil && u.Visibility == nil && u.Tags == nil && u.OriginalsPolicy == nil {
+		verr.add("", CodeRequired, "at least one of name, description, visibility, tags or originalsPolicy is required")
+	}
+	if u.Name != nil {
+		checkString(verr, "name", *u.Name, 1, MaxGroupNameLength)
+	}
+	if u.Description != nil {
+		checkString(verr, "description", *u.Description, 1, MaxGroupDescriptionLength)
+	}
+	if u.Visibility != nil {
+		checkVisibility(verr, *u.Visibility)
+	}
+	if u.Tags != nil {
+		checkTags(verr, *u.Tags)
+	}
+	if u.OriginalsPolicy != nil {
+		checkOriginalsPolicy(verr, *u.OriginalsPolicy)
+	}
+
+	return verr.orNil()
+}
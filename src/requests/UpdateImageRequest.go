@@ -0,0 +1,41 @@
+package requests
+
+// The same limits as in models/update-image-request.json
+const (
+	MaxImageDescriptionLength = 1000
+	MaxImageAltTextLength     = 500
+)
+
+/*
+UpdateImageRequest is the body of a PATCH request. Like UpdateGroupRequest, all the fields are
+optional. The description and alt text can be sent empty to remove them
+*/
+type UpdateImageRequest struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	AltText     *string   `json:"altText"`
+	Tags        *[]string `json:"tags"` // replaces all the tags of the image. An empty list removes them
+}
+
+// Validate checks the request against the rules of models/update-image-request.json
+func (u *UpdateImageRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if u.Title == nil && u.Description == nil && u.AltText == nil && u.Tags == nil {
+		verr.add("", CodeRequired, "at least one of title, description, altText or tags is required")
+	}
+	if u.Title != nil {
+		checkString(verr, "title", *u.Title, 1, MaxImageTitleLength)
+	}
+	if u.Description != nil {
+		checkString(verr, "description", *u.Description, 0, MaxImageDescriptionLength)
+	}
+	if u.AltText != nil {
+		checkString(verr, "altText", *u.AltText, 0, MaxImageAltTextLength)
+	}
+	if u.Tags != nil {
+		checkTags(verr, *u.Tags)
+	}
+
+	return verr.orNil()
+}
@@ -0,0 +1,21 @@
+package requests
+
+import "strings"
+
+/*
+Header returns the value of a request header. API Gateway gives us the headers the way the client
+sent them, and http/2 clients send them in lower case, so we can't just index the map
+*/
+func Header(headers map[string]string, name string) string {
+	if v, ok := headers[name]; ok {
+		return v
+	}
+
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,58 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+	"github.com/udacity/serverless-golang/src/models"
+)
+
+// dateFormat is a day without a time, like 2021-05-01
+const dateFormat = "2006-01-02"
+
+/*
+ParseTimeRange reads the from and to query parameters of a listing. Each one is an RFC3339 timestamp
+or a day like 2021-05-01, in UTC. A day as from starts at midnight and a day as to ends at midnight
+the next day, so ?from=2021-05-01&to=2021-05-01 is all of that day.
+
+It returns them in models.TimestampFormat so they compare with our timestamps as strings. An empty
+parameter stays empty, it means there is no bound on that side
+*/
+func ParseTimeRange(from string, to string) (string, string, error) {
+	f, err := parseTimeBound("from", from, false)
+	if err != nil {
+		return "", "", err
+	}
+
+	t, err := parseTimeBound("to", to, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	if f != "" && t != "" && f > t {
+		return "", "", apperrors.Invalid("from should be before to")
+	}
+
+	return f, t, nil
+}
+
+func parseTimeBound(name string, s string, end bool) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	if day, err := time.Parse(dateFormat, s); err == nil {
+		if end {
+			//the last nanosecond of the day, our timestamps don't go further
+			day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return models.FormatTimestamp(day), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return "", apperrors.Invalid(name + " should be an RFC3339 timestamp or a date like 2021-05-01")
+	}
+
+	return models.FormatTimestamp(t), nil
+}
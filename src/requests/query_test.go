@@ -0,0 +1,41 @@
+package requests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		wantTo   string
+		invalid  bool
+	}{
+		{"no bounds", "", "", "", "", false},
+		{"days", "2021-05-01", "2021-05-02", "2021-05-01T00:00:00.000000000Z", "2021-05-02T23:59:59.999999999Z", false},
+		{"one day", "2021-05-01", "2021-05-01", "2021-05-01T00:00:00.000000000Z", "2021-05-01T23:59:59.999999999Z", false},
+		{"timestamps", "2021-05-01T12:00:00Z", "2021-05-01T14:00:00.5+02:00", "2021-05-01T12:00:00.000000000Z", "2021-05-01T12:00:00.500000000Z", false},
+		{"only to", "", "2021-05-01", "", "2021-05-01T23:59:59.999999999Z", false},
+		{"not a time", "yesterday", "", "", "", true},
+		{"from after to", "2021-05-02", "2021-05-01", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := ParseTimeRange(tt.from, tt.to)
+			if tt.invalid {
+				if !errors.Is(err, apperrors.ErrValidation) {
+					t.Errorf("ParseTimeRange(%q, %q) returned %v, want a validation error", tt.from, tt.to, err)
+				}
+				return
+			}
+			if err != nil || from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("ParseTimeRange(%q, %q) = %q, %q, %v, want %q, %q", tt.from, tt.to, from, to, err, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
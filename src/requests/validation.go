@@ -0,0 +1,184 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/udacity/serverless-golang/src/apperrors"
+)
+
+/*
+API Gateway validates our request bodies with the JSON schemas in the models folder, but only
+when the request comes through API Gateway. These are the same rules, checked in our own code,
+so the handlers are safe behind any front end
+*/
+
+// The codes a client can expect to find in a FieldError
+const (
+	CodeInvalidJson  = "invalid_json"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeInvalidValue = "invalid_value"
+	CodeOutOfRange   = "out_of_range"
+)
+
+// A FieldError tells the client what is wrong with one field of the request body
+type FieldError = apperrors.FieldError
+
+// ValidationError has every FieldError we found in a request body
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (v *ValidationError) Error() string {
+	msgs := make([]string, 0, len(v.Errors))
+	for _, e := range v.Errors {
+		msgs = append(msgs, e.Message)
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Is makes a ValidationError an apperrors.ErrValidation, so handlers answer it with a 400
+func (v *ValidationError) Is(target error) bool {
+	return target == apperrors.ErrValidation
+}
+
+// FieldErrors is what apperrors.Response sends the client as the list of wrong fields
+func (v *ValidationError) FieldErrors() []FieldError {
+	return v.Errors
+}
+
+func (v *ValidationError) add(field, code, msg string) {
+	v.Errors = append(v.Errors, FieldError{Field: field, Code: code, Message: msg})
+}
+
+// orNil returns nil when we found nothing wrong so that callers can just check err != nil
+func (v *ValidationError) orNil() error {
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Validator is implemented by every request that has rules on its fields
+type Validator interface {
+	Validate() error
+}
+
+/*
+Decode parses a JSON request body into v and validates it. Just like "additionalProperties": false
+in our JSON schemas, a field that v does not have is an error.
+
+The error is always a *ValidationError
+*/
+func Decode(body string, v Validator) error {
+	verr := &ValidationError{}
+
+	// We first read the body as a plain object so we can report every unknown field, not just the first one
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &raw); err != nil || raw == nil {
+		verr.add("", CodeInvalidJson, "request body must be a JSON object")
+		return verr
+	}
+
+	known := jsonFields(v)
+	for k := range raw {
+		if !known[k] {
+			verr.add(k, CodeUnknownField, fmt.Sprintf("%s is not allowed", k))
+		}
+	}
+
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		if terr, ok := err.(*json.UnmarshalTypeError); ok {
+			verr.add(terr.Field, CodeInvalidType, fmt.Sprintf("%s must be a %s", terr.Field, jsonType(terr.Type)))
+		} else {
+			verr.add("", CodeInvalidJson, err.Error())
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+
+	return v.Validate()
+}
+
+// jsonFields returns the names of the JSON fields of the struct v points to
+func jsonFields(v interface{}) map[string]bool {
+	fields := make(map[string]bool)
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		if name != "-" {
+			fields[name] = true
+		}
+	}
+
+	return fields
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
+
+// checkString applies the minLength and maxLength rules of a JSON schema. Lengths are in characters, not bytes
+func checkString(verr *ValidationError, field, value string, min, max int) {
+	n := utf8.RuneCountInString(value)
+
+	switch {
+	case n == 0 && min > 0:
+		verr.add(field, CodeRequired, fmt.Sprintf("%s is required", field))
+	case n < min:
+		verr.add(field, CodeTooShort, fmt.Sprintf("%s must be at least %d characters", field, min))
+	case n > max:
+		verr.add(field, CodeTooLong, fmt.Sprintf("%s must be at most %d characters", field, max))
+	}
+}
+
+// checkInt applies the minimum and maximum rules of a JSON schema. A number that was not sent is 0, so it is out of range when min > 0
+func checkInt(verr *ValidationError, field string, value, min, max int) {
+	if value < min || value > max {
+		verr.add(field, CodeOutOfRange, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+	}
+}
+
+// checkEnum applies the enum rule of a JSON schema
+func checkEnum(verr *ValidationError, field, value string, allowed ...string) {
+	if value == "" {
+		verr.add(field, CodeRequired, fmt.Sprintf("%s is required", field))
+		return
+	}
+
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	verr.add(field, CodeInvalidValue, fmt.Sprintf("%s must be one of %s", field, strings.Join(allowed, ", ")))
+}
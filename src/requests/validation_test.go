@@ -0,0 +1,187 @@
+package requests
+
+import (
+	"strings"
+	"testing"
+)
+
+func codes(err error) map[string]string {
+	got := make(map[string]string)
+	if verr, ok := err.(*ValidationError); ok {
+		for _, e := range verr.Errors {
+			got[e.Field] = e.Code
+		}
+	}
+	return got
+}
+
+func TestDecodeCreateGroupRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]string // field -> code. Empty means the body is valid
+	}{
+		{"valid", `{"name":"Cats","description":"Pictures of cats"}`, map[string]string{}},
+		{"not json", `name=Cats`, map[string]string{"": CodeInvalidJson}},
+		{"not an object", `["Cats"]`, map[string]string{"": CodeInvalidJson}},
+		{"missing fields", `{}`, map[string]string{"name": CodeRequired, "description": CodeRequired}},
+		{"extra fields", `{"name":"Cats","description":"d","id":"1","userId":"2"}`, map[string]string{"id": CodeUnknownField, "userId": CodeUnknownField}},
+		{"wrong type", `{"name":1,"description":"d"}`, map[string]string{"name": CodeInvalidType}},
+		{"too long", `{"name":"` + strings.Repeat("é", MaxGroupNameLength+1) + `","description":"d"}`, map[string]string{"name": CodeTooLong}},
+		{"private", `{"name":"Cats","description":"d","visibility":"private"}`, map[string]string{}},
+		{"unknown visibility", `{"name":"Cats","description":"d","visibility":"secret"}`, map[string]string{"visibility": CodeInvalidValue}},
+		{"private copies", `{"name":"Cats","description":"d","originalsPolicy":"keepPrivateCopy"}`, map[string]string{}},
+		{"unknown originals policy", `{"name":"Cats","description":"d","originalsPolicy":"keep"}`, map[string]string{"originalsPolicy": CodeInvalidValue}},
+		{"multibyte at limit", `{"name":"` + strings.Repeat("é", MaxGroupNameLength) + `","description":"d"}`, map[string]string{}},
+		{"tags", `{"name":"Cats","description":"d","tags":["Cute Cats","#kittens"]}`, map[string]string{}},
+		{"tags not a list", `{"name":"Cats","description":"d","tags":"cats"}`, map[string]string{"tags": CodeInvalidType}},
+		{"too many tags", `{"name":"Cats","description":"d","tags":["1","2","3","4","5","6","7","8","9","10","11"]}`, map[string]string{"tags": CodeTooLong}},
+		{"invalid tags", `{"name":"Cats","description":"d","tags":["cats","  ","c@ts","` + strings.Repeat("a", 33) + `"]}`, map[string]string{"tags[1]": CodeInvalidValue, "tags[2]": CodeInvalidValue, "tags[3]": CodeInvalidValue}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Decode(tt.body, &CreateGroupRequest{})
+
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Decode returned %v, want nil", err)
+				}
+				return
+			}
+
+			if _, ok := err.(*ValidationError); !ok {
+				t.Fatalf("Decode returned %T, want *ValidationError", err)
+			}
+			got := codes(err)
+			if len(got) != len(tt.want) {
+				t.Errorf("Decode returned errors %v, want %v", got, tt.want)
+			}
+			for f, c := range tt.want {
+				if got[f] != c {
+					t.Errorf("field %q: got code %q, want %q", f, got[f], c)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeUpdateGroupRequest(t *testing.T) {
+	if err := Decode(`{}`, &UpdateGroupRequest{}); err == nil {
+		t.Error("Decode of an empty update returned nil, want an error")
+	}
+
+	u := &UpdateGroupRequest{}
+	if err := Decode(`{"description":"new"}`, u); err != nil {
+		t.Fatalf("Decode returned %v, want nil", err)
+	}
+	if u.Name != nil || u.Description == nil || *u.Description != "new" {
+		t.Errorf("Decode returned %+v", u)
+	}
+
+	if got := codes(Decode(`{"name":""}`, &UpdateGroupRequest{})); got["name"] != CodeRequired {
+		t.Errorf("Decode of an empty name returned %v", got)
+	}
+
+	if err := Decode(`{"visibility":"unlisted"}`, &UpdateGroupRequest{}); err != nil {
+		t.Errorf("Decode of a visibility only update returned %v, want nil", err)
+	}
+	if got := codes(Decode(`{"visibility":""}`, &UpdateGroupRequest{})); got["visibility"] != CodeRequired {
+		t.Errorf("Decode of an empty visibility returned %v", got)
+	}
+	if err := Decode(`{"originalsPolicy":"strip"}`, &UpdateGroupRequest{}); err != nil {
+		t.Errorf("Decode of an originals policy only update returned %v, want nil", err)
+	}
+
+	u = &UpdateGroupRequest{}
+	if err := Decode(`{"tags":[]}`, u); err != nil || u.Tags == nil {
+		t.Errorf("Decode of an update that removes the tags returned %v, %+v", err, u)
+	}
+	if got := codes(Decode(`{"tags":["ok","not ok!"]}`, &UpdateGroupRequest{})); got["tags[1]"] != CodeInvalidValue {
+		t.Errorf("Decode of an invalid tag returned %v", got)
+	}
+}
+
+func TestDecodeCreateImageRequest(t *testing.T) {
+	if err := Decode(`{"title":"A cat","contentType":"image/jpeg","size":52000}`, &CreateImageRequest{}); err != nil {
+		t.Fatalf("Decode returned %v, want nil", err)
+	}
+
+	//the client has to tell us what it will upload, so S3 can hold it to it
+	got := codes(Decode(`{"title":"A cat"}`, &CreateImageRequest{}))
+	want := map[string]string{"contentType": CodeRequired, "size": CodeOutOfRange}
+	for f, c := range want {
+		if got[f] != c {
+			t.Errorf("field %q: got code %q, want %q", f, got[f], c)
+		}
+	}
+
+	if got := codes(Decode(`{"title":"A cat","contentType":"image/jpeg","size":6000000000}`, &CreateImageRequest{})); got["size"] != CodeOutOfRange {
+		t.Errorf("Decode of a size S3 does not take returned %v", got)
+	}
+}
+
+func TestDecodeUpdateImageRequest(t *testing.T) {
+	if err := Decode(`{}`, &UpdateImageRequest{}); err == nil {
+		t.Error("Decode of an empty update returned nil, want an error")
+	}
+
+	//the description and alt text can be removed, but an image always has a title
+	u := &UpdateImageRequest{}
+	if err := Decode(`{"description":"","altText":""}`, u); err != nil || u.Description == nil || u.AltText == nil {
+		t.Errorf("Decode of an update that removes the description and alt text returned %v, %+v", err, u)
+	}
+	if got := codes(Decode(`{"title":""}`, &UpdateImageRequest{})); got["title"] != CodeRequired {
+		t.Errorf("Decode of an empty title returned %v", got)
+	}
+
+	tests := map[string]string{
+		`{"altText":"` + strings.Repeat("a", MaxImageAltTextLength+1) + `"}`:         "altText",
+		`{"description":"` + strings.Repeat("a", MaxImageDescriptionLength+1) + `"}`: "description",
+	}
+	for body, field := range tests {
+		if got := codes(Decode(body, &UpdateImageRequest{})); got[field] != CodeTooLong {
+			t.Errorf("Decode of a %s that is too long returned %v", field, got)
+		}
+	}
+
+	if got := codes(Decode(`{"tags":["ok","not ok!"]}`, &UpdateImageRequest{})); got["tags[1]"] != CodeInvalidValue {
+		t.Errorf("Decode of an invalid tag returned %v", got)
+	}
+	if got := codes(Decode(`{"title":"A cat","imageUrl":"x"}`, &UpdateImageRequest{})); got["imageUrl"] != CodeUnknownField {
+		t.Errorf("Decode of an update of the imageUrl returned %v", got)
+	}
+}
+
+func TestDecodeCreateInvitationRequest(t *testing.T) {
+	if err := Decode(`{"role":"viewer","maxUses":5,"expiresInHours":48}`, &CreateInvitationRequest{}); err != nil {
+		t.Fatalf("Decode returned %v, want nil", err)
+	}
+
+	got := codes(Decode(`{"role":"admin","expiresInHours":10000}`, &CreateInvitationRequest{}))
+	want := map[string]string{"role": CodeInvalidValue, "maxUses": CodeOutOfRange, "expiresInHours": CodeOutOfRange}
+	for f, c := range want {
+		if got[f] != c {
+			t.Errorf("field %q: got code %q, want %q", f, got[f], c)
+		}
+	}
+
+	if got := codes(Decode(`{"role":"viewer","maxUses":1.5,"expiresInHours":1}`, &CreateInvitationRequest{})); got["maxUses"] != CodeInvalidType {
+		t.Errorf("Decode of a fractional maxUses returned %v", got)
+	}
+}
+
+func TestDecodeSignPartsRequest(t *testing.T) {
+	if err := Decode(`{"partNumbers":[1,2,3]}`, &SignPartsRequest{}); err != nil {
+		t.Fatalf("Decode returned %v, want nil", err)
+	}
+
+	if got := codes(Decode(`{"partNumbers":[]}`, &SignPartsRequest{})); got["partNumbers"] != CodeOutOfRange {
+		t.Errorf("Decode of no parts returned %v", got)
+	}
+	//S3 numbers the parts from 1 to 10000
+	got := codes(Decode(`{"partNumbers":[1,0,10001]}`, &SignPartsRequest{}))
+	if got["partNumbers[1]"] != CodeOutOfRange || got["partNumbers[2]"] != CodeOutOfRange {
+		t.Errorf("Decode of invalid part numbers returned %v", got)
+	}
+}